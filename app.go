@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,9 +20,15 @@ type URLItem struct {
 	Description string    `json:"description"`
 	Category    string    `json:"category"`
 	Tags        []string  `json:"tags"`
+	Keyword     string    `json:"keyword,omitempty"`
+	Favicon     string    `json:"favicon,omitempty"`
+	SourceGUID  string    `json:"sourceGuid,omitempty"` // GUID in the browser it was imported from
 	Order       int       `json:"order"`
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
+
+	Health *LinkHealth    `json:"health,omitempty"` // Latest link check result
+	Watch  *WatchSettings `json:"watch,omitempty"`  // Page change monitoring
 }
 
 // Category represents a URL category
@@ -81,24 +88,62 @@ func (a *App) SaveURLs(urls []URLItem) error {
 	}
 
 	filePath := filepath.Join(a.GetDataDir(), "urls.json")
-	return ioutil.WriteFile(filePath, data, 0644)
+	return writeFileAtomic(filePath, data, 0644)
 }
 
-// AddURL adds a new URL
-func (a *App) AddURL(title, url, description, category string, tags []string) (*URLItem, error) {
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so that readers see either the old or the new contents
+// but never a partly written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := file.Name()
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tempPath, path)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+	}
+	return err
+}
+
+// urlsMutex serializes read-modify-write cycles of urls.json
+var urlsMutex sync.Mutex
+
+// modifyURLs loads the bookmarks, applies fn and saves the slice it
+// returns. Every change to urls.json goes through it so that edits and
+// background results are merged into the latest data instead of
+// overwriting each other.
+func (a *App) modifyURLs(fn func(urls []URLItem) ([]URLItem, error)) error {
+	urlsMutex.Lock()
+	defer urlsMutex.Unlock()
+
 	urls, err := a.GetURLs()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Calculate next order (highest order + 1)
-	order := 0
-	for _, existingURL := range urls {
-		if existingURL.Order >= order {
-			order = existingURL.Order + 1
-		}
+	urls, err = fn(urls)
+	if err != nil {
+		return err
 	}
 
+	return a.SaveURLs(urls)
+}
+
+// AddURL adds a new URL. The favicon and whatever the user left empty are
+// filled in from the page afterwards and sent as a url:metadata event.
+func (a *App) AddURL(title, url, description, category string, tags []string) (*URLItem, error) {
 	newURL := URLItem{
 		ID:          fmt.Sprintf("%d", time.Now().UnixNano()),
 		Title:       title,
@@ -106,63 +151,78 @@ func (a *App) AddURL(title, url, description, category string, tags []string) (*
 		Description: description,
 		Category:    category,
 		Tags:        tags,
-		Order:       order,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	urls = append(urls, newURL)
-	err = a.SaveURLs(urls)
+	err := a.modifyURLs(func(urls []URLItem) ([]URLItem, error) {
+		// Calculate next order (highest order + 1)
+		for _, existingURL := range urls {
+			if existingURL.Order >= newURL.Order {
+				newURL.Order = existingURL.Order + 1
+			}
+		}
+		return append(urls, newURL), nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	go a.fillMetadata(newURL.ID, newURL.URL)
+
 	return &newURL, nil
 }
 
 // UpdateURL updates an existing URL
 func (a *App) UpdateURL(id, title, url, description, category string, tags []string) (*URLItem, error) {
-	urls, err := a.GetURLs()
-	if err != nil {
-		return nil, err
-	}
-
-	for i, urlItem := range urls {
-		if urlItem.ID == id {
-			urls[i].Title = title
-			urls[i].URL = url
-			urls[i].Description = description
-			urls[i].Category = category
-			urls[i].Tags = tags
-			urls[i].UpdatedAt = time.Now()
-
-			err = a.SaveURLs(urls)
-			if err != nil {
-				return nil, err
+	var updated *URLItem
+	err := a.modifyURLs(func(urls []URLItem) ([]URLItem, error) {
+		for i, urlItem := range urls {
+			if urlItem.ID == id {
+				urls[i].Title = title
+				urls[i].URL = url
+				urls[i].Description = description
+				urls[i].Category = category
+				urls[i].Tags = tags
+				urls[i].UpdatedAt = time.Now()
+
+				item := urls[i]
+				updated = &item
+				return urls, nil
 			}
-
-			return &urls[i], nil
 		}
+		return nil, fmt.Errorf("URL with id %s not found", id)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("URL with id %s not found", id)
+	return updated, nil
 }
 
 // DeleteURL deletes a URL by ID
 func (a *App) DeleteURL(id string) error {
-	urls, err := a.GetURLs()
+	// Keep page checks from writing files of the deleted bookmark
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+
+	err := a.modifyURLs(func(urls []URLItem) ([]URLItem, error) {
+		for i, urlItem := range urls {
+			if urlItem.ID == id {
+				return append(urls[:i], urls[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("URL with id %s not found", id)
+	})
 	if err != nil {
 		return err
 	}
 
-	for i, urlItem := range urls {
-		if urlItem.ID == id {
-			urls = append(urls[:i], urls[i+1:]...)
-			return a.SaveURLs(urls)
-		}
-	}
-
-	return fmt.Errorf("URL with id %s not found", id)
+	// Snapshots and watch history are useless without their bookmark
+	os.Remove(a.watchTextPath(id))
+	os.Remove(filepath.Join(a.changesDir(), filepath.Base(id)+".json"))
+	a.removePageContent(id)
+	return os.RemoveAll(a.snapshotDir(id))
 }
 
 // GetCategories returns all categories
@@ -206,7 +266,7 @@ func (a *App) SaveCategories(categories []Category) error {
 	}
 
 	filePath := filepath.Join(a.GetDataDir(), "categories.json")
-	return ioutil.WriteFile(filePath, data, 0644)
+	return writeFileAtomic(filePath, data, 0644)
 }
 
 // AddCategory adds a new category
@@ -239,13 +299,20 @@ func (a *App) SearchURLs(keyword string) ([]URLItem, error) {
 		return nil, err
 	}
 
-	if keyword == "" {
+	// status:broken etc. filter by the latest link check
+	keyword, statuses := extractStatusFilters(keyword)
+
+	if keyword == "" && len(statuses) == 0 {
 		return urls, nil
 	}
 
 	var filtered []URLItem
 	for _, url := range urls {
-		if containsIgnoreCase(url.Title, keyword) ||
+		if !matchesAnyLinkStatus(url.Health, statuses) {
+			continue
+		}
+		if keyword == "" ||
+			containsIgnoreCase(url.Title, keyword) ||
 			containsIgnoreCase(url.Description, keyword) ||
 			containsIgnoreCase(url.URL, keyword) ||
 			containsIgnoreCase(url.Category, keyword) {
@@ -270,26 +337,22 @@ func containsIgnoreCase(str, substr string) bool {
 
 // ReorderURLs updates the order of URLs based on new positions
 func (a *App) ReorderURLs(urlIDs []string) error {
-	urls, err := a.GetURLs()
-	if err != nil {
-		return err
-	}
-
-	// Create a map for quick lookup
-	urlMap := make(map[string]*URLItem)
-	for i := range urls {
-		urlMap[urls[i].ID] = &urls[i]
-	}
-
-	// Update orders based on new positions
-	for newOrder, urlID := range urlIDs {
-		if url, exists := urlMap[urlID]; exists {
-			url.Order = newOrder
-			url.UpdatedAt = time.Now()
+	return a.modifyURLs(func(urls []URLItem) ([]URLItem, error) {
+		// Create a map for quick lookup
+		urlMap := make(map[string]*URLItem)
+		for i := range urls {
+			urlMap[urls[i].ID] = &urls[i]
 		}
-	}
 
-	return a.SaveURLs(urls)
+		// Update orders based on new positions
+		for newOrder, urlID := range urlIDs {
+			if url, exists := urlMap[urlID]; exists {
+				url.Order = newOrder
+				url.UpdatedAt = time.Now()
+			}
+		}
+		return urls, nil
+	})
 }
 
 // AdvancedSearchOptions represents advanced search parameters
@@ -300,7 +363,7 @@ type AdvancedSearchOptions struct {
 	StartDate string   `json:"startDate"`
 	EndDate   string   `json:"endDate"`
 	SortBy    string   `json:"sortBy"`    // title, date, category, frequency
-	SearchIn  []string `json:"searchIn"` // title, description, url
+	SearchIn  []string `json:"searchIn"` // title, description, url, content
 }
 
 // AdvancedSearchURLs performs advanced search with multiple criteria
@@ -363,15 +426,21 @@ func (a *App) matchesAdvancedCriteria(url URLItem, options AdvancedSearchOptions
 		}
 	}
 
+	// Check status:xxx tokens against the latest link check
+	query, statuses := extractStatusFilters(options.Query)
+	if !matchesAnyLinkStatus(url.Health, statuses) {
+		return false
+	}
+
 	// Check search query in specified fields
-	if options.Query != "" {
+	if query != "" {
 		searchFields := options.SearchIn
 		if len(searchFields) == 0 {
 			// Default to all fields if none specified
 			searchFields = []string{"title", "description", "url"}
 		}
 
-		queryLower := strings.ToLower(options.Query)
+		queryLower := strings.ToLower(query)
 		found := false
 
 		for _, field := range searchFields {
@@ -383,9 +452,12 @@ func (a *App) matchesAdvancedCriteria(url URLItem, options AdvancedSearchOptions
 				searchText = strings.ToLower(url.Description)
 			case "url":
 				searchText = strings.ToLower(url.URL)
+			case "content":
+				// Page text is matched by words through the content index
+				found = a.contentMatches(url.ID, query)
 			}
 
-			if strings.Contains(searchText, queryLower) {
+			if found || strings.Contains(searchText, queryLower) {
 				found = true
 				break
 			}
@@ -485,102 +557,92 @@ type ChromeBookmarkRoot struct {
 	Version int `json:"version"`
 }
 
+// ChromeImportOptions controls which parts of a Chrome bookmark file are imported
+type ChromeImportOptions struct {
+	IncludeSynced bool `json:"includeSynced"` // Also import "Mobile bookmarks" synced from phones
+	SkipChecksum  bool `json:"skipChecksum"`  // Accept files whose checksum does not match
+}
+
 // ImportChromeBookmarks imports bookmarks from Chrome JSON format
 func (a *App) ImportChromeBookmarks(jsonData string) (int, error) {
+	return a.ImportChromeBookmarksWithOptions(jsonData, ChromeImportOptions{})
+}
+
+// ImportChromeBookmarksWithOptions imports bookmarks from Chrome JSON format.
+// Bookmarks whose Chrome GUID was imported before are skipped.
+func (a *App) ImportChromeBookmarksWithOptions(jsonData string, options ChromeImportOptions) (int, error) {
 	var chromeData ChromeBookmarkRoot
 	if err := json.Unmarshal([]byte(jsonData), &chromeData); err != nil {
 		return 0, err
 	}
 
-	var importedCount int
-	defaultCategory := "导入"
-
-	// Ensure default category exists
-	categories, _ := a.GetCategories()
-	hasDefaultCategory := false
-	for _, cat := range categories {
-		if cat.Name == defaultCategory {
-			hasDefaultCategory = true
-			break
+	if !options.SkipChecksum && chromeData.Checksum != "" {
+		if checksum := chromeBookmarksChecksum(&chromeData); checksum != chromeData.Checksum {
+			return 0, fmt.Errorf("Chrome bookmark checksum mismatch: file may be corrupted or modified")
 		}
 	}
 
-	if !hasDefaultCategory {
-		a.AddCategory(defaultCategory, "从浏览器导入的书签", "#6366f1")
-	}
+	var bookmarks []importedBookmark
 
-	// Parse bookmark bar
-	if err := a.parseChromeBookmarks(chromeData.Roots.BookmarkBar.Children, defaultCategory, &importedCount); err != nil {
-		return importedCount, err
-	}
+	// Parse bookmark bar and other bookmarks
+	collectChromeBookmarks(chromeData.Roots.BookmarkBar.Children, "", &bookmarks)
+	collectChromeBookmarks(chromeData.Roots.Other.Children, "", &bookmarks)
 
-	// Parse other bookmarks
-	if err := a.parseChromeBookmarks(chromeData.Roots.Other.Children, defaultCategory, &importedCount); err != nil {
-		return importedCount, err
+	// Mobile bookmarks are opt-in
+	if options.IncludeSynced {
+		collectChromeBookmarks(chromeData.Roots.Synced.Children, "", &bookmarks)
 	}
 
-	return importedCount, nil
+	return a.saveImportedBookmarks(bookmarks)
 }
 
-// parseChromeBookmarks recursively parses Chrome bookmark structure
-func (a *App) parseChromeBookmarks(bookmarks []ChromeBookmark, category string, count *int) error {
+// collectChromeBookmarks recursively collects Chrome bookmarks, using the
+// nearest folder name as category
+func collectChromeBookmarks(bookmarks []ChromeBookmark, category string, result *[]importedBookmark) {
 	for _, bookmark := range bookmarks {
 		if bookmark.Type == "url" && bookmark.URL != "" {
-			_, err := a.AddURL(bookmark.Name, bookmark.URL, "", category, []string{})
-			if err != nil {
-				continue // Skip invalid bookmarks
+			createdAt := chromeTime(bookmark.DateAdded)
+			updatedAt := chromeTime(bookmark.DateModified)
+			if updatedAt.IsZero() {
+				updatedAt = createdAt
 			}
-			*count++
+
+			*result = append(*result, importedBookmark{
+				Title:     bookmark.Name,
+				URL:       bookmark.URL,
+				Category:  category,
+				GUID:      bookmark.GUID,
+				CreatedAt: createdAt,
+				UpdatedAt: updatedAt,
+			})
 		} else if bookmark.Type == "folder" && len(bookmark.Children) > 0 {
 			// Use folder name as category or subcategory info
 			folderCategory := category
 			if bookmark.Name != "" {
 				folderCategory = bookmark.Name
 			}
-			a.parseChromeBookmarks(bookmark.Children, folderCategory, count)
+			collectChromeBookmarks(bookmark.Children, folderCategory, result)
 		}
 	}
-	return nil
 }
 
 // ImportNetscapeBookmarks imports bookmarks from Netscape HTML format
 func (a *App) ImportNetscapeBookmarks(htmlData string) (int, error) {
-	var importedCount int
-	defaultCategory := "导入"
-
-	// Ensure default category exists
-	categories, _ := a.GetCategories()
-	hasDefaultCategory := false
-	for _, cat := range categories {
-		if cat.Name == defaultCategory {
-			hasDefaultCategory = true
-			break
-		}
-	}
-
-	if !hasDefaultCategory {
-		a.AddCategory(defaultCategory, "从浏览器导入的书签", "#6366f1")
-	}
-
 	// Parse HTML bookmarks using regex
 	linkRegex := regexp.MustCompile(`<A[^>]+HREF="([^"]+)"[^>]*>([^<]+)</A>`)
 	matches := linkRegex.FindAllStringSubmatch(htmlData, -1)
 
+	var bookmarks []importedBookmark
 	for _, match := range matches {
-		if len(match) >= 3 {
-			url := match[1]
-			title := match[2]
-
-			if url != "" && title != "" {
-				_, err := a.AddURL(title, url, "", defaultCategory, []string{})
-				if err != nil {
-					continue // Skip invalid bookmarks
-				}
-				importedCount++
-			}
+		if len(match) >= 3 && match[1] != "" && match[2] != "" {
+			bookmarks = append(bookmarks, importedBookmark{
+				Title:    match[2],
+				URL:      match[1],
+				Category: importDefaultCategory,
+			})
 		}
 	}
 
-	return importedCount, nil
+	return a.saveImportedBookmarks(bookmarks)
 }
 
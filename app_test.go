@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// setTestHome points the home directory, and with it the data directory,
+// at an empty temporary directory. Windows reads USERPROFILE instead of
+// HOME.
+func setTestHome(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	return home
+}
+
+func TestConcurrentURLWritersKeepEveryChange(t *testing.T) {
+	setTestHome(t)
+	a := NewApp()
+
+	first, err := a.AddURL("First", "urlnav-test:first", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := a.AddURL(fmt.Sprintf("Item %d", i), fmt.Sprintf("urlnav-test:%d", i), "", "", nil); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			err := a.saveLinkHealth(map[string]LinkHealth{first.ID: {Status: linkStatusOK}}, 5)
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	urls, err := a.GetURLs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != writers+1 {
+		t.Fatalf("got %d bookmarks, want %d", len(urls), writers+1)
+	}
+	if urls[0].Health == nil || len(urls[0].Health.History) != 5 {
+		t.Errorf("health of first bookmark = %+v, want 5 history entries", urls[0].Health)
+	}
+
+	orders := make(map[int]bool)
+	for _, item := range urls {
+		if orders[item.Order] {
+			t.Errorf("order %d used twice", item.Order)
+		}
+		orders[item.Order] = true
+	}
+}
+
+func TestImportNetscapeBookmarks(t *testing.T) {
+	setTestHome(t)
+	a := NewApp()
+
+	const links = 2000
+	var html strings.Builder
+	html.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n<DL><p>\n")
+	for i := 0; i < links; i++ {
+		fmt.Fprintf(&html, "<DT><A HREF=\"https://example.com/%d\" ADD_DATE=\"1700000000\">Link %d</A>\n", i, i)
+	}
+	html.WriteString("<DT><A HREF=\"https://example.com/untitled\"></A>\n</DL><p>\n")
+
+	count, err := a.ImportNetscapeBookmarks(html.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != links {
+		t.Errorf("imported %d bookmarks, want %d", count, links)
+	}
+
+	urls, err := a.GetURLs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != links || urls[0].Title != "Link 0" || urls[0].Category != importDefaultCategory {
+		t.Errorf("got %d bookmarks, first %+v", len(urls), urls[0])
+	}
+}
+
+func TestReadersNeverSeePartialURLs(t *testing.T) {
+	setTestHome(t)
+	a := NewApp()
+
+	urls := make([]URLItem, 500)
+	for i := range urls {
+		urls[i] = URLItem{ID: fmt.Sprint(i), Title: strings.Repeat("t", 100), URL: fmt.Sprintf("https://%d.example/", i)}
+	}
+	if err := a.SaveURLs(urls); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			if err := a.SaveURLs(urls); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		got, err := a.GetURLs()
+		if err != nil {
+			t.Fatalf("read during a write: %v", err)
+		}
+		if len(got) != len(urls) {
+			t.Fatalf("read %d bookmarks during a write, want %d", len(got), len(urls))
+		}
+	}
+}
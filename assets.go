@@ -0,0 +1,14 @@
+package main
+
+import (
+	"net/http"
+)
+
+// newAssetHandler serves the dynamic assets requested by the frontend that
+// are not part of the embedded build, e.g. cached favicons and snapshots
+func newAssetHandler(app *App) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/favicon/", app.serveFavicon)
+	mux.HandleFunc("/snapshot/", app.serveSnapshot)
+	return mux
+}
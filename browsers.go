@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Browser families that share a bookmark storage format
+const (
+	browserFamilyChromium = "chromium"
+	browserFamilyFirefox  = "firefox"
+)
+
+// Base directories that browser locations are relative to
+const (
+	browserBaseHome         = "home"
+	browserBaseAppData      = "appdata"
+	browserBaseLocalAppData = "localappdata"
+)
+
+// BrowserProfile represents a browser profile found on this machine
+type BrowserProfile struct {
+	ID            string `json:"id"`
+	Browser       string `json:"browser"`
+	BrowserName   string `json:"browserName"`
+	Name          string `json:"name"`
+	Path          string `json:"path"`
+	BookmarkCount int    `json:"bookmarkCount"`
+	Error         string `json:"error,omitempty"`
+}
+
+// browserLocation is a browser data directory relative to a base directory
+type browserLocation struct {
+	Base string
+	Path string // slash separated
+}
+
+// browserDefinition describes where a browser keeps its profiles per OS
+type browserDefinition struct {
+	Key       string
+	Name      string
+	Family    string
+	Locations map[string][]browserLocation // keyed by GOOS
+}
+
+// knownBrowsers lists the browsers that can be discovered, in display order
+var knownBrowsers = []browserDefinition{
+	{
+		Key: "chrome", Name: "Google Chrome", Family: browserFamilyChromium,
+		Locations: map[string][]browserLocation{
+			"linux":   {{browserBaseHome, ".config/google-chrome"}},
+			"darwin":  {{browserBaseHome, "Library/Application Support/Google/Chrome"}},
+			"windows": {{browserBaseLocalAppData, "Google/Chrome/User Data"}},
+		},
+	},
+	{
+		Key: "chromium", Name: "Chromium", Family: browserFamilyChromium,
+		Locations: map[string][]browserLocation{
+			"linux": {
+				{browserBaseHome, ".config/chromium"},
+				{browserBaseHome, "snap/chromium/common/chromium"},
+			},
+			"darwin":  {{browserBaseHome, "Library/Application Support/Chromium"}},
+			"windows": {{browserBaseLocalAppData, "Chromium/User Data"}},
+		},
+	},
+	{
+		Key: "edge", Name: "Microsoft Edge", Family: browserFamilyChromium,
+		Locations: map[string][]browserLocation{
+			"linux":   {{browserBaseHome, ".config/microsoft-edge"}},
+			"darwin":  {{browserBaseHome, "Library/Application Support/Microsoft Edge"}},
+			"windows": {{browserBaseLocalAppData, "Microsoft/Edge/User Data"}},
+		},
+	},
+	{
+		Key: "brave", Name: "Brave", Family: browserFamilyChromium,
+		Locations: map[string][]browserLocation{
+			"linux":   {{browserBaseHome, ".config/BraveSoftware/Brave-Browser"}},
+			"darwin":  {{browserBaseHome, "Library/Application Support/BraveSoftware/Brave-Browser"}},
+			"windows": {{browserBaseLocalAppData, "BraveSoftware/Brave-Browser/User Data"}},
+		},
+	},
+	{
+		Key: "vivaldi", Name: "Vivaldi", Family: browserFamilyChromium,
+		Locations: map[string][]browserLocation{
+			"linux":   {{browserBaseHome, ".config/vivaldi"}},
+			"darwin":  {{browserBaseHome, "Library/Application Support/Vivaldi"}},
+			"windows": {{browserBaseLocalAppData, "Vivaldi/User Data"}},
+		},
+	},
+	{
+		Key: "firefox", Name: "Mozilla Firefox", Family: browserFamilyFirefox,
+		Locations: map[string][]browserLocation{
+			"linux": {
+				{browserBaseHome, ".mozilla/firefox"},
+				{browserBaseHome, "snap/firefox/common/.mozilla/firefox"},
+				{browserBaseHome, ".var/app/org.mozilla.firefox/.mozilla/firefox"},
+			},
+			"darwin":  {{browserBaseHome, "Library/Application Support/Firefox"}},
+			"windows": {{browserBaseAppData, "Mozilla/Firefox"}},
+		},
+	},
+}
+
+// browserEnv holds the directories browser locations are resolved against.
+// Tests can point it at a fixture tree instead of the real home directory.
+type browserEnv struct {
+	GOOS         string
+	Home         string
+	AppData      string
+	LocalAppData string
+}
+
+// currentBrowserEnv returns the browser environment of the running system
+func currentBrowserEnv() browserEnv {
+	home, _ := os.UserHomeDir()
+	env := browserEnv{
+		GOOS:         runtime.GOOS,
+		Home:         home,
+		AppData:      os.Getenv("APPDATA"),
+		LocalAppData: os.Getenv("LOCALAPPDATA"),
+	}
+	if env.AppData == "" && home != "" {
+		env.AppData = filepath.Join(home, "AppData", "Roaming")
+	}
+	if env.LocalAppData == "" && home != "" {
+		env.LocalAppData = filepath.Join(home, "AppData", "Local")
+	}
+	return env
+}
+
+// resolve returns the absolute directory for a browser location
+func (e browserEnv) resolve(location browserLocation) string {
+	base := ""
+	switch location.Base {
+	case browserBaseHome:
+		base = e.Home
+	case browserBaseAppData:
+		base = e.AppData
+	case browserBaseLocalAppData:
+		base = e.LocalAppData
+	}
+	if base == "" {
+		return ""
+	}
+	return filepath.Join(base, filepath.FromSlash(location.Path))
+}
+
+// discoverBrowserProfiles finds all browser profiles in the given environment
+func discoverBrowserProfiles(env browserEnv) []BrowserProfile {
+	profiles := []BrowserProfile{}
+
+	for _, browser := range knownBrowsers {
+		for _, location := range browser.Locations[env.GOOS] {
+			dir := env.resolve(location)
+			if dir == "" {
+				continue
+			}
+
+			switch browser.Family {
+			case browserFamilyChromium:
+				profiles = append(profiles, discoverChromiumProfiles(browser, dir)...)
+			case browserFamilyFirefox:
+				profiles = append(profiles, discoverFirefoxProfiles(browser, dir)...)
+			}
+		}
+	}
+
+	return profiles
+}
+
+// discoverChromiumProfiles lists the profiles of a Chromium based browser
+// user data directory. Every subdirectory holding a Bookmarks file is a profile.
+func discoverChromiumProfiles(browser browserDefinition, userDataDir string) []BrowserProfile {
+	entries, err := os.ReadDir(userDataDir)
+	if err != nil {
+		return nil
+	}
+
+	names := readChromiumProfileNames(filepath.Join(userDataDir, "Local State"))
+
+	var profiles []BrowserProfile
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		profileDir := filepath.Join(userDataDir, entry.Name())
+		bookmarksPath := filepath.Join(profileDir, "Bookmarks")
+		if _, err := os.Stat(bookmarksPath); err != nil {
+			continue
+		}
+
+		name := names[entry.Name()]
+		if name == "" {
+			name = entry.Name()
+		}
+
+		profile := BrowserProfile{
+			ID:          browser.Key + ":" + profileDir,
+			Browser:     browser.Key,
+			BrowserName: browser.Name,
+			Name:        name,
+			Path:        profileDir,
+		}
+
+		bookmarks, err := readChromiumBookmarksFile(bookmarksPath)
+		if err != nil {
+			profile.Error = err.Error()
+		} else {
+			profile.BookmarkCount = countChromeBookmarks(bookmarks)
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Path < profiles[j].Path
+	})
+
+	return profiles
+}
+
+// readChromiumProfileNames reads the display names of profiles from the
+// "Local State" file, keyed by profile directory name
+func readChromiumProfileNames(localStatePath string) map[string]string {
+	names := make(map[string]string)
+
+	data, err := os.ReadFile(localStatePath)
+	if err != nil {
+		return names
+	}
+
+	var localState struct {
+		Profile struct {
+			InfoCache map[string]struct {
+				Name string `json:"name"`
+			} `json:"info_cache"`
+		} `json:"profile"`
+	}
+	if err := json.Unmarshal(data, &localState); err != nil {
+		return names
+	}
+
+	for dir, info := range localState.Profile.InfoCache {
+		names[dir] = info.Name
+	}
+	return names
+}
+
+// readChromiumBookmarksFile parses a Chromium Bookmarks file
+func readChromiumBookmarksFile(path string) (*ChromeBookmarkRoot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root ChromeBookmarkRoot
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid Bookmarks file: %w", err)
+	}
+	return &root, nil
+}
+
+// countChromeBookmarks counts URL entries in all bookmark roots
+func countChromeBookmarks(root *ChromeBookmarkRoot) int {
+	return countChromeURLs(root.Roots.BookmarkBar.Children) +
+		countChromeURLs(root.Roots.Other.Children) +
+		countChromeURLs(root.Roots.Synced.Children)
+}
+
+// countChromeURLs recursively counts URL entries in a bookmark subtree
+func countChromeURLs(bookmarks []ChromeBookmark) int {
+	count := 0
+	for _, bookmark := range bookmarks {
+		if bookmark.Type == "url" && bookmark.URL != "" {
+			count++
+		} else if bookmark.Type == "folder" {
+			count += countChromeURLs(bookmark.Children)
+		}
+	}
+	return count
+}
+
+// firefoxProfileEntry is a [ProfileN] section of profiles.ini
+type firefoxProfileEntry struct {
+	Name       string
+	Path       string
+	IsRelative bool
+}
+
+// discoverFirefoxProfiles lists Firefox profiles from profiles.ini, falling
+// back to scanning the Profiles directory when the file is missing
+func discoverFirefoxProfiles(browser browserDefinition, firefoxDir string) []BrowserProfile {
+	entries, err := readFirefoxProfilesIni(filepath.Join(firefoxDir, "profiles.ini"))
+	if err != nil {
+		dirs, err := os.ReadDir(filepath.Join(firefoxDir, "Profiles"))
+		if err != nil {
+			return nil
+		}
+		for _, dir := range dirs {
+			if dir.IsDir() {
+				entries = append(entries, firefoxProfileEntry{
+					Name:       dir.Name(),
+					Path:       "Profiles/" + dir.Name(),
+					IsRelative: true,
+				})
+			}
+		}
+	}
+
+	var profiles []BrowserProfile
+	for _, entry := range entries {
+		profileDir := filepath.FromSlash(entry.Path)
+		if entry.IsRelative {
+			profileDir = filepath.Join(firefoxDir, profileDir)
+		}
+
+		placesPath := filepath.Join(profileDir, "places.sqlite")
+		if _, err := os.Stat(placesPath); err != nil {
+			continue
+		}
+
+		name := entry.Name
+		if name == "" {
+			name = filepath.Base(profileDir)
+		}
+
+		profile := BrowserProfile{
+			ID:          browser.Key + ":" + profileDir,
+			Browser:     browser.Key,
+			BrowserName: browser.Name,
+			Name:        name,
+			Path:        profileDir,
+		}
+
+		bookmarks, err := readFirefoxPlaces(placesPath)
+		if err != nil {
+			profile.Error = err.Error()
+		} else {
+			profile.BookmarkCount = len(bookmarks)
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return profiles
+}
+
+// readFirefoxProfilesIni parses the profile sections of a profiles.ini file
+func readFirefoxProfilesIni(path string) ([]firefoxProfileEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []firefoxProfileEntry
+	var current *firefoxProfileEntry
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section := line[1 : len(line)-1]
+			if strings.HasPrefix(section, "Profile") {
+				entries = append(entries, firefoxProfileEntry{IsRelative: true})
+				current = &entries[len(entries)-1]
+			} else {
+				current = nil
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Name":
+			current.Name = strings.TrimSpace(value)
+		case "Path":
+			current.Path = strings.TrimSpace(value)
+		case "IsRelative":
+			current.IsRelative = strings.TrimSpace(value) != "0"
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Drop sections without a profile path
+	valid := entries[:0]
+	for _, entry := range entries {
+		if entry.Path != "" {
+			valid = append(valid, entry)
+		}
+	}
+	return valid, nil
+}
+
+// browserFamily returns the storage family of a known browser key
+func browserFamily(key string) string {
+	for _, browser := range knownBrowsers {
+		if browser.Key == key {
+			return browser.Family
+		}
+	}
+	return ""
+}
+
+// ListBrowserProfiles returns the browser profiles installed on this machine
+func (a *App) ListBrowserProfiles() ([]BrowserProfile, error) {
+	return discoverBrowserProfiles(currentBrowserEnv()), nil
+}
+
+// ImportBrowserProfile imports the bookmarks of a discovered browser
+// profile. The options apply to Chromium based browsers.
+func (a *App) ImportBrowserProfile(profileID string, options ChromeImportOptions) (int, error) {
+	return a.importBrowserProfile(currentBrowserEnv(), profileID, options)
+}
+
+// importBrowserProfile imports a profile found in the given environment
+func (a *App) importBrowserProfile(env browserEnv, profileID string, options ChromeImportOptions) (int, error) {
+	for _, profile := range discoverBrowserProfiles(env) {
+		if profile.ID != profileID {
+			continue
+		}
+
+		switch browserFamily(profile.Browser) {
+		case browserFamilyFirefox:
+			bookmarks, err := readFirefoxPlaces(filepath.Join(profile.Path, "places.sqlite"))
+			if err != nil {
+				return 0, err
+			}
+			return a.saveImportedBookmarks(bookmarks)
+		default:
+			data, err := os.ReadFile(filepath.Join(profile.Path, "Bookmarks"))
+			if err != nil {
+				return 0, err
+			}
+			return a.ImportChromeBookmarksWithOptions(string(data), options)
+		}
+	}
+
+	return 0, fmt.Errorf("browser profile %s not found", profileID)
+}
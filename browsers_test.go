@@ -0,0 +1,286 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFixtureFile writes a fixture file, creating its directories
+func writeFixtureFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeChromiumFixture creates a Chromium user data directory with one
+// profile per entry of profiles, mapping directory names to bookmark URLs.
+// URLs prefixed with "synced:" are put in the mobile bookmarks root.
+func writeChromiumFixture(t *testing.T, userDataDir string, names map[string]string, profiles map[string][]string) {
+	t.Helper()
+
+	localState := map[string]interface{}{}
+	infoCache := map[string]interface{}{}
+	for dir, name := range names {
+		infoCache[dir] = map[string]string{"name": name}
+	}
+	localState["profile"] = map[string]interface{}{"info_cache": infoCache}
+	data, _ := json.Marshal(localState)
+	writeFixtureFile(t, filepath.Join(userDataDir, "Local State"), data)
+
+	for dir, urls := range profiles {
+		var root ChromeBookmarkRoot
+		root.Roots.BookmarkBar = ChromeBookmark{Type: "folder", Name: "Bookmarks bar"}
+		root.Roots.Synced = ChromeBookmark{Type: "folder", Name: "Mobile bookmarks"}
+		folder := ChromeBookmark{Type: "folder", Name: "Fixtures"}
+		for i, url := range urls {
+			bookmark := ChromeBookmark{
+				Type: "url",
+				Name: "Bookmark " + url,
+				URL:  url,
+				GUID: dir + "-" + string(rune('a'+i)),
+			}
+			if synced, ok := strings.CutPrefix(url, "synced:"); ok {
+				bookmark.URL = synced
+				root.Roots.Synced.Children = append(root.Roots.Synced.Children, bookmark)
+				continue
+			}
+			folder.Children = append(folder.Children, bookmark)
+		}
+		root.Roots.BookmarkBar.Children = []ChromeBookmark{folder}
+		root.Version = 1
+		data, _ := json.Marshal(root)
+		writeFixtureFile(t, filepath.Join(userDataDir, dir, "Bookmarks"), data)
+	}
+
+	// A directory without a Bookmarks file is not a profile
+	if err := os.MkdirAll(filepath.Join(userDataDir, "System Profile"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeFirefoxPlacesFixture creates a minimal places.sqlite holding the
+// given URLs in a "Fixtures" folder of the bookmarks menu
+func writeFirefoxPlacesFixture(t *testing.T, placesPath string, urls []string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(placesPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", placesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	statements := []string{
+		`CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url TEXT)`,
+		`CREATE TABLE moz_bookmarks (id INTEGER PRIMARY KEY, type INTEGER, fk INTEGER, parent INTEGER,
+			position INTEGER, title TEXT, guid TEXT, dateAdded INTEGER, lastModified INTEGER)`,
+		`CREATE TABLE moz_keywords (id INTEGER PRIMARY KEY, keyword TEXT, place_id INTEGER)`,
+		`INSERT INTO moz_bookmarks VALUES (1, 2, NULL, 0, 0, '', 'root________', 0, 0)`,
+		`INSERT INTO moz_bookmarks VALUES (2, 2, NULL, 1, 0, 'menu', 'menu________', 0, 0)`,
+		`INSERT INTO moz_bookmarks VALUES (3, 2, NULL, 2, 0, 'Fixtures', 'folder000001', 0, 0)`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, url := range urls {
+		id := int64(10 + i)
+		if _, err := db.Exec(`INSERT INTO moz_places VALUES (?, ?)`, id, url); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`INSERT INTO moz_bookmarks VALUES (?, 1, ?, 3, ?, ?, ?, 1700000000000000, 1700000000000000)`,
+			id, id, i, "Bookmark "+url, "bookmark"+string(rune('a'+i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiscoverBrowserProfiles(t *testing.T) {
+	tests := []struct {
+		name string
+		goos string
+		// setup builds the fixture tree below root for the environment
+		setup func(t *testing.T, env browserEnv)
+		want  map[string]int // Profile name to bookmark count, per browser key
+		keys  map[string]string
+	}{
+		{
+			name: "linux",
+			goos: "linux",
+			setup: func(t *testing.T, env browserEnv) {
+				writeChromiumFixture(t, filepath.Join(env.Home, ".config", "google-chrome"),
+					map[string]string{"Default": "Personal", "Profile 1": "Work"},
+					map[string][]string{
+						"Default":   {"https://a.example/", "https://b.example/"},
+						"Profile 1": {"https://c.example/"},
+					})
+				writeChromiumFixture(t, filepath.Join(env.Home, ".config", "microsoft-edge"),
+					nil, map[string][]string{"Default": {"https://edge.example/"}})
+
+				firefox := filepath.Join(env.Home, ".mozilla", "firefox")
+				writeFixtureFile(t, filepath.Join(firefox, "profiles.ini"), []byte(
+					"[General]\nStartWithLastProfile=1\n\n[Profile0]\nName=default-release\nIsRelative=1\nPath=abcd.default-release\n\n[Profile1]\nName=missing\nIsRelative=1\nPath=gone.default\n"))
+				writeFirefoxPlacesFixture(t, filepath.Join(firefox, "abcd.default-release", "places.sqlite"),
+					[]string{"https://f1.example/", "https://f2.example/", "https://f3.example/"})
+			},
+			want: map[string]int{"Personal": 2, "Work": 1, "Default": 1, "default-release": 3},
+			keys: map[string]string{"Personal": "chrome", "Work": "chrome", "Default": "edge", "default-release": "firefox"},
+		},
+		{
+			name: "windows",
+			goos: "windows",
+			setup: func(t *testing.T, env browserEnv) {
+				writeChromiumFixture(t, filepath.Join(env.LocalAppData, "Google", "Chrome", "User Data"),
+					map[string]string{"Default": "Person 1"},
+					map[string][]string{"Default": {"https://a.example/"}})
+				writeChromiumFixture(t, filepath.Join(env.LocalAppData, "Microsoft", "Edge", "User Data"),
+					map[string]string{"Profile 2": "Edge Work"},
+					map[string][]string{"Profile 2": {"https://b.example/", "https://c.example/"}})
+
+				// Without profiles.ini the Profiles directory is scanned
+				writeFirefoxPlacesFixture(t, filepath.Join(env.AppData, "Mozilla", "Firefox", "Profiles", "wxyz.default", "places.sqlite"),
+					[]string{"https://f.example/"})
+			},
+			want: map[string]int{"Person 1": 1, "Edge Work": 2, "wxyz.default": 1},
+			keys: map[string]string{"Person 1": "chrome", "Edge Work": "edge", "wxyz.default": "firefox"},
+		},
+		{
+			name: "darwin",
+			goos: "darwin",
+			setup: func(t *testing.T, env browserEnv) {
+				support := filepath.Join(env.Home, "Library", "Application Support")
+				writeChromiumFixture(t, filepath.Join(support, "Microsoft Edge"),
+					nil, map[string][]string{"Default": {"https://a.example/"}})
+
+				// An absolute profile path outside the Firefox directory
+				outside := filepath.Join(env.Home, "elsewhere", "profile")
+				writeFixtureFile(t, filepath.Join(support, "Firefox", "profiles.ini"), []byte(
+					"[Profile0]\nName=custom\nIsRelative=0\nPath="+filepath.ToSlash(outside)+"\n"))
+				writeFirefoxPlacesFixture(t, filepath.Join(outside, "places.sqlite"), []string{"https://f.example/"})
+			},
+			want: map[string]int{"Default": 1, "custom": 1},
+			keys: map[string]string{"Default": "edge", "custom": "firefox"},
+		},
+		{
+			name:  "empty",
+			goos:  "linux",
+			setup: func(t *testing.T, env browserEnv) {},
+			want:  map[string]int{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			env := browserEnv{
+				GOOS:         test.goos,
+				Home:         filepath.Join(root, "home"),
+				AppData:      filepath.Join(root, "appdata"),
+				LocalAppData: filepath.Join(root, "localappdata"),
+			}
+			test.setup(t, env)
+
+			profiles := discoverBrowserProfiles(env)
+			if len(profiles) != len(test.want) {
+				t.Fatalf("found %d profiles, want %d: %+v", len(profiles), len(test.want), profiles)
+			}
+			for _, profile := range profiles {
+				count, ok := test.want[profile.Name]
+				if !ok {
+					t.Errorf("unexpected profile %q (%s)", profile.Name, profile.Path)
+					continue
+				}
+				if profile.Error != "" {
+					t.Errorf("profile %q: %s", profile.Name, profile.Error)
+				}
+				if profile.BookmarkCount != count {
+					t.Errorf("profile %q has %d bookmarks, want %d", profile.Name, profile.BookmarkCount, count)
+				}
+				if profile.Browser != test.keys[profile.Name] {
+					t.Errorf("profile %q belongs to %q, want %q", profile.Name, profile.Browser, test.keys[profile.Name])
+				}
+			}
+		})
+	}
+}
+
+func TestImportBrowserProfile(t *testing.T) {
+	setTestHome(t)
+	root := t.TempDir()
+	env := browserEnv{GOOS: "linux", Home: root}
+
+	writeChromiumFixture(t, filepath.Join(root, ".config", "google-chrome"), nil,
+		map[string][]string{"Default": {"https://a.example/", "https://b.example/"}})
+	writeFixtureFile(t, filepath.Join(root, ".mozilla", "firefox", "profiles.ini"),
+		[]byte("[Profile0]\nName=default\nIsRelative=1\nPath=p.default\n"))
+	writeFirefoxPlacesFixture(t, filepath.Join(root, ".mozilla", "firefox", "p.default", "places.sqlite"),
+		[]string{"https://f.example/"})
+
+	a := NewApp()
+	for _, profile := range discoverBrowserProfiles(env) {
+		count, err := a.importBrowserProfile(env, profile.ID, ChromeImportOptions{})
+		if err != nil {
+			t.Fatalf("import %s: %v", profile.ID, err)
+		}
+		if count != profile.BookmarkCount {
+			t.Errorf("import %s: imported %d, want %d", profile.ID, count, profile.BookmarkCount)
+		}
+
+		// Chrome and Firefox GUIDs make a second import a no-op
+		if count, err := a.importBrowserProfile(env, profile.ID, ChromeImportOptions{}); err != nil || count != 0 {
+			t.Errorf("re-import %s: imported %d (%v), want 0", profile.ID, count, err)
+		}
+	}
+
+	urls, err := a.GetURLs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 3 {
+		t.Fatalf("stored %d bookmarks, want 3", len(urls))
+	}
+	for _, url := range urls {
+		if url.Category != "Fixtures" {
+			t.Errorf("%s has category %q, want Fixtures", url.URL, url.Category)
+		}
+	}
+
+	if _, err := a.importBrowserProfile(env, "chrome:/does/not/exist", ChromeImportOptions{}); err == nil {
+		t.Error("importing an unknown profile succeeded")
+	}
+}
+
+func TestImportBrowserProfileSynced(t *testing.T) {
+	setTestHome(t)
+	root := t.TempDir()
+	env := browserEnv{GOOS: "linux", Home: root}
+
+	writeChromiumFixture(t, filepath.Join(root, ".config", "chromium"), nil,
+		map[string][]string{"Default": {"https://desktop.example/", "synced:https://mobile.example/"}})
+
+	profiles := discoverBrowserProfiles(env)
+	if len(profiles) != 1 || profiles[0].BookmarkCount != 2 {
+		t.Fatalf("profiles = %+v, want one with 2 bookmarks", profiles)
+	}
+
+	a := NewApp()
+	count, err := a.importBrowserProfile(env, profiles[0].ID, ChromeImportOptions{})
+	if err != nil || count != 1 {
+		t.Fatalf("import without synced: %d (%v), want 1", count, err)
+	}
+	count, err = a.importBrowserProfile(env, profiles[0].ID, ChromeImportOptions{IncludeSynced: true})
+	if err != nil || count != 1 {
+		t.Fatalf("import with synced: %d (%v), want the 1 synced bookmark", count, err)
+	}
+}
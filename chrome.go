@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"time"
+	"unicode/utf16"
+)
+
+// chromeEpochOffset is the number of microseconds between the WebKit epoch
+// (1601-01-01 UTC) used by Chrome timestamps and the Unix epoch
+const chromeEpochOffset = 11644473600 * 1000 * 1000
+
+// chromeTime converts a Chrome timestamp (microseconds since 1601-01-01 UTC
+// encoded as a decimal string) to a time.Time. Invalid or empty values yield
+// the zero time.
+func chromeTime(value string) time.Time {
+	micros, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || micros <= chromeEpochOffset {
+		return time.Time{}
+	}
+	return time.UnixMicro(micros - chromeEpochOffset)
+}
+
+// chromeBookmarksChecksum computes the checksum Chrome stores in its
+// Bookmarks file: an MD5 over the id, UTF-16 title, type and URL of every
+// node of the bookmark bar, other and synced roots in file order.
+func chromeBookmarksChecksum(root *ChromeBookmarkRoot) string {
+	hash := md5.New()
+	updateChromeChecksum(hash.Write, root.Roots.BookmarkBar)
+	updateChromeChecksum(hash.Write, root.Roots.Other)
+	updateChromeChecksum(hash.Write, root.Roots.Synced)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// updateChromeChecksum feeds a bookmark node and its children to the hash
+func updateChromeChecksum(write func([]byte) (int, error), node ChromeBookmark) {
+	write([]byte(node.ID))
+
+	// Chrome hashes titles as little-endian UTF-16
+	title := utf16.Encode([]rune(node.Name))
+	titleBytes := make([]byte, len(title)*2)
+	for i, unit := range title {
+		binary.LittleEndian.PutUint16(titleBytes[i*2:], unit)
+	}
+	write(titleBytes)
+
+	if node.Type == "url" {
+		write([]byte("url"))
+		write([]byte(node.URL))
+		return
+	}
+
+	write([]byte("folder"))
+	for _, child := range node.Children {
+		updateChromeChecksum(write, child)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// chromeBookmarksFile is a Bookmarks file in the layout current Chrome
+// versions write, including fields the importer ignores. Its checksum was
+// computed separately from the Go code, following Chromium's
+// BookmarkCodec: MD5 over id, UTF-16LE title, "url"/"folder" and URL of
+// every node of the three roots.
+const chromeBookmarksFile = `
+{
+   "checksum": "073dbcf56bd7f16b4da582dcaff773c5",
+   "roots": {
+      "bookmark_bar": {
+         "children": [
+            {
+               "date_added": "13350000000000000",
+               "date_last_used": "13351000000000000",
+               "guid": "2f6ab6c4-6f0d-4a1b-9d1f-3b9a7f1c2e01",
+               "id": "5",
+               "meta_info": {
+                  "power_bookmark_meta": ""
+               },
+               "name": "Go 语言",
+               "type": "url",
+               "url": "https://go.dev/"
+            },
+            {
+               "children": [
+                  {
+                     "date_added": "13350000000000001",
+                     "date_last_used": "0",
+                     "guid": "2f6ab6c4-6f0d-4a1b-9d1f-3b9a7f1c2e02",
+                     "id": "7",
+                     "name": "Emoji 🚀 title",
+                     "type": "url",
+                     "url": "https://example.com/rocket?q=%F0%9F%9A%80"
+                  }
+               ],
+               "date_added": "13350000000000002",
+               "date_last_used": "0",
+               "date_modified": "13350000000000003",
+               "guid": "2f6ab6c4-6f0d-4a1b-9d1f-3b9a7f1c2e03",
+               "id": "6",
+               "name": "工具",
+               "type": "folder"
+            }
+         ],
+         "date_added": "13349000000000000",
+         "date_last_used": "0",
+         "date_modified": "13350000000000003",
+         "guid": "0bc5d13f-2cba-5d74-951f-3f233fe6c908",
+         "id": "1",
+         "name": "书签栏",
+         "type": "folder"
+      },
+      "other": {
+         "children": [
+            {
+               "date_added": "13350000000000004",
+               "date_last_used": "0",
+               "guid": "2f6ab6c4-6f0d-4a1b-9d1f-3b9a7f1c2e04",
+               "id": "8",
+               "name": "Other",
+               "type": "url",
+               "url": "https://other.example/"
+            }
+         ],
+         "date_added": "13349000000000000",
+         "date_last_used": "0",
+         "date_modified": "0",
+         "guid": "82b081ec-3dd3-529c-8475-ab6c344590dd",
+         "id": "2",
+         "name": "其他书签",
+         "type": "folder"
+      },
+      "synced": {
+         "children": [],
+         "date_added": "13349000000000000",
+         "date_last_used": "0",
+         "date_modified": "0",
+         "guid": "4cf2e351-0e85-532b-bb37-df045d8f8d0f",
+         "id": "3",
+         "name": "移动设备书签",
+         "type": "folder"
+      }
+   },
+   "sync_metadata": "CgIIAQ==",
+   "version": 1
+}
+`
+
+func TestChromeBookmarksChecksum(t *testing.T) {
+	var root ChromeBookmarkRoot
+	if err := json.Unmarshal([]byte(chromeBookmarksFile), &root); err != nil {
+		t.Fatal(err)
+	}
+	if got := chromeBookmarksChecksum(&root); got != root.Checksum {
+		t.Errorf("checksum = %s, want %s", got, root.Checksum)
+	}
+
+	tampered := strings.Replace(chromeBookmarksFile, "https://go.dev/", "https://go.dev/x", 1)
+
+	tests := []struct {
+		name      string
+		data      string
+		options   ChromeImportOptions
+		wantCount int
+		wantErr   string
+	}{
+		{name: "unchanged", data: chromeBookmarksFile, wantCount: 3},
+		{name: "tampered", data: tampered, wantErr: "checksum mismatch"},
+		{name: "tampered, import anyway", data: tampered, options: ChromeImportOptions{SkipChecksum: true}, wantCount: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestHome(t)
+			a := NewApp()
+			count, err := a.ImportChromeBookmarksWithOptions(test.data, test.options)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != test.wantCount {
+				t.Errorf("imported %d, want %d", count, test.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// contentMaxBytes caps the stored text of a single page
+const contentMaxBytes = 100 * 1024
+
+// contentIndex maps bookmark IDs to the tokens of their page text. It is
+// built from the stored texts on first use and kept up to date afterwards.
+type contentIndex struct {
+	mu     sync.Mutex
+	loaded bool
+	docs   map[string]map[string]struct{}
+}
+
+// pageContentIndex is the content index of the running app
+var pageContentIndex = &contentIndex{}
+
+// contentDir returns the directory holding extracted page texts
+func (a *App) contentDir() string {
+	return filepath.Join(a.GetDataDir(), "content")
+}
+
+// contentPath returns the file holding the text of a bookmarked page
+func (a *App) contentPath(id string) string {
+	return filepath.Join(a.contentDir(), filepath.Base(id)+".txt")
+}
+
+// indexPageContent stores the readable text of a page and adds it to the
+// content index. Empty text leaves the previous content in place.
+func (a *App) indexPageContent(id, text string) error {
+	text = truncateUTF8(text, contentMaxBytes)
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(a.contentDir(), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(a.contentPath(id), []byte(text), 0644); err != nil {
+		return err
+	}
+
+	pageContentIndex.mu.Lock()
+	defer pageContentIndex.mu.Unlock()
+	if pageContentIndex.loaded {
+		pageContentIndex.docs[id] = tokenSet(text)
+	}
+	return nil
+}
+
+// removePageContent drops a bookmark from the content index
+func (a *App) removePageContent(id string) {
+	os.Remove(a.contentPath(id))
+
+	pageContentIndex.mu.Lock()
+	defer pageContentIndex.mu.Unlock()
+	delete(pageContentIndex.docs, id)
+}
+
+// contentMatches reports whether the page text of a bookmark contains
+// every token of the query. Latin words also match as prefixes.
+func (a *App) contentMatches(id, query string) bool {
+	terms := tokenizeQuery(query)
+	if len(terms) == 0 {
+		return false
+	}
+
+	pageContentIndex.mu.Lock()
+	defer pageContentIndex.mu.Unlock()
+	a.loadContentIndex()
+
+	tokens, ok := pageContentIndex.docs[id]
+	if !ok {
+		return false
+	}
+
+	for _, term := range terms {
+		if _, ok := tokens[term]; ok {
+			continue
+		}
+		if isCJKToken(term) || !hasTokenWithPrefix(tokens, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// loadContentIndex tokenizes all stored texts; callers must hold the index mutex
+func (a *App) loadContentIndex() {
+	if pageContentIndex.loaded {
+		return
+	}
+	pageContentIndex.docs = make(map[string]map[string]struct{})
+	pageContentIndex.loaded = true
+
+	entries, err := os.ReadDir(a.contentDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".txt") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(a.contentDir(), name))
+		if err != nil {
+			continue
+		}
+		pageContentIndex.docs[strings.TrimSuffix(name, ".txt")] = tokenSet(string(data))
+	}
+}
+
+// hasTokenWithPrefix reports whether any token starts with prefix
+func hasTokenWithPrefix(tokens map[string]struct{}, prefix string) bool {
+	for token := range tokens {
+		if strings.HasPrefix(token, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenSet returns the distinct index tokens of a text
+func tokenSet(text string) map[string]struct{} {
+	set := make(map[string]struct{})
+	forEachToken(text, true, func(token string) {
+		set[token] = struct{}{}
+	})
+	return set
+}
+
+// tokenizeQuery returns the tokens a document must contain to match
+func tokenizeQuery(query string) []string {
+	var terms []string
+	forEachToken(query, false, func(token string) {
+		terms = append(terms, token)
+	})
+	return terms
+}
+
+// forEachToken splits text into lower-case words. Chinese, Japanese and
+// Korean text has no spaces, so runs of those characters are indexed as
+// overlapping bigrams and, for documents, single characters as well so
+// that one-character queries can match. A query run of two or more
+// characters is searched by its bigrams.
+func forEachToken(text string, document bool, emit func(string)) {
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			emit(string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 0:
+			return
+		case len(cjk) == 1:
+			emit(string(cjk))
+		default:
+			for i := 0; i+1 < len(cjk); i++ {
+				emit(string(cjk[i : i+2]))
+			}
+			if document {
+				for _, r := range cjk {
+					emit(string(r))
+				}
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			flushCJK()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+}
+
+// isCJK reports whether a character belongs to a script written without
+// spaces between words
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// isCJKToken reports whether a token was produced from CJK characters
+func isCJKToken(token string) bool {
+	r, _ := utf8.DecodeRuneInString(token)
+	return isCJK(r)
+}
+
+// readableText extracts the main text of a parsed page
+func readableText(doc *html.Node) string {
+	var b strings.Builder
+	collectText(&b, mainContent(doc))
+	return normalizeText(b.String())
+}
+
+// truncateUTF8 shortens a string to at most max bytes without splitting
+// a character
+func truncateUTF8(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	for max > 0 && !utf8.RuneStart(text[max]) {
+		max--
+	}
+	return text[:max]
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenizeQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "Go Programming", want: []string{"go", "programming"}},
+		{query: "  café, naïve!  ", want: []string{"café", "naïve"}},
+		{query: "中文", want: []string{"中文"}},
+		{query: "中文搜索", want: []string{"中文", "文搜", "搜索"}},
+		{query: "字", want: []string{"字"}},
+		{query: "Go语言编程v2", want: []string{"go", "语言", "言编", "编程", "v2"}},
+		{query: "ひらがなカタカナ", want: []string{"ひら", "らが", "がな", "なカ", "カタ", "タカ", "カナ"}},
+		{query: "한국어 test", want: []string{"한국", "국어", "test"}},
+		{query: "-- ...", want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			if got := tokenizeQuery(test.query); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("tokenizeQuery(%q) = %q, want %q", test.query, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTokenSetIndexesSingleCJKCharacters(t *testing.T) {
+	got := tokenSet("学习Go 语言")
+	want := map[string]struct{}{
+		"学习": {}, "学": {}, "习": {}, "go": {}, "语言": {}, "语": {}, "言": {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenSet() = %v, want %v", got, want)
+	}
+}
+
+func TestContentMatches(t *testing.T) {
+	setTestHome(t)
+	a := NewApp()
+
+	// The index is shared by the running app; start from this test's data
+	resetIndex := func() {
+		pageContentIndex.mu.Lock()
+		pageContentIndex.loaded = false
+		pageContentIndex.mu.Unlock()
+	}
+	resetIndex()
+	t.Cleanup(resetIndex)
+
+	if err := a.indexPageContent("1", "Go 语言编程入门：Concurrency patterns in Golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "golang", want: true},
+		{query: "CONCURRENCY", want: true},
+		{query: "concur", want: true},
+		{query: "lang", want: false},
+		{query: "语言", want: true},
+		{query: "编程入门", want: true},
+		{query: "语", want: true},
+		{query: "言编程", want: true},
+		{query: "编入", want: false},
+		{query: "语言 pattern", want: true},
+		{query: "语言 rust", want: false},
+		{query: "Go语言", want: true},
+		{query: "   ", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			if got := a.contentMatches("1", test.query); got != test.want {
+				t.Errorf("contentMatches(%q) = %v, want %v", test.query, got, test.want)
+			}
+		})
+	}
+
+	if a.contentMatches("2", "go") {
+		t.Error("bookmark without content matched")
+	}
+	a.removePageContent("1")
+	if a.contentMatches("1", "go") {
+		t.Error("removed content still matches")
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		text string
+		max  int
+		want string
+	}{
+		{text: "hello", max: 10, want: "hello"},
+		{text: "hello", max: 5, want: "hello"},
+		{text: "hello", max: 3, want: "hel"},
+		{text: "中文", max: 6, want: "中文"},
+		{text: "中文", max: 5, want: "中"},
+		{text: "中文", max: 3, want: "中"},
+		{text: "中文", max: 2, want: ""},
+		{text: "a中", max: 2, want: "a"},
+		{text: "é", max: 1, want: ""},
+		{text: "abc", max: 0, want: ""},
+	}
+
+	for _, test := range tests {
+		if got := truncateUTF8(test.text, test.max); got != test.want {
+			t.Errorf("truncateUTF8(%q, %d) = %q, want %q", test.text, test.max, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// CSV fields that can be mapped to columns
+const (
+	csvFieldTitle       = "title"
+	csvFieldURL         = "url"
+	csvFieldDescription = "description"
+	csvFieldCategory    = "category"
+	csvFieldTags        = "tags"
+	csvFieldCreated     = "created"
+)
+
+// csvDefaultColumns is the column order used when exporting without options
+var csvDefaultColumns = []string{
+	csvFieldTitle, csvFieldURL, csvFieldDescription, csvFieldCategory, csvFieldTags, csvFieldCreated,
+}
+
+// csvHeaderAliases lists header names recognized for each field, lowercased
+var csvHeaderAliases = map[string][]string{
+	csvFieldTitle:       {"title", "name", "标题", "名称"},
+	csvFieldURL:         {"url", "link", "href", "address", "网址", "链接", "地址"},
+	csvFieldDescription: {"description", "note", "notes", "excerpt", "描述", "备注", "说明"},
+	csvFieldCategory:    {"category", "folder", "collection", "分类", "类别", "文件夹"},
+	csvFieldTags:        {"tags", "tag", "labels", "标签"},
+	csvFieldCreated:     {"created", "created_at", "createdat", "date", "added", "time_added", "创建时间", "添加时间", "日期"},
+}
+
+// CSVColumnMapping maps bookmark fields to CSV header names.
+// Empty entries are detected from common header names.
+type CSVColumnMapping struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Tags        string `json:"tags"`
+	Created     string `json:"created"`
+}
+
+// CSVImportOptions controls how a CSV file is decoded and mapped
+type CSVImportOptions struct {
+	Mapping      CSVColumnMapping `json:"mapping"`
+	Delimiter    string           `json:"delimiter"`    // Empty to detect , ; tab or |
+	Encoding     string           `json:"encoding"`     // "utf-8", "utf-16", "gbk" or empty to detect
+	TagSeparator string           `json:"tagSeparator"` // Empty to split on , ; | and ，
+}
+
+// CSVExportOptions controls the layout of an exported CSV file
+type CSVExportOptions struct {
+	Columns   []string `json:"columns"`   // Field names in output order
+	Delimiter string   `json:"delimiter"` // Defaults to ,
+	UTF8BOM   bool     `json:"utf8Bom"`   // Prepend a BOM so Excel detects UTF-8
+}
+
+// csvDelimiters are the candidates tried when detecting the delimiter
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+// ImportCSV imports bookmarks from a CSV file given as base64
+func (a *App) ImportCSV(base64Data string, options CSVImportOptions) (int, error) {
+	data, err := base64.StdEncoding.DecodeString(base64Data)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file data: %w", err)
+	}
+
+	bookmarks, err := parseCSVBookmarks(data, options)
+	if err != nil {
+		return 0, err
+	}
+
+	return a.saveImportedBookmarks(bookmarks)
+}
+
+// readCSVRecords decodes CSV data and returns all records including the
+// header. Empty encoding and delimiter are detected.
+func readCSVRecords(data []byte, encodingName, delimiterName string) ([][]string, error) {
+	text, err := decodeText(data, encodingName)
+	if err != nil {
+		return nil, err
+	}
+
+	delimiter := detectCSVDelimiter(text)
+	if delimiterName != "" {
+		delimiter, _ = utf8.DecodeRuneInString(unescapeDelimiter(delimiterName))
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	return records, nil
+}
+
+// parseCSVBookmarks decodes and parses CSV data into bookmarks
+func parseCSVBookmarks(data []byte, options CSVImportOptions) ([]importedBookmark, error) {
+	records, err := readCSVRecords(data, options.Encoding, options.Delimiter)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := resolveCSVColumns(records[0], options.Mapping)
+	if _, ok := columns[csvFieldURL]; !ok {
+		return nil, fmt.Errorf("CSV has no URL column")
+	}
+
+	var bookmarks []importedBookmark
+	for _, record := range records[1:] {
+		field := func(name string) string {
+			index, ok := columns[name]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		url := field(csvFieldURL)
+		if url == "" {
+			continue
+		}
+
+		bookmarks = append(bookmarks, importedBookmark{
+			Title:       field(csvFieldTitle),
+			URL:         url,
+			Description: field(csvFieldDescription),
+			Category:    field(csvFieldCategory),
+			Tags:        splitTags(field(csvFieldTags), options.TagSeparator),
+			CreatedAt:   parseFlexibleTime(field(csvFieldCreated)),
+		})
+	}
+
+	return bookmarks, nil
+}
+
+// resolveCSVColumns maps field names to column indexes using the explicit
+// mapping first and the known header aliases for unmapped fields
+func resolveCSVColumns(header []string, mapping CSVColumnMapping) map[string]int {
+	explicit := map[string]string{
+		csvFieldTitle:       mapping.Title,
+		csvFieldURL:         mapping.URL,
+		csvFieldDescription: mapping.Description,
+		csvFieldCategory:    mapping.Category,
+		csvFieldTags:        mapping.Tags,
+		csvFieldCreated:     mapping.Created,
+	}
+
+	normalized := make([]string, len(header))
+	for i, name := range header {
+		normalized[i] = normalizeCSVHeader(name)
+	}
+
+	columns := make(map[string]int)
+	for field, name := range explicit {
+		candidates := csvHeaderAliases[field]
+		if name != "" {
+			candidates = []string{strings.ToLower(strings.TrimSpace(name))}
+		}
+		for _, candidate := range candidates {
+			if index := indexOf(normalized, candidate); index >= 0 {
+				columns[field] = index
+				break
+			}
+		}
+	}
+	return columns
+}
+
+// normalizeCSVHeader lowercases a header name and strips spaces and BOM
+func normalizeCSVHeader(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+}
+
+// indexOf returns the index of value in values or -1
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// decodeText converts raw file data to UTF-8. Without an explicit encoding
+// BOMs are honored and data that is not valid UTF-8 is treated as GBK
+// (GB18030), the default of Chinese Excel exports.
+func decodeText(data []byte, encodingName string) (string, error) {
+	var decoder *encoding.Decoder
+
+	switch strings.ToLower(strings.ReplaceAll(encodingName, "-", "")) {
+	case "utf8":
+		return string(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))), nil
+	case "utf16":
+		decoder = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+	case "gbk", "gb2312", "gb18030":
+		decoder = simplifiedchinese.GB18030.NewDecoder()
+	case "":
+		switch {
+		case bytes.HasPrefix(data, []byte("\xef\xbb\xbf")):
+			return string(data[3:]), nil
+		case bytes.HasPrefix(data, []byte("\xff\xfe")), bytes.HasPrefix(data, []byte("\xfe\xff")):
+			decoder = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+		case utf8.Valid(data):
+			return string(data), nil
+		default:
+			decoder = simplifiedchinese.GB18030.NewDecoder()
+		}
+	default:
+		return "", fmt.Errorf("unsupported encoding: %s", encodingName)
+	}
+
+	decoded, err := decoder.Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s text: %w", encodingName, err)
+	}
+	return string(decoded), nil
+}
+
+// detectCSVDelimiter picks the candidate delimiter that splits the first
+// lines into the most columns consistently
+func detectCSVDelimiter(text string) rune {
+	best, bestFields := ',', 1
+
+	for _, delimiter := range csvDelimiters {
+		reader := csv.NewReader(strings.NewReader(text))
+		reader.Comma = delimiter
+		reader.LazyQuotes = true
+
+		fields := 0
+		consistent := true
+		for line := 0; line < 10; line++ {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				consistent = false
+				break
+			}
+			if line == 0 {
+				fields = len(record)
+			}
+		}
+
+		if consistent && fields > bestFields {
+			best, bestFields = delimiter, fields
+		}
+	}
+
+	return best
+}
+
+// unescapeDelimiter accepts "\t" and "tab" as a tab delimiter
+func unescapeDelimiter(delimiter string) string {
+	if delimiter == `\t` || strings.EqualFold(delimiter, "tab") {
+		return "\t"
+	}
+	return delimiter
+}
+
+// csvTagSeparators are the separators splitTags detects when none is given
+const csvTagSeparators = ",;|，、"
+
+// splitTags splits a tag list on the given separator, or on common
+// separators when none is given. Without a separator a tag may be quoted
+// as written by joinTags.
+func splitTags(value, separator string) []string {
+	var parts []string
+	if separator != "" {
+		parts = strings.Split(value, separator)
+	} else {
+		parts = splitQuotedTags(value)
+	}
+
+	var tags []string
+	for _, part := range parts {
+		if tag := strings.TrimSpace(part); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// splitQuotedTags splits on any of csvTagSeparators outside double quotes.
+// Quotes around a tag are removed and "" inside them is a literal quote.
+func splitQuotedTags(value string) []string {
+	var parts []string
+	var part strings.Builder
+	quoted := false
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quoted && r == '"' && i+1 < len(runes) && runes[i+1] == '"':
+			part.WriteRune('"')
+			i++
+		case quoted && r == '"':
+			quoted = false
+		case !quoted && r == '"' && strings.TrimSpace(part.String()) == "":
+			part.Reset()
+			quoted = true
+		case !quoted && strings.ContainsRune(csvTagSeparators, r):
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteRune(r)
+		}
+	}
+	return append(parts, part.String())
+}
+
+// joinTags joins tags with commas, quoting tags that contain a separator
+// or a quote so that splitTags reads them back unchanged
+func joinTags(tags []string) string {
+	quoted := make([]string, len(tags))
+	for i, tag := range tags {
+		if strings.ContainsAny(tag, csvTagSeparators+`"`) {
+			tag = `"` + strings.ReplaceAll(tag, `"`, `""`) + `"`
+		}
+		quoted[i] = tag
+	}
+	return strings.Join(quoted, ",")
+}
+
+// flexibleTimeLayouts are the date formats accepted by parseFlexibleTime
+var flexibleTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/1/2 15:04",
+	"2006/1/2",
+}
+
+// parseFlexibleTime parses common date formats and Unix timestamps in
+// seconds or milliseconds. Unparseable values yield the zero time.
+func parseFlexibleTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds > 1e12 {
+			return time.UnixMilli(seconds)
+		}
+		return time.Unix(seconds, 0)
+	}
+
+	for _, layout := range flexibleTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ExportCSV exports all bookmarks as CSV text
+func (a *App) ExportCSV(options CSVExportOptions) (string, error) {
+	urls, err := a.GetURLs()
+	if err != nil {
+		return "", err
+	}
+
+	columns := options.Columns
+	if len(columns) == 0 {
+		columns = csvDefaultColumns
+	}
+	for _, column := range columns {
+		if _, ok := csvHeaderAliases[column]; !ok {
+			return "", fmt.Errorf("unknown CSV column: %s", column)
+		}
+	}
+
+	var buf bytes.Buffer
+	if options.UTF8BOM {
+		buf.WriteString("\ufeff")
+	}
+
+	writer := csv.NewWriter(&buf)
+	if options.Delimiter != "" {
+		writer.Comma, _ = utf8.DecodeRuneInString(unescapeDelimiter(options.Delimiter))
+	}
+
+	if err := writer.Write(columns); err != nil {
+		return "", err
+	}
+
+	for _, url := range urls {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			switch column {
+			case csvFieldTitle:
+				record[i] = url.Title
+			case csvFieldURL:
+				record[i] = url.URL
+			case csvFieldDescription:
+				record[i] = url.Description
+			case csvFieldCategory:
+				record[i] = url.Category
+			case csvFieldTags:
+				record[i] = joinTags(url.Tags)
+			case csvFieldCreated:
+				record[i] = url.CreatedAt.Format(time.RFC3339)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestTagsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+	}{
+		{name: "plain", tags: []string{"go", "web"}},
+		{name: "comma", tags: []string{"a,b", "c"}},
+		{name: "other separators", tags: []string{"x;y", "p|q", "中，文", "顿、号"}},
+		{name: "quotes", tags: []string{`say "hi"`, `"quoted"`, `a,"b"`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := splitTags(joinTags(test.tags), "")
+			if !reflect.DeepEqual(got, test.tags) {
+				t.Errorf("splitTags(%q) = %q, want %q", joinTags(test.tags), got, test.tags)
+			}
+		})
+	}
+}
+
+func TestSplitTags(t *testing.T) {
+	tests := []struct {
+		value     string
+		separator string
+		want      []string
+	}{
+		{value: "a, b；c", want: []string{"a", "b；c"}},
+		{value: "a；b", separator: "；", want: []string{"a", "b"}},
+		{value: `a, "b, c" ,d`, want: []string{"a", "b, c", "d"}},
+		{value: `it"s, ok`, want: []string{`it"s`, "ok"}},
+		{value: " , ", want: nil},
+	}
+
+	for _, test := range tests {
+		if got := splitTags(test.value, test.separator); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitTags(%q, %q) = %q, want %q", test.value, test.separator, got, test.want)
+		}
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String("标题,网址")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utf16, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String("标题,网址")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		encoding string
+		want     string
+		wantErr  string
+	}{
+		{name: "utf-8", data: "标题,网址", want: "标题,网址"},
+		{name: "utf-8 bom", data: "\xef\xbb\xbf标题,网址", want: "标题,网址"},
+		{name: "detected gbk", data: gbk, want: "标题,网址"},
+		{name: "explicit gbk", data: gbk, encoding: "GBK", want: "标题,网址"},
+		{name: "utf-16 bom", data: utf16, want: "标题,网址"},
+		{name: "explicit utf-8 strips bom", data: "\xef\xbb\xbfa", encoding: "UTF-8", want: "a"},
+		{name: "unsupported", data: "a", encoding: "latin-9", wantErr: "unsupported encoding"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := decodeText([]byte(test.data), test.encoding)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDetectCSVDelimiter(t *testing.T) {
+	tests := []struct {
+		text string
+		want rune
+	}{
+		{text: "title,url\na,https://a.example/\n", want: ','},
+		{text: "title;url;tags\na;https://a.example/;x,y\n", want: ';'},
+		{text: "title\turl\na\thttps://a.example/\n", want: '\t'},
+		{text: "title|url\na|https://a.example/\n", want: '|'},
+		{text: `"a, b";url` + "\n" + `"c, d";https://c.example/` + "\n", want: ';'},
+		{text: "url\nhttps://a.example/\n", want: ','},
+	}
+
+	for _, test := range tests {
+		if got := detectCSVDelimiter(test.text); got != test.want {
+			t.Errorf("detectCSVDelimiter(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestResolveCSVColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		mapping CSVColumnMapping
+		want    map[string]int
+	}{
+		{
+			name:   "aliases",
+			header: []string{"\ufeffName", " Link ", "Notes", "Folder", "Labels", "time_added"},
+			want: map[string]int{
+				csvFieldTitle: 0, csvFieldURL: 1, csvFieldDescription: 2,
+				csvFieldCategory: 3, csvFieldTags: 4, csvFieldCreated: 5,
+			},
+		},
+		{
+			name:   "chinese",
+			header: []string{"网址", "标题", "分类"},
+			want:   map[string]int{csvFieldURL: 0, csvFieldTitle: 1, csvFieldCategory: 2},
+		},
+		{
+			name:    "explicit mapping wins",
+			header:  []string{"url", "Address", "Heading"},
+			mapping: CSVColumnMapping{URL: "address", Title: " HEADING "},
+			want:    map[string]int{csvFieldURL: 1, csvFieldTitle: 2},
+		},
+		{
+			name:    "missing explicit column",
+			header:  []string{"url"},
+			mapping: CSVColumnMapping{Title: "heading"},
+			want:    map[string]int{csvFieldURL: 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := resolveCSVColumns(test.header, test.mapping); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Events of long running jobs. The names and payloads are mirrored in
+// frontend/src/lib/events.ts; link check, watch and metadata events are
+// declared next to their jobs.
+const (
+	eventUpdateProgress  = "update:progress"  // UpdateProgress
+	eventUpdateAvailable = "update:available" // UpdateInfo, found by a background check
+	eventImportProgress  = "import:progress"  // JobProgress
+)
+
+// progressEventInterval limits progress events to 10 per second
+const progressEventInterval = 100 * time.Millisecond
+
+// JobProgress is the progress of a job without a payload of its own, such
+// as an import
+type JobProgress struct {
+	Job     string `json:"job"`   // e.g. "import"
+	Phase   string `json:"phase"` // "running", "completed" or "error"
+	Current int    `json:"current"`
+	Total   int    `json:"total"`
+	Message string `json:"message,omitempty"`
+}
+
+// emitEvent sends an event to the frontend. It is a no-op before the app
+// has started, e.g. when running from the command line.
+func (a *App) emitEvent(name string, data interface{}) {
+	if a.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, name, data)
+}
+
+// progressEmitter throttles the progress events of a job. Events coming
+// faster than progressEventInterval are coalesced and only the latest one
+// is sent when the interval ends, so the final state is never lost.
+type progressEmitter struct {
+	name string
+	emit func(name string, data interface{}) // App.emitEvent, replaced in tests
+
+	mutex   sync.Mutex
+	last    time.Time
+	pending interface{}
+	timer   *time.Timer
+	round   int // Identifies the current timer to its callback
+}
+
+// newProgressEmitter returns a throttled emitter for one event name
+func (a *App) newProgressEmitter(name string) *progressEmitter {
+	return &progressEmitter{name: name, emit: a.emitEvent}
+}
+
+// Emit sends data now or, within the interval, when the interval ends
+func (e *progressEmitter) Emit(data interface{}) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	wait := progressEventInterval - time.Since(e.last)
+	if wait <= 0 && e.timer == nil {
+		e.send(data)
+		return
+	}
+	e.pending = data
+	if e.timer == nil {
+		round := e.round
+		e.timer = time.AfterFunc(wait, func() { e.flushPending(round) })
+	}
+}
+
+// Final sends data at once and drops any pending event; used for states
+// such as completed or failed that must arrive last
+func (e *progressEmitter) Final(data interface{}) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.stopTimer()
+	e.pending = nil
+	e.send(data)
+}
+
+// Flush sends the pending event, if any, without waiting
+func (e *progressEmitter) Flush() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.stopTimer()
+	if e.pending != nil {
+		e.send(e.pending)
+		e.pending = nil
+	}
+}
+
+// flushPending runs when the interval of a throttled event has ended
+func (e *progressEmitter) flushPending(round int) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if round != e.round {
+		return // Stopped by Final or Flush
+	}
+	e.round++
+	e.timer = nil
+	if e.pending != nil {
+		e.send(e.pending)
+		e.pending = nil
+	}
+}
+
+// stopTimer cancels the scheduled send; callers must hold the mutex
+func (e *progressEmitter) stopTimer() {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+		e.round++
+	}
+}
+
+// send emits data; callers must hold the mutex
+func (e *progressEmitter) send(data interface{}) {
+	e.last = time.Now()
+	e.emit(e.name, data)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingEmitter returns a progress emitter that records what it sends
+func recordingEmitter() (*progressEmitter, func() []interface{}) {
+	var mutex sync.Mutex
+	var sent []interface{}
+	emitter := &progressEmitter{name: "test:progress", emit: func(name string, data interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		sent = append(sent, data)
+	}}
+	return emitter, func() []interface{} {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]interface{}(nil), sent...)
+	}
+}
+
+func TestProgressEmitter(t *testing.T) {
+	// Long enough for a pending event to be sent when the interval ends
+	const settle = 3 * progressEventInterval
+
+	tests := []struct {
+		name string
+		run  func(e *progressEmitter)
+		want []interface{}
+	}{
+		{
+			name: "first event at once",
+			run:  func(e *progressEmitter) { e.Emit(1) },
+			want: []interface{}{1},
+		},
+		{
+			name: "burst coalesced to the latest event",
+			run: func(e *progressEmitter) {
+				for i := 1; i <= 5; i++ {
+					e.Emit(i)
+				}
+			},
+			want: []interface{}{1, 5},
+		},
+		{
+			name: "events after the interval",
+			run: func(e *progressEmitter) {
+				e.Emit(1)
+				time.Sleep(settle)
+				e.Emit(2)
+			},
+			want: []interface{}{1, 2},
+		},
+		{
+			name: "final drops the pending event",
+			run: func(e *progressEmitter) {
+				e.Emit(1)
+				e.Emit(2)
+				e.Final("done")
+			},
+			want: []interface{}{1, "done"},
+		},
+		{
+			name: "final after the pending event was sent",
+			run: func(e *progressEmitter) {
+				e.Emit(1)
+				e.Emit(2)
+				time.Sleep(settle)
+				e.Final("done")
+			},
+			want: []interface{}{1, 2, "done"},
+		},
+		{
+			name: "flush sends the pending event",
+			run: func(e *progressEmitter) {
+				e.Emit(1)
+				e.Emit(2)
+				e.Flush()
+				e.Flush()
+			},
+			want: []interface{}{1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			emitter, sent := recordingEmitter()
+			test.run(emitter)
+
+			// Nothing stale may arrive once the interval has passed
+			time.Sleep(settle)
+			if got := sent(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("sent %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSetUpdateProgressEmitsWithoutLock(t *testing.T) {
+	a := NewApp()
+	previous := updateProgressEvents
+	t.Cleanup(func() {
+		updateProgressMutex.Lock()
+		updateProgressEvents = previous
+		currentUpdateProgress = nil
+		updateProgressMutex.Unlock()
+	})
+
+	// A frontend handler reads the progress while the event is sent
+	var seen []string
+	updateProgressMutex.Lock()
+	updateProgressEvents = &progressEmitter{name: eventUpdateProgress, emit: func(name string, data interface{}) {
+		done := make(chan *UpdateProgress)
+		go func() { done <- a.GetUpdateProgress() }()
+		select {
+		case progress := <-done:
+			seen = append(seen, progress.Phase)
+		case <-time.After(time.Second):
+			t.Error("GetUpdateProgress blocked while the event was sent")
+		}
+	}}
+	updateProgressMutex.Unlock()
+
+	a.setUpdateProgress(&UpdateProgress{Phase: "downloading", Progress: 10})
+	a.setUpdateProgress(&UpdateProgress{Phase: "completed", Progress: 100})
+	if want := []string{"downloading", "completed"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("phases seen = %v, want %v", seen, want)
+	}
+}
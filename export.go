@@ -0,0 +1,54 @@
+package main
+
+import (
+	"sort"
+)
+
+// categoryGroup is a category with the bookmarks that belong to it
+type categoryGroup struct {
+	Name     string
+	Category Category // Zero value for categories only referenced by bookmarks
+	URLs     []URLItem
+}
+
+// groupURLsByCategory groups bookmarks by category. Categories keep their
+// configured order, categories only referenced by bookmarks follow
+// alphabetically, and bookmarks within a group are sorted by their order.
+// Categories without bookmarks are included only when includeEmpty is set.
+func groupURLsByCategory(urls []URLItem, categories []Category, includeEmpty bool) []categoryGroup {
+	grouped := make(map[string][]URLItem)
+	for _, url := range urls {
+		grouped[url.Category] = append(grouped[url.Category], url)
+	}
+
+	var groups []categoryGroup
+	known := make(map[string]bool)
+	for _, category := range categories {
+		if known[category.Name] {
+			continue
+		}
+		known[category.Name] = true
+		if len(grouped[category.Name]) == 0 && !includeEmpty {
+			continue
+		}
+		groups = append(groups, categoryGroup{Name: category.Name, Category: category, URLs: grouped[category.Name]})
+	}
+
+	var extra []string
+	for name := range grouped {
+		if !known[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		groups = append(groups, categoryGroup{Name: name, Category: Category{Name: name}, URLs: grouped[name]})
+	}
+
+	for _, group := range groups {
+		items := group.URLs
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Order < items[j].Order })
+	}
+
+	return groups
+}
@@ -0,0 +1,523 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// faviconSize is the edge length favicons are normalized to
+const faviconSize = 64
+
+// faviconMaxBytes limits the size of downloaded icons
+const faviconMaxBytes = 1 << 20
+
+// faviconFetchTimeout is the time allowed for downloading one icon
+const faviconFetchTimeout = 8 * time.Second
+
+// faviconRetryAfter is how long a failed download is remembered before
+// the icon is fetched again
+const faviconRetryAfter = 24 * time.Hour
+
+// faviconDefaultColor is used for letter avatars of uncategorized bookmarks
+const faviconDefaultColor = "#6b7280"
+
+// faviconMaxDimension limits the width and height of icons that are
+// decoded, so that a small compressed file cannot allocate huge images
+const faviconMaxDimension = 1024
+
+// faviconSVGCSP keeps SVG icons from running scripts or loading anything
+// when they are opened directly
+const faviconSVGCSP = "default-src 'none'; style-src 'unsafe-inline'; sandbox"
+
+// faviconFetches deduplicates concurrent downloads of the same icon
+var faviconFetches sync.Map
+
+// faviconLookupCache keeps the bookmarks and category colours needed by
+// serveFavicon, reloaded when urls.json or categories.json change
+var faviconLookupCache struct {
+	mu        sync.Mutex
+	dataDir   string
+	urlsStamp fileStamp
+	catsStamp fileStamp
+	items     map[string]URLItem
+	colors    map[string]string
+}
+
+// fileStamp identifies a version of a file by its size and modification time
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// statStamp returns the stamp of a file, or the zero stamp when it is missing
+func statStamp(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{size: info.Size(), modTime: info.ModTime()}
+}
+
+// faviconLookup returns a bookmark and the colour of its category
+func (a *App) faviconLookup(id string) (URLItem, string, bool, error) {
+	cache := &faviconLookupCache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	dataDir := a.GetDataDir()
+	urlsStamp := statStamp(filepath.Join(dataDir, "urls.json"))
+	catsStamp := statStamp(filepath.Join(dataDir, "categories.json"))
+
+	if cache.items == nil || cache.dataDir != dataDir || cache.urlsStamp != urlsStamp {
+		urls, err := a.GetURLs()
+		if err != nil {
+			return URLItem{}, "", false, err
+		}
+		cache.items = make(map[string]URLItem, len(urls))
+		for _, item := range urls {
+			cache.items[item.ID] = item
+		}
+		cache.urlsStamp = urlsStamp
+	}
+	if cache.colors == nil || cache.dataDir != dataDir || cache.catsStamp != catsStamp {
+		cache.colors = make(map[string]string)
+		if categories, err := a.GetCategories(); err == nil {
+			for _, category := range categories {
+				if hexColorRegex.MatchString(category.Color) {
+					cache.colors[category.Name] = category.Color
+				}
+			}
+		}
+		cache.catsStamp = catsStamp
+	}
+	cache.dataDir = dataDir
+
+	item, ok := cache.items[id]
+	if !ok {
+		return URLItem{}, "", false, nil
+	}
+	color, ok := cache.colors[item.Category]
+	if !ok {
+		color = faviconDefaultColor
+	}
+	return item, color, true, nil
+}
+
+// faviconDir returns the directory cached favicons are stored in
+func (a *App) faviconDir() string {
+	return filepath.Join(a.GetDataDir(), "favicons")
+}
+
+// ClearFaviconCache removes all cached favicons
+func (a *App) ClearFaviconCache() error {
+	return os.RemoveAll(a.faviconDir())
+}
+
+// serveFavicon serves /favicon/{id}: the cached icon of a bookmark, or a
+// letter avatar in its category's colour when no icon is available
+func (a *App) serveFavicon(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/favicon/")
+
+	item, color, ok, err := a.faviconLookup(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if data, contentType, err := a.cachedFavicon(r.Context(), faviconSource(item)); err == nil {
+		if contentType == "image/svg+xml" {
+			// Downloaded SVGs are untrusted and served from the app's origin
+			w.Header().Set("Content-Security-Policy", faviconSVGCSP)
+		}
+		writeCachedAsset(w, r, data, contentType, 7*24*time.Hour)
+		return
+	}
+
+	avatar := letterAvatarSVG(faviconLetter(item), color)
+	w.Header().Set("Content-Security-Policy", faviconSVGCSP)
+	// Short lifetime so that a later successful download replaces it
+	writeCachedAsset(w, r, avatar, "image/svg+xml", time.Hour)
+}
+
+// faviconSource returns the icon URL of a bookmark, falling back to
+// /favicon.ico at the root of its site
+func faviconSource(item URLItem) string {
+	if item.Favicon != "" {
+		return item.Favicon
+	}
+	parsed, err := url.Parse(item.URL)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	return (&url.URL{Scheme: parsed.Scheme, Host: parsed.Host, Path: "/favicon.ico"}).String()
+}
+
+// cachedFavicon returns an icon from the disk cache, downloading and
+// normalizing it first if needed
+func (a *App) cachedFavicon(ctx context.Context, source string) ([]byte, string, error) {
+	if source == "" {
+		return nil, "", fmt.Errorf("no favicon URL")
+	}
+
+	sum := sha1.Sum([]byte(source))
+	key := hex.EncodeToString(sum[:])
+	base := filepath.Join(a.faviconDir(), key)
+
+	if data, err := os.ReadFile(base + ".png"); err == nil {
+		return data, "image/png", nil
+	}
+	if data, err := os.ReadFile(base + ".svg"); err == nil {
+		return data, "image/svg+xml", nil
+	}
+	if info, err := os.Stat(base + ".miss"); err == nil && time.Since(info.ModTime()) < faviconRetryAfter {
+		return nil, "", fmt.Errorf("favicon unavailable")
+	}
+
+	// Only one download per icon, other requests wait for its result
+	done := make(chan struct{})
+	if existing, loaded := faviconFetches.LoadOrStore(key, done); loaded {
+		select {
+		case <-existing.(chan struct{}):
+			return a.cachedFavicon(ctx, source)
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+	}
+	defer func() {
+		faviconFetches.Delete(key)
+		close(done)
+	}()
+
+	if err := os.MkdirAll(a.faviconDir(), 0755); err != nil {
+		return nil, "", err
+	}
+
+	client, err := a.httpClient(0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fetchCtx, cancel := context.WithTimeout(context.Background(), faviconFetchTimeout)
+	defer cancel()
+
+	data, contentType, err := fetchFavicon(fetchCtx, client, source)
+	if err != nil {
+		os.WriteFile(base+".miss", []byte(err.Error()), 0644)
+		return nil, "", err
+	}
+
+	ext := ".png"
+	if contentType == "image/svg+xml" {
+		ext = ".svg"
+	}
+	if err := os.WriteFile(base+ext, data, 0644); err != nil {
+		return nil, "", err
+	}
+	os.Remove(base + ".miss")
+
+	return data, contentType, nil
+}
+
+// fetchFavicon downloads an icon and converts it to a square PNG. SVG
+// icons are kept as they are since they scale without loss.
+func fetchFavicon(ctx context.Context, client *http.Client, source string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", AppName, strings.TrimPrefix(Version, "v")))
+	req.Header.Set("Accept", "image/avif,image/webp,image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, faviconMaxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > faviconMaxBytes {
+		return nil, "", fmt.Errorf("favicon larger than %d bytes", faviconMaxBytes)
+	}
+
+	if isSVG(data) {
+		return data, "image/svg+xml", nil
+	}
+
+	img, err := decodeIcon(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, resizeIcon(img, faviconSize)); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// isSVG reports whether data looks like an SVG document
+func isSVG(data []byte) bool {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	head = bytes.TrimSpace(bytes.TrimPrefix(head, []byte("\ufeff")))
+	return bytes.HasPrefix(head, []byte("<svg")) ||
+		(bytes.HasPrefix(head, []byte("<?xml")) || bytes.HasPrefix(head, []byte("<!--"))) && bytes.Contains(head, []byte("<svg"))
+}
+
+// decodeIcon decodes ICO files and every format registered with the
+// image package (PNG, JPEG, GIF, BMP, WebP)
+func decodeIcon(data []byte) (image.Image, error) {
+	if len(data) >= 6 && binary.LittleEndian.Uint16(data[0:]) == 0 && binary.LittleEndian.Uint16(data[2:]) == 1 {
+		return decodeICO(data)
+	}
+	if err := checkIconDimensions(data); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// checkIconDimensions reads the image header and rejects images larger
+// than faviconMaxDimension before any pixels are decoded
+func checkIconDimensions(data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > faviconMaxDimension || config.Height > faviconMaxDimension {
+		return fmt.Errorf("favicon dimensions %dx%d out of range", config.Width, config.Height)
+	}
+	return nil
+}
+
+// decodeICO decodes the largest image of a Windows icon file. Entries are
+// either embedded PNGs or BMP device independent bitmaps without a file
+// header, stored at double height with a 1-bit transparency mask.
+func decodeICO(data []byte) (image.Image, error) {
+	count := int(binary.LittleEndian.Uint16(data[4:]))
+	if count == 0 || len(data) < 6+16*count {
+		return nil, fmt.Errorf("invalid ICO file")
+	}
+
+	best, bestSize := -1, 0
+	for i := 0; i < count; i++ {
+		entry := data[6+16*i:]
+		size := int(entry[0])
+		if size == 0 {
+			size = 256
+		}
+		if size > bestSize {
+			best, bestSize = i, size
+		}
+	}
+
+	entry := data[6+16*best:]
+	length := int(binary.LittleEndian.Uint32(entry[8:]))
+	offset := int(binary.LittleEndian.Uint32(entry[12:]))
+	if offset < 0 || length <= 0 || offset+length > len(data) {
+		return nil, fmt.Errorf("invalid ICO entry")
+	}
+	payload := data[offset : offset+length]
+
+	if bytes.HasPrefix(payload, []byte("\x89PNG")) {
+		if err := checkIconDimensions(payload); err != nil {
+			return nil, err
+		}
+		return png.Decode(bytes.NewReader(payload))
+	}
+	return decodeICODIB(payload)
+}
+
+// decodeICODIB decodes an uncompressed 1, 4, 8, 24 or 32 bit ICO bitmap
+func decodeICODIB(dib []byte) (image.Image, error) {
+	if len(dib) < 40 {
+		return nil, fmt.Errorf("invalid ICO bitmap")
+	}
+
+	headerSize := int(binary.LittleEndian.Uint32(dib[0:]))
+	width := int(int32(binary.LittleEndian.Uint32(dib[4:])))
+	height := int(int32(binary.LittleEndian.Uint32(dib[8:]))) / 2 // Includes the mask
+	bitCount := int(binary.LittleEndian.Uint16(dib[14:]))
+	compression := binary.LittleEndian.Uint32(dib[16:])
+	colorsUsed := int(binary.LittleEndian.Uint32(dib[32:]))
+
+	if width <= 0 || height <= 0 || width > 256 || height > 256 || compression != 0 {
+		return nil, fmt.Errorf("unsupported ICO bitmap")
+	}
+
+	var palette []color.NRGBA
+	if bitCount <= 8 {
+		if colorsUsed == 0 {
+			colorsUsed = 1 << bitCount
+		}
+		start := headerSize
+		if start+4*colorsUsed > len(dib) {
+			return nil, fmt.Errorf("invalid ICO palette")
+		}
+		for i := 0; i < colorsUsed; i++ {
+			p := dib[start+4*i:]
+			palette = append(palette, color.NRGBA{R: p[2], G: p[1], B: p[0], A: 255})
+		}
+	}
+
+	stride := (width*bitCount + 31) / 32 * 4
+	maskStride := (width + 31) / 32 * 4
+	pixels := headerSize + 4*len(palette)
+	mask := pixels + stride*height
+	if mask > len(dib) {
+		return nil, fmt.Errorf("truncated ICO bitmap")
+	}
+	hasMask := mask+maskStride*height <= len(dib)
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		row := dib[pixels+stride*(height-1-y):] // Rows are stored bottom-up
+		for x := 0; x < width; x++ {
+			var c color.NRGBA
+			switch bitCount {
+			case 32:
+				c = color.NRGBA{R: row[4*x+2], G: row[4*x+1], B: row[4*x], A: row[4*x+3]}
+				if c.A != 0 {
+					hasAlpha = true
+				}
+			case 24:
+				c = color.NRGBA{R: row[3*x+2], G: row[3*x+1], B: row[3*x], A: 255}
+			case 8, 4, 1:
+				bit := x * bitCount
+				index := int(row[bit/8]>>(8-bitCount-bit%8)) & (1<<bitCount - 1)
+				if index < len(palette) {
+					c = palette[index]
+				}
+			default:
+				return nil, fmt.Errorf("unsupported ICO bit depth %d", bitCount)
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	// 32-bit icons carry their own alpha; older ones rely on the AND mask
+	if bitCount == 32 && hasAlpha {
+		return img, nil
+	}
+	for y := 0; hasMask && y < height; y++ {
+		row := dib[mask+maskStride*(height-1-y):]
+		for x := 0; x < width; x++ {
+			c := img.NRGBAAt(x, y)
+			c.A = 255
+			if row[x/8]&(0x80>>(x%8)) != 0 {
+				c.A = 0
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img, nil
+}
+
+// resizeIcon scales an image to fit a size x size square, centred on a
+// transparent background
+func resizeIcon(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == size && h == size {
+		return src
+	}
+
+	scaledW, scaledH := size, size
+	if w > h {
+		scaledH = h * size / w
+	} else if h > w {
+		scaledW = w * size / h
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	target := image.Rect((size-scaledW)/2, (size-scaledH)/2, (size+scaledW)/2, (size+scaledH)/2)
+
+	// Pixel art icons stay crisp when enlarged with nearest neighbour
+	scaler := draw.Scaler(draw.CatmullRom)
+	if w < size && h < size {
+		scaler = draw.NearestNeighbor
+	}
+	scaler.Scale(dst, target, src, bounds, draw.Over, nil)
+	return dst
+}
+
+// faviconLetter returns the character shown in a letter avatar
+func faviconLetter(item URLItem) string {
+	text := strings.TrimSpace(item.Title)
+	if text == "" {
+		if parsed, err := url.Parse(item.URL); err == nil {
+			text = strings.TrimPrefix(parsed.Hostname(), "www.")
+		}
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return strings.ToUpper(string(r))
+		}
+	}
+	return "?"
+}
+
+// letterAvatarSVG renders a rounded square with a letter in it
+func letterAvatarSVG(letter, background string) []byte {
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="%[1]d" viewBox="0 0 64 64">`+
+		`<rect width="64" height="64" rx="12" fill="%[2]s"/>`+
+		`<text x="32" y="32" dy=".35em" text-anchor="middle" fill="#fff" font-size="36" font-weight="600" `+
+		`font-family="-apple-system,'Segoe UI','PingFang SC','Microsoft YaHei',sans-serif">%[3]s</text></svg>`,
+		faviconSize, html.EscapeString(background), html.EscapeString(letter)))
+}
+
+// writeCachedAsset writes a response with an ETag and Cache-Control
+// header, answering conditional requests with 304 Not Modified
+func writeCachedAsset(w http.ResponseWriter, r *http.Request, data []byte, contentType string, maxAge time.Duration) {
+	sum := sha1.Sum(data)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds())))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	w.Write(data)
+}
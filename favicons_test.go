@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// encodeTestPNG returns a blank PNG of the given size
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeIconChecksDimensions(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		wantErr       bool
+	}{
+		{name: "small", width: 16, height: 16},
+		{name: "largest allowed", width: faviconMaxDimension, height: 1},
+		{name: "too wide", width: faviconMaxDimension + 1, height: 1, wantErr: true},
+		{name: "too tall", width: 1, height: 20000, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := decodeIcon(encodeTestPNG(t, test.width, test.height))
+			if (err != nil) != test.wantErr {
+				t.Errorf("error = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestServeFavicon(t *testing.T) {
+	setTestHome(t)
+
+	icons := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/icon.svg":
+			w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`))
+		case "/icon.png":
+			w.Write(encodeTestPNG(t, 32, 32))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer icons.Close()
+
+	a := NewApp()
+	if _, err := a.AddCategory("Colored", "", "#123456"); err != nil {
+		t.Fatal(err)
+	}
+	err := a.SaveURLs([]URLItem{
+		{ID: "svg", Title: "Svg", URL: icons.URL + "/", Favicon: icons.URL + "/icon.svg"},
+		{ID: "png", Title: "Png", URL: icons.URL + "/", Favicon: icons.URL + "/icon.png"},
+		{ID: "avatar", Title: "avatar", URL: icons.URL + "/missing", Favicon: icons.URL + "/missing.ico", Category: "Colored"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(id string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		a.serveFavicon(recorder, httptest.NewRequest(http.MethodGet, "/favicon/"+id, nil))
+		return recorder
+	}
+
+	tests := []struct {
+		id          string
+		wantStatus  int
+		wantType    string
+		wantCSP     bool
+		wantContent string
+	}{
+		{id: "svg", wantStatus: http.StatusOK, wantType: "image/svg+xml", wantCSP: true, wantContent: "<svg"},
+		{id: "png", wantStatus: http.StatusOK, wantType: "image/png", wantContent: "\x89PNG"},
+		{id: "avatar", wantStatus: http.StatusOK, wantType: "image/svg+xml", wantCSP: true, wantContent: `fill="#123456"`},
+		{id: "unknown", wantStatus: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			recorder := serve(test.id)
+			if recorder.Code != test.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, test.wantStatus)
+			}
+			if test.wantStatus != http.StatusOK {
+				return
+			}
+			header := recorder.Header()
+			if header.Get("Content-Type") != test.wantType {
+				t.Errorf("content type = %q, want %q", header.Get("Content-Type"), test.wantType)
+			}
+			if header.Get("X-Content-Type-Options") != "nosniff" {
+				t.Error("missing nosniff header")
+			}
+			if csp := header.Get("Content-Security-Policy"); (csp == faviconSVGCSP) != test.wantCSP {
+				t.Errorf("Content-Security-Policy = %q, want it set %v", csp, test.wantCSP)
+			}
+			if !strings.Contains(recorder.Body.String(), test.wantContent) {
+				t.Errorf("body does not contain %q", test.wantContent)
+			}
+		})
+	}
+
+	// The cached lookup follows later changes to the bookmarks
+	if err := a.SaveURLs([]URLItem{{ID: "added", Title: "Added", URL: "urlnav-test:added"}}); err != nil {
+		t.Fatal(err)
+	}
+	if code := serve("added").Code; code != http.StatusOK {
+		t.Errorf("status of added bookmark = %d, want 200", code)
+	}
+	if code := serve("svg").Code; code != http.StatusNotFound {
+		t.Errorf("status of removed bookmark = %d, want 404", code)
+	}
+}
+
+// icoFile wraps images in an ICO directory; sizes are the directory widths
+func icoFile(sizes []byte, payloads ...[]byte) []byte {
+	data := binary.LittleEndian.AppendUint16(nil, 0)
+	data = binary.LittleEndian.AppendUint16(data, 1)
+	data = binary.LittleEndian.AppendUint16(data, uint16(len(payloads)))
+	offset := 6 + 16*len(payloads)
+	for i, payload := range payloads {
+		data = append(data, sizes[i], sizes[i], 0, 0, 1, 0, 32, 0)
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(payload)))
+		data = binary.LittleEndian.AppendUint32(data, uint32(offset))
+		offset += len(payload)
+	}
+	for _, payload := range payloads {
+		data = append(data, payload...)
+	}
+	return data
+}
+
+// icoBitmap encodes a bottom-up DIB as stored in ICO files. pixel returns
+// BGR(A) bytes for 24 and 32 bit images and a palette index otherwise;
+// masked pixels are set in the transparency mask.
+func icoBitmap(width, height, bitCount int, palette []color.NRGBA, pixel func(x, y int) []byte, masked func(x, y int) bool) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, 40)
+	data = binary.LittleEndian.AppendUint32(data, uint32(width))
+	data = binary.LittleEndian.AppendUint32(data, uint32(height*2))
+	data = binary.LittleEndian.AppendUint16(data, 1)
+	data = binary.LittleEndian.AppendUint16(data, uint16(bitCount))
+	data = append(data, make([]byte, 16)...) // Compression, size, resolution
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(palette)))
+	data = binary.LittleEndian.AppendUint32(data, 0)
+	for _, c := range palette {
+		data = append(data, c.B, c.G, c.R, 0)
+	}
+
+	stride := (width*bitCount + 31) / 32 * 4
+	for y := height - 1; y >= 0; y-- {
+		row := make([]byte, stride)
+		for x := 0; x < width; x++ {
+			value := pixel(x, y)
+			if bitCount >= 24 {
+				copy(row[x*bitCount/8:], value)
+				continue
+			}
+			bit := x * bitCount
+			row[bit/8] |= value[0] << (8 - bitCount - bit%8)
+		}
+		data = append(data, row...)
+	}
+
+	maskStride := (width + 31) / 32 * 4
+	for y := height - 1; y >= 0; y-- {
+		row := make([]byte, maskStride)
+		for x := 0; x < width; x++ {
+			if masked != nil && masked(x, y) {
+				row[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		data = append(data, row...)
+	}
+	return data
+}
+
+func TestDecodeICO(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	clear := color.NRGBA{}
+	palette := []color.NRGBA{red, blue}
+
+	// Red top row, blue bottom row, top right pixel masked out
+	indexed := func(x, y int) []byte { return []byte{byte(y)} }
+	bgr := func(x, y int) []byte {
+		if y == 0 {
+			return []byte{0, 0, 255}
+		}
+		return []byte{255, 0, 0}
+	}
+	topRight := func(x, y int) bool { return x == 1 && y == 0 }
+	wantRows := [][]color.NRGBA{{red, clear}, {blue, blue}}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    [][]color.NRGBA
+		wantErr string
+	}{
+		{name: "1 bit", data: icoFile([]byte{2}, icoBitmap(2, 2, 1, palette, indexed, topRight)), want: wantRows},
+		{name: "4 bit", data: icoFile([]byte{2}, icoBitmap(2, 2, 4, palette, indexed, topRight)), want: wantRows},
+		{name: "8 bit", data: icoFile([]byte{2}, icoBitmap(2, 2, 8, palette, indexed, topRight)), want: wantRows},
+		{name: "24 bit", data: icoFile([]byte{2}, icoBitmap(2, 2, 24, nil, bgr, topRight)), want: wantRows},
+		{
+			name: "32 bit alpha ignores the mask",
+			data: icoFile([]byte{2}, icoBitmap(2, 2, 32, nil, func(x, y int) []byte {
+				if x == 1 && y == 0 {
+					return []byte{0, 0, 0, 0}
+				}
+				return append(bgr(x, y), 255)
+			}, func(x, y int) bool { return true })),
+			want: wantRows,
+		},
+		{
+			name: "largest entry is an embedded PNG",
+			data: icoFile([]byte{2, 32}, icoBitmap(2, 2, 8, palette, indexed, nil), encodeTestPNG(t, 32, 32)),
+		},
+		{
+			name:    "embedded PNG too large",
+			data:    icoFile([]byte{0}, encodeTestPNG(t, faviconMaxDimension+1, 1)),
+			wantErr: "out of range",
+		},
+		{
+			name:    "truncated bitmap",
+			data:    icoFile([]byte{2}, icoBitmap(2, 2, 24, nil, bgr, nil)[:48]),
+			wantErr: "truncated",
+		},
+		{
+			name:    "entry outside the file",
+			data:    icoFile([]byte{2}, icoBitmap(2, 2, 24, nil, bgr, nil))[:30],
+			wantErr: "invalid ICO",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img, err := decodeIcon(test.data)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.want == nil {
+				if size := img.Bounds().Size(); size != image.Pt(32, 32) {
+					t.Errorf("decoded %v, want the 32x32 PNG", size)
+				}
+				return
+			}
+			for y, row := range test.want {
+				for x, want := range row {
+					// The colour of a transparent pixel does not matter
+					got := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+					if got != want && (want.A != 0 || got.A != 0) {
+						t.Errorf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// firefoxTypeBookmark is the moz_bookmarks type of a URL bookmark
+const firefoxTypeBookmark = 1
+
+// Firefox built-in root folders, identified by their fixed GUIDs
+var firefoxRootGUIDs = map[string]bool{
+	"root________": true,
+	"menu________": true,
+	"toolbar_____": true,
+	"unfiled_____": true,
+	"mobile______": true,
+	"tags________": true,
+}
+
+// firefoxTagsRootGUID is the root folder holding Firefox tag folders
+const firefoxTagsRootGUID = "tags________"
+
+// firefoxPlacesRow is one row of moz_bookmarks joined with moz_places
+type firefoxPlacesRow struct {
+	ID           int64
+	Type         int
+	FK           int64
+	Parent       int64
+	Title        string
+	GUID         string
+	DateAdded    int64
+	LastModified int64
+	URL          string
+}
+
+// readFirefoxPlaces reads bookmarks from a Firefox places.sqlite database.
+// The database is copied to a temporary snapshot first so that a running
+// Firefox is never locked or modified.
+func readFirefoxPlaces(placesPath string) ([]importedBookmark, error) {
+	snapshotDir, err := os.MkdirTemp("", "urlnavigator-places-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(snapshotDir)
+
+	snapshotPath := filepath.Join(snapshotDir, "places.sqlite")
+	if err := copyFile(placesPath, snapshotPath); err != nil {
+		return nil, fmt.Errorf("failed to copy places.sqlite: %w", err)
+	}
+	// Uncheckpointed changes live in the write-ahead log
+	if _, err := os.Stat(placesPath + "-wal"); err == nil {
+		if err := copyFile(placesPath+"-wal", snapshotPath+"-wal"); err != nil {
+			return nil, fmt.Errorf("failed to copy places.sqlite-wal: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", snapshotPath+"?_pragma=query_only(1)")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT b.id, b.type, IFNULL(b.fk, 0), IFNULL(b.parent, 0), IFNULL(b.title, ''), IFNULL(b.guid, ''),
+		       IFNULL(b.dateAdded, 0), IFNULL(b.lastModified, 0), IFNULL(p.url, '')
+		FROM moz_bookmarks b
+		LEFT JOIN moz_places p ON b.fk = p.id
+		ORDER BY b.parent, b.position`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query places.sqlite: %w", err)
+	}
+	defer rows.Close()
+
+	var placesRows []firefoxPlacesRow
+	for rows.Next() {
+		var row firefoxPlacesRow
+		if err := rows.Scan(&row.ID, &row.Type, &row.FK, &row.Parent, &row.Title, &row.GUID,
+			&row.DateAdded, &row.LastModified, &row.URL); err != nil {
+			return nil, err
+		}
+		placesRows = append(placesRows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	keywords, err := readFirefoxKeywords(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return firefoxBookmarksFromRows(placesRows, keywords), nil
+}
+
+// readFirefoxKeywords returns bookmark keywords keyed by moz_places id.
+// Databases older than Firefox 39 have no moz_keywords.place_id column and
+// yield no keywords.
+func readFirefoxKeywords(db *sql.DB) (map[int64]string, error) {
+	keywords := make(map[int64]string)
+
+	rows, err := db.Query(`SELECT place_id, keyword FROM moz_keywords WHERE place_id IS NOT NULL`)
+	if err != nil {
+		return keywords, nil
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var placeID int64
+		var keyword string
+		if err := rows.Scan(&placeID, &keyword); err != nil {
+			return nil, err
+		}
+		keywords[placeID] = keyword
+	}
+	return keywords, rows.Err()
+}
+
+// firefoxBookmarksFromRows converts moz_bookmarks rows into bookmarks, using
+// the nearest user folder as the category. Entries under the tags root are
+// tag assignments: their folder title is added to the tags of every bookmark
+// pointing at the same place.
+func firefoxBookmarksFromRows(rows []firefoxPlacesRow, keywords map[int64]string) []importedBookmark {
+	byID := make(map[int64]firefoxPlacesRow, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+
+	tagsByPlace := make(map[int64][]string)
+	for _, row := range rows {
+		if row.Type != firefoxTypeBookmark || row.FK == 0 {
+			continue
+		}
+		tagFolder, ok := byID[row.Parent]
+		if !ok || tagFolder.Title == "" {
+			continue
+		}
+		if tagsRoot, ok := byID[tagFolder.Parent]; ok && tagsRoot.GUID == firefoxTagsRootGUID {
+			tagsByPlace[row.FK] = append(tagsByPlace[row.FK], tagFolder.Title)
+		}
+	}
+
+	var bookmarks []importedBookmark
+	for _, row := range rows {
+		if row.Type != firefoxTypeBookmark || row.URL == "" || isFirefoxInternalURL(row.URL) {
+			continue
+		}
+
+		category := ""
+		underTags := false
+		for parentID := row.Parent; parentID != 0; {
+			parent, ok := byID[parentID]
+			if !ok {
+				break
+			}
+			if parent.GUID == firefoxTagsRootGUID {
+				underTags = true
+				break
+			}
+			if category == "" && !firefoxRootGUIDs[parent.GUID] && parent.Title != "" {
+				category = parent.Title
+			}
+			parentID = parent.Parent
+		}
+		if underTags {
+			continue
+		}
+
+		bookmarks = append(bookmarks, importedBookmark{
+			Title:     row.Title,
+			URL:       row.URL,
+			Category:  category,
+			Tags:      tagsByPlace[row.FK],
+			Keyword:   keywords[row.FK],
+			GUID:      row.GUID,
+			CreatedAt: firefoxTime(row.DateAdded),
+			UpdatedAt: firefoxTime(row.LastModified),
+		})
+	}
+
+	return bookmarks
+}
+
+// firefoxBackupNode is a node of a Firefox bookmarks-*.json backup
+type firefoxBackupNode struct {
+	GUID         string              `json:"guid"`
+	Title        string              `json:"title"`
+	DateAdded    int64               `json:"dateAdded"`
+	LastModified int64               `json:"lastModified"`
+	TypeCode     int                 `json:"typeCode"`
+	Root         string              `json:"root"`
+	URI          string              `json:"uri"`
+	Tags         string              `json:"tags"`
+	Keyword      string              `json:"keyword"`
+	Children     []firefoxBackupNode `json:"children"`
+}
+
+// parseFirefoxBackup parses a Firefox JSON bookmark backup
+func parseFirefoxBackup(data []byte) ([]importedBookmark, error) {
+	var root firefoxBackupNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid Firefox bookmark backup: %w", err)
+	}
+	if root.Root == "" && root.GUID != "root________" {
+		return nil, fmt.Errorf("invalid Firefox bookmark backup: missing places root")
+	}
+
+	var bookmarks []importedBookmark
+	collectFirefoxBackupNodes(root.Children, "", &bookmarks)
+	return bookmarks, nil
+}
+
+// collectFirefoxBackupNodes recursively collects bookmarks from backup nodes.
+// User folders become categories; the tags root is skipped because tags are
+// already listed on each bookmark.
+func collectFirefoxBackupNodes(nodes []firefoxBackupNode, category string, bookmarks *[]importedBookmark) {
+	for _, node := range nodes {
+		switch {
+		case node.TypeCode == firefoxTypeBookmark:
+			if node.URI == "" || isFirefoxInternalURL(node.URI) {
+				continue
+			}
+
+			var tags []string
+			for _, tag := range strings.Split(node.Tags, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+
+			*bookmarks = append(*bookmarks, importedBookmark{
+				Title:     node.Title,
+				URL:       node.URI,
+				Category:  category,
+				Tags:      tags,
+				Keyword:   node.Keyword,
+				GUID:      node.GUID,
+				CreatedAt: firefoxTime(node.DateAdded),
+				UpdatedAt: firefoxTime(node.LastModified),
+			})
+		case len(node.Children) > 0:
+			if node.Root == "tagsFolder" {
+				continue
+			}
+			folderCategory := category
+			if node.Root == "" && node.Title != "" {
+				folderCategory = node.Title
+			}
+			collectFirefoxBackupNodes(node.Children, folderCategory, bookmarks)
+		}
+	}
+}
+
+// mozLz4Magic prefixes Firefox .jsonlz4 / .mozlz4 files
+const mozLz4Magic = "mozLz40\x00"
+
+// mozLz4MaxSize limits the decompressed size taken from the untrusted
+// header; real bookmark backups are a few megabytes at most
+const mozLz4MaxSize = 256 * 1024 * 1024
+
+// decompressMozLz4 decodes a mozlz4 file: the magic, a little-endian uint32
+// decompressed size and a single raw LZ4 block
+func decompressMozLz4(data []byte) ([]byte, error) {
+	if len(data) < len(mozLz4Magic)+4 || string(data[:len(mozLz4Magic)]) != mozLz4Magic {
+		return nil, fmt.Errorf("not a mozlz4 file")
+	}
+
+	size := binary.LittleEndian.Uint32(data[len(mozLz4Magic):])
+	if size > mozLz4MaxSize {
+		return nil, fmt.Errorf("mozlz4 file too large: %d bytes", size)
+	}
+	return decompressLZ4Block(data[len(mozLz4Magic)+4:], int(size))
+}
+
+// decompressLZ4Block decodes a raw LZ4 block of known decompressed size.
+// Output beyond size is treated as corruption.
+func decompressLZ4Block(src []byte, size int) ([]byte, error) {
+	if size < 0 || size > mozLz4MaxSize {
+		return nil, fmt.Errorf("lz4 size out of range: %d", size)
+	}
+	dst := make([]byte, 0, size)
+	errCorrupt := fmt.Errorf("corrupt lz4 block")
+
+	// readLength extends a 4-bit length with the following 255-terminated bytes
+	i := 0
+	readLength := func(length int) (int, error) {
+		if length != 15 {
+			return length, nil
+		}
+		for {
+			if i >= len(src) {
+				return 0, errCorrupt
+			}
+			b := src[i]
+			i++
+			length += int(b)
+			if b != 255 {
+				return length, nil
+			}
+		}
+	}
+
+	for i < len(src) {
+		token := src[i]
+		i++
+
+		literalLen, err := readLength(int(token >> 4))
+		if err != nil {
+			return nil, err
+		}
+		if i+literalLen > len(src) || len(dst)+literalLen > size {
+			return nil, errCorrupt
+		}
+		dst = append(dst, src[i:i+literalLen]...)
+		i += literalLen
+
+		// The last sequence carries literals only
+		if i >= len(src) {
+			break
+		}
+
+		if i+2 > len(src) {
+			return nil, errCorrupt
+		}
+		offset := int(src[i]) | int(src[i+1])<<8
+		i += 2
+		if offset == 0 || offset > len(dst) {
+			return nil, errCorrupt
+		}
+
+		matchLen, err := readLength(int(token & 0x0f))
+		if err != nil {
+			return nil, err
+		}
+		matchLen += 4
+		if len(dst)+matchLen > size {
+			return nil, errCorrupt
+		}
+
+		// Matches may overlap their own output, so copy byte by byte
+		start := len(dst) - offset
+		for k := 0; k < matchLen; k++ {
+			dst = append(dst, dst[start+k])
+		}
+	}
+
+	if len(dst) != size {
+		return nil, fmt.Errorf("lz4 size mismatch: expected %d bytes, got %d", size, len(dst))
+	}
+	return dst, nil
+}
+
+// sqliteMagic is the header of every SQLite database file
+const sqliteMagic = "SQLite format 3\x00"
+
+// parseFirefoxBookmarks detects the format of a Firefox bookmark file
+// (places.sqlite, .jsonlz4 backup or .json backup) and parses it
+func parseFirefoxBookmarks(data []byte) ([]importedBookmark, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte(sqliteMagic)):
+		tempDir, err := os.MkdirTemp("", "urlnavigator-places-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tempDir)
+
+		placesPath := filepath.Join(tempDir, "places.sqlite")
+		if err := os.WriteFile(placesPath, data, 0600); err != nil {
+			return nil, err
+		}
+		return readFirefoxPlaces(placesPath)
+	case bytes.HasPrefix(data, []byte(mozLz4Magic)):
+		jsonData, err := decompressMozLz4(data)
+		if err != nil {
+			return nil, err
+		}
+		return parseFirefoxBackup(jsonData)
+	default:
+		return parseFirefoxBackup(data)
+	}
+}
+
+// ImportFirefoxBookmarks imports a Firefox bookmark file given as base64:
+// a bookmarks-*.json or .jsonlz4 backup, or a places.sqlite database
+func (a *App) ImportFirefoxBookmarks(base64Data string) (int, error) {
+	data, err := base64.StdEncoding.DecodeString(base64Data)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file data: %w", err)
+	}
+
+	bookmarks, err := parseFirefoxBookmarks(data)
+	if err != nil {
+		return 0, err
+	}
+
+	return a.saveImportedBookmarks(bookmarks)
+}
+
+// isFirefoxInternalURL reports whether a bookmark points to a Firefox
+// internal page such as smart bookmark queries
+func isFirefoxInternalURL(url string) bool {
+	return strings.HasPrefix(url, "place:")
+}
+
+// firefoxTime converts a Firefox PRTime (microseconds since the Unix epoch)
+func firefoxTime(prTime int64) time.Time {
+	if prTime <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(prTime)
+}
+
+// copyFile copies src to dst, creating or truncating dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
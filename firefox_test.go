@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// mozLz4File wraps a raw LZ4 block in the mozlz4 header
+func mozLz4File(size uint32, block []byte) []byte {
+	data := []byte(mozLz4Magic)
+	data = binary.LittleEndian.AppendUint32(data, size)
+	return append(data, block...)
+}
+
+func TestDecompressMozLz4(t *testing.T) {
+	// "abcabcabcabc": 3 literals, then a 9 byte match at offset 3
+	valid := []byte{0x35, 'a', 'b', 'c', 0x03, 0x00}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr string
+	}{
+		{name: "valid", data: mozLz4File(12, append(valid, 0x00)), want: "abcabcabcabc"},
+		{name: "literals only", data: mozLz4File(5, []byte{0x50, 'h', 'e', 'l', 'l', 'o'}), want: "hello"},
+		{name: "bad magic", data: []byte("notmozlz4"), wantErr: "not a mozlz4 file"},
+		{name: "huge declared size", data: mozLz4File(0xffffffff, valid), wantErr: "too large"},
+		{name: "output exceeds size", data: mozLz4File(4, append(valid, 0x00)), wantErr: "corrupt"},
+		{name: "output short of size", data: mozLz4File(20, append(valid, 0x00)), wantErr: "size mismatch"},
+		{name: "offset before start", data: mozLz4File(12, []byte{0x15, 'a', 0x05, 0x00}), wantErr: "corrupt"},
+		{name: "truncated literals", data: mozLz4File(12, []byte{0x50, 'h', 'e'}), wantErr: "corrupt"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := decompressMozLz4(test.data)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseFirefoxBackupKeepsGUIDs(t *testing.T) {
+	backup := `{"guid": "root________", "root": "placesRoot", "children": [
+		{"guid": "menu________", "root": "bookmarksMenuFolder", "children": [
+			{"guid": "folder000001", "title": "Reading", "typeCode": 2, "children": [
+				{"guid": "bookmark0001", "title": "Example", "typeCode": 1, "uri": "https://example.com/"}
+			]}
+		]}
+	]}`
+
+	bookmarks, err := parseFirefoxBackup([]byte(backup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].GUID != "bookmark0001" || bookmarks[0].Category != "Reading" {
+		t.Errorf("got %+v, want one bookmark with its GUID in Reading", bookmarks)
+	}
+}
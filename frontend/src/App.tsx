@@ -19,6 +19,7 @@ import { ContextMenu } from '@/components/ContextMenu';
 import { ImportExport } from '@/components/ImportExport';
 import { SimpleVersionInfo } from '@/components/VersionInfo';
 import { apiCache, searchCache, withCache } from '@/lib/cacheManager';
+import { onAppEvent } from '@/lib/events';
 import { DndContext, closestCenter, DragEndEvent } from '@dnd-kit/core';
 import { SortableContext, rectSortingStrategy } from '@dnd-kit/sortable';
 
@@ -277,6 +278,13 @@ function App() {
     loadData();
   }, []);
 
+  // 新增网址的标题、描述和图标在后台获取完成后更新
+  useEffect(() => onAppEvent('url:metadata', (item) => {
+    apiCache.delete('urls');
+    searchCache.clear();
+    setUrls(current => current.map(url => url.id === item.id ? item : url));
+  }), []);
+
   useEffect(() => {
     if (searchTerm) {
       searchURLs(searchTerm);
@@ -116,7 +116,8 @@ export function AdvancedSearch({ categories, onSearch, onReset, triggerRef }: Ad
               {[
                 { key: 'title', label: '标题' },
                 { key: 'description', label: '描述' },
-                { key: 'url', label: 'URL' }
+                { key: 'url', label: 'URL' },
+                { key: 'content', label: '网页内容' }
               ].map(({ key, label }) => (
                 <Button
                   key={key}
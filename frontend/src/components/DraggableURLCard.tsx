@@ -52,20 +52,14 @@ export function DraggableURLCard({
         <div className="flex items-start justify-between">
           <div className="flex-1">
             <div className="flex items-center space-x-2">
-              {url.favicon ? (
-                <img
-                  src={url.favicon}
-                  alt={`${url.title} favicon`}
-                  className="w-5 h-5 flex-shrink-0 rounded-sm"
-                  onError={(e) => {
-                    (e.target as HTMLImageElement).style.display = 'none';
-                  }}
-                />
-              ) : (
-                <div className="w-5 h-5 flex-shrink-0 rounded-sm bg-muted flex items-center justify-center">
-                  <ExternalLink className="w-3 h-3 text-muted-foreground" />
-                </div>
-              )}
+              <img
+                src={`/favicon/${encodeURIComponent(url.id)}`}
+                alt={`${url.title} favicon`}
+                className="w-5 h-5 flex-shrink-0 rounded-sm"
+                onError={(e) => {
+                  (e.target as HTMLImageElement).style.visibility = 'hidden';
+                }}
+              />
               <CardTitle className="text-lg font-semibold text-foreground line-clamp-1">
                 {url.title}
               </CardTitle>
@@ -1,36 +1,167 @@
-import { useState, useRef } from 'react';
+import { useState, useRef, useEffect } from 'react';
 import { Upload, Download, FileText, Chrome, Globe, AlertCircle, CheckCircle } from 'lucide-react';
 import { Button } from '@/components/ui/button';
 import { Dialog, DialogContent, DialogDescription, DialogHeader, DialogTitle, DialogTrigger } from '@/components/ui/dialog';
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card';
 import * as AppService from '../../wailsjs/go/main/App';
+import { main } from '../../wailsjs/go/models';
+import { onAppEvent } from '@/lib/events';
+import { JobProgress } from '@/types';
 
 interface ImportExportProps {
   onImportComplete: () => void;
 }
 
+// Firefox JSON backups start at the places root instead of Chrome's "roots"
+const isFirefoxBackup = (content: string): boolean => {
+  try {
+    const data = JSON.parse(content);
+    return !data.roots && (data.root === 'placesRoot' || data.guid === 'root________');
+  } catch {
+    return false;
+  }
+};
+
+// The backend refuses Chrome files whose checksum does not match
+const isChecksumMismatch = (error: unknown): boolean =>
+  String(error instanceof Error ? error.message : error).includes('checksum mismatch');
+
+const readFileAsBase64 = (file: File): Promise<string> =>
+  new Promise((resolve, reject) => {
+    const reader = new FileReader();
+    reader.onload = () => {
+      const result = reader.result as string;
+      resolve(result.substring(result.indexOf(',') + 1));
+    };
+    reader.onerror = () => reject(reader.error);
+    reader.readAsDataURL(file);
+  });
+
 export function ImportExport({ onImportComplete }: ImportExportProps) {
   const [isOpen, setIsOpen] = useState(false);
   const [isImporting, setIsImporting] = useState(false);
   const [isExporting, setIsExporting] = useState(false);
+  const [importProgress, setImportProgress] = useState<JobProgress | null>(null);
   const [importResult, setImportResult] = useState<{
     success: boolean;
     count: number;
     message: string;
   } | null>(null);
 
+  const [browserProfiles, setBrowserProfiles] = useState<main.BrowserProfile[] | null>(null);
+  const [isDetecting, setIsDetecting] = useState(false);
+  const [includeSynced, setIncludeSynced] = useState(false);
+  // 校验和不匹配时可忽略校验重新导入
+  const [importAnyway, setImportAnyway] = useState<(() => void) | null>(null);
+
+  const [service, setService] = useState('pocket');
+
   const fileInputRef = useRef<HTMLInputElement>(null);
+  const serviceFileInputRef = useRef<HTMLInputElement>(null);
+
+  // 后端保存书签时推送导入进度
+  useEffect(() => onAppEvent('import:progress', setImportProgress), []);
+
+  const handleServiceFileSelect = async (event: React.ChangeEvent<HTMLInputElement>) => {
+    const file = event.target.files?.[0];
+    if (!file) {
+      return;
+    }
+
+    try {
+      setIsImporting(true);
+      setImportResult(null);
+
+      const importCount = await AppService.ImportServiceExport(service, await readFileAsBase64(file));
+      setImportResult({
+        success: true,
+        count: importCount,
+        message: `成功导入 ${importCount} 个书签`
+      });
+
+      onImportComplete();
+    } catch (error) {
+      console.error('Import failed:', error);
+      setImportResult({
+        success: false,
+        count: 0,
+        message: `导入失败: ${error instanceof Error ? error.message : String(error)}`
+      });
+    } finally {
+      setIsImporting(false);
+      if (serviceFileInputRef.current) {
+        serviceFileInputRef.current.value = '';
+      }
+    }
+  };
+
+  const detectBrowserProfiles = async () => {
+    try {
+      setIsDetecting(true);
+      const profiles = await AppService.ListBrowserProfiles();
+      setBrowserProfiles(profiles || []);
+    } catch (error) {
+      console.error('Browser detection failed:', error);
+      setBrowserProfiles([]);
+    } finally {
+      setIsDetecting(false);
+    }
+  };
 
-  const handleExport = async () => {
+  const importBrowserProfile = async (profile: main.BrowserProfile, skipChecksum = false) => {
+    try {
+      setIsImporting(true);
+      setImportResult(null);
+      setImportAnyway(null);
+
+      const importCount = await AppService.ImportBrowserProfile(
+        profile.id,
+        main.ChromeImportOptions.createFrom({ includeSynced, skipChecksum })
+      );
+      setImportResult({
+        success: true,
+        count: importCount,
+        message: `已从 ${profile.browserName} (${profile.name}) 导入 ${importCount} 个书签`
+      });
+
+      onImportComplete();
+    } catch (error) {
+      console.error('Import failed:', error);
+      setImportResult({
+        success: false,
+        count: 0,
+        message: `导入失败: ${error instanceof Error ? error.message : String(error)}`
+      });
+      if (!skipChecksum && isChecksumMismatch(error)) {
+        setImportAnyway(() => () => importBrowserProfile(profile, true));
+      }
+    } finally {
+      setIsImporting(false);
+    }
+  };
+
+  const handleExport = async (format: 'json' | 'csv' | 'md' = 'json') => {
     try {
       setIsExporting(true);
-      const exportData = await AppService.ExportBookmarks();
+      let exportData: string;
+      let mimeType: string;
+
+      if (format === 'csv') {
+        exportData = await AppService.ExportCSV(main.CSVExportOptions.createFrom({ columns: [], delimiter: ',', utf8Bom: true }));
+        mimeType = 'text/csv';
+      } else if (format === 'md') {
+        exportData = await AppService.ExportMarkdown(main.MarkdownExportOptions.createFrom({ title: 'URL Navigator 书签', style: 'headings', includeTags: true, includeEmpty: false }));
+        mimeType = 'text/markdown';
+      } else {
+        exportData = await AppService.ExportBookmarks();
+        mimeType = 'application/json';
+      }
 
-      const blob = new Blob([exportData], { type: 'application/json' });
+      const blob = new Blob([exportData], { type: mimeType });
       const url = URL.createObjectURL(blob);
       const link = document.createElement('a');
       link.href = url;
-      link.download = `url-navigator-bookmarks-${new Date().toISOString().split('T')[0]}.json`;
+      link.download = `url-navigator-bookmarks-${new Date().toISOString().split('T')[0]}.${format}`;
       document.body.appendChild(link);
       link.click();
       document.body.removeChild(link);
@@ -51,17 +182,33 @@ export function ImportExport({ onImportComplete }: ImportExportProps) {
     }
   };
 
-  const importBookmarks = async (file: File) => {
+  const importBookmarks = async (file: File, skipChecksum = false) => {
     try {
       setIsImporting(true);
       setImportResult(null);
+      setImportAnyway(null);
 
-      const fileContent = await file.text();
+      const fileName = file.name.toLowerCase();
       let importCount = 0;
 
-      if (file.name.endsWith('.json')) {
-        importCount = await AppService.ImportChromeBookmarks(fileContent);
-      } else if (file.name.endsWith('.html') || file.name.endsWith('.htm')) {
+      if (fileName.endsWith('.jsonlz4') || fileName.endsWith('.mozlz4') || fileName.endsWith('.sqlite')) {
+        importCount = await AppService.ImportFirefoxBookmarks(await readFileAsBase64(file));
+      } else if (fileName.endsWith('.json')) {
+        const fileContent = await file.text();
+        if (isFirefoxBackup(fileContent)) {
+          importCount = await AppService.ImportFirefoxBookmarks(await readFileAsBase64(file));
+        } else {
+          importCount = await AppService.ImportChromeBookmarksWithOptions(
+            fileContent,
+            main.ChromeImportOptions.createFrom({ includeSynced, skipChecksum })
+          );
+        }
+      } else if (fileName.endsWith('.csv') || fileName.endsWith('.tsv')) {
+        importCount = await AppService.ImportCSV(await readFileAsBase64(file), main.CSVImportOptions.createFrom({}));
+      } else if (fileName.endsWith('.md') || fileName.endsWith('.markdown')) {
+        importCount = await AppService.ImportMarkdown(await file.text());
+      } else if (fileName.endsWith('.html') || fileName.endsWith('.htm')) {
+        const fileContent = await file.text();
         importCount = await AppService.ImportNetscapeBookmarks(fileContent);
       } else {
         throw new Error('不支持的文件格式');
@@ -82,6 +229,9 @@ export function ImportExport({ onImportComplete }: ImportExportProps) {
         count: 0,
         message: `导入失败: ${error instanceof Error ? error.message : '未知错误'}`
       });
+      if (!skipChecksum && isChecksumMismatch(error)) {
+        setImportAnyway(() => () => importBookmarks(file, true));
+      }
     } finally {
       setIsImporting(false);
       if (fileInputRef.current) {
@@ -122,12 +272,12 @@ export function ImportExport({ onImportComplete }: ImportExportProps) {
                 导出书签
               </CardTitle>
               <CardDescription>
-                将当前所有书签和分类导出为JSON文件
+                将当前所有书签和分类导出为 JSON、CSV 或 Markdown 文件
               </CardDescription>
             </CardHeader>
             <CardContent>
               <Button
-                onClick={handleExport}
+                onClick={() => handleExport('json')}
                 disabled={isExporting}
                 className="w-full"
               >
@@ -143,6 +293,14 @@ export function ImportExport({ onImportComplete }: ImportExportProps) {
                   </>
                 )}
               </Button>
+              <div className="grid grid-cols-2 gap-2 mt-2">
+                <Button variant="outline" onClick={() => handleExport('csv')} disabled={isExporting}>
+                  导出 CSV
+                </Button>
+                <Button variant="outline" onClick={() => handleExport('md')} disabled={isExporting}>
+                  导出 Markdown
+                </Button>
+              </div>
             </CardContent>
           </Card>
 
@@ -169,11 +327,20 @@ export function ImportExport({ onImportComplete }: ImportExportProps) {
                   <Globe className="h-8 w-8 mr-3 text-orange-500" />
                   <div>
                     <p className="font-medium">Firefox/Edge 书签</p>
-                    <p className="text-sm text-muted-foreground">HTML 格式文件</p>
+                    <p className="text-sm text-muted-foreground">HTML、JSON(LZ4) 备份或 places.sqlite</p>
                   </div>
                 </div>
               </div>
 
+              <label className="flex items-center space-x-2 text-sm">
+                <input
+                  type="checkbox"
+                  checked={includeSynced}
+                  onChange={(e) => setIncludeSynced(e.target.checked)}
+                />
+                <span>包含 Chrome 移动设备书签</span>
+              </label>
+
               <Button
                 onClick={triggerFileInput}
                 disabled={isImporting}
@@ -183,6 +350,7 @@ export function ImportExport({ onImportComplete }: ImportExportProps) {
                   <>
                     <AlertCircle className="h-4 w-4 mr-2 animate-spin" />
                     导入中...
+                    {importProgress?.phase === 'running' && ` ${importProgress.current}/${importProgress.total}`}
                   </>
                 ) : (
                   <>
@@ -192,10 +360,75 @@ export function ImportExport({ onImportComplete }: ImportExportProps) {
                 )}
               </Button>
 
+              <Button
+                variant="outline"
+                onClick={detectBrowserProfiles}
+                disabled={isDetecting || isImporting}
+                className="w-full"
+              >
+                <Globe className="h-4 w-4 mr-2" />
+                {isDetecting ? '正在检测浏览器...' : '从已安装的浏览器导入'}
+              </Button>
+
+              <div className="flex items-center gap-2">
+                <select
+                  value={service}
+                  onChange={(e) => setService(e.target.value)}
+                  className="h-9 rounded-md border border-input bg-background px-3 text-sm"
+                >
+                  <option value="pocket">Pocket (HTML/CSV)</option>
+                  <option value="raindrop">Raindrop.io (CSV)</option>
+                  <option value="pinboard">Pinboard (JSON)</option>
+                  <option value="instapaper">Instapaper (CSV)</option>
+                  <option value="linkwarden">Linkwarden (JSON)</option>
+                </select>
+                <Button
+                  variant="outline"
+                  onClick={() => serviceFileInputRef.current?.click()}
+                  disabled={isImporting}
+                  className="flex-1"
+                >
+                  从稍后阅读服务导入
+                </Button>
+                <input
+                  ref={serviceFileInputRef}
+                  type="file"
+                  accept=".html,.htm,.csv,.json"
+                  onChange={handleServiceFileSelect}
+                  className="hidden"
+                />
+              </div>
+
+              {browserProfiles && (
+                <div className="space-y-2">
+                  {browserProfiles.length === 0 ? (
+                    <p className="text-sm text-muted-foreground">未检测到浏览器配置文件</p>
+                  ) : (
+                    browserProfiles.map(profile => (
+                      <div key={profile.id} className="flex items-center justify-between p-3 border border-border rounded-lg">
+                        <div className="min-w-0">
+                          <p className="font-medium">{profile.browserName} · {profile.name}</p>
+                          <p className="text-sm text-muted-foreground truncate">
+                            {profile.error ? profile.error : `${profile.bookmarkCount} 个书签`}
+                          </p>
+                        </div>
+                        <Button
+                          size="sm"
+                          onClick={() => importBrowserProfile(profile)}
+                          disabled={isImporting || !!profile.error || profile.bookmarkCount === 0}
+                        >
+                          导入
+                        </Button>
+                      </div>
+                    ))
+                  )}
+                </div>
+              )}
+
               <input
                 ref={fileInputRef}
                 type="file"
-                accept=".json,.html,.htm"
+                accept=".json,.jsonlz4,.mozlz4,.sqlite,.html,.htm,.csv,.tsv,.md,.markdown"
                 onChange={handleFileSelect}
                 className="hidden"
               />
@@ -214,6 +447,14 @@ export function ImportExport({ onImportComplete }: ImportExportProps) {
                     )}
                     <span className="font-medium">{importResult.message}</span>
                   </div>
+                  {!importResult.success && importAnyway && (
+                    <div className="mt-2 flex items-center justify-between gap-2 text-sm">
+                      <span>书签文件的校验和不一致，可能已被其他程序修改。</span>
+                      <Button variant="outline" size="sm" onClick={importAnyway} disabled={isImporting}>
+                        仍然导入
+                      </Button>
+                    </div>
+                  )}
                 </div>
               )}
 
@@ -221,8 +462,10 @@ export function ImportExport({ onImportComplete }: ImportExportProps) {
                 <p className="font-medium mb-2">导入说明：</p>
                 <ul className="space-y-1">
                   <li>• Chrome: 设置 → 书签 → 书签管理器 → 导出书签</li>
-                  <li>• Firefox: 书签 → 管理所有书签 → 导入和备份 → 导出书签为HTML</li>
+                  <li>• Firefox: 书签 → 管理所有书签 → 导入和备份 → 导出书签为HTML，或选择 bookmarkbackups 中的 .jsonlz4 备份</li>
                   <li>• Edge: 设置 → 导入浏览器数据 → 导出收藏夹</li>
+                  <li>• CSV: 需包含表头（标题、网址、分类、标签等），支持 GBK 编码的 Excel 导出</li>
+                  <li>• Markdown: 标题下的 [标题](链接) 将按标题归入分类</li>
                   <li>• 导入的书签将添加到"导入"分类中</li>
                 </ul>
               </div>
@@ -0,0 +1,63 @@
+import React from 'react';
+import { ReleaseNotes } from '@/types';
+
+interface ReleaseNotesViewProps {
+  notes: ReleaseNotes[];   // Newest first
+  fallback?: string;       // Raw notes shown when nothing could be parsed
+}
+
+const sectionLabels: Record<string, string> = {
+  breaking: '不兼容变更',
+  features: '新功能',
+  fixes: '问题修复',
+  other: '其他',
+};
+
+const sectionColors: Record<string, string> = {
+  breaking: 'text-red-600',
+  features: 'text-green-600',
+  fixes: 'text-blue-600',
+  other: 'text-gray-600',
+};
+
+// 按版本和分类展示更新说明，无法解析的版本显示原文
+const ReleaseNotesView: React.FC<ReleaseNotesViewProps> = ({ notes, fallback }) => {
+  if (notes.length === 0) {
+    return fallback ? <pre className="whitespace-pre-wrap">{fallback}</pre> : null;
+  }
+
+  return (
+    <div className="space-y-3">
+      {notes.map((release) => (
+        <div key={release.version}>
+          {notes.length > 1 && (
+            <div className="font-medium mb-1">
+              v{release.version}
+              {release.prerelease && (
+                <span className="ml-2 text-xs text-orange-600">预发布</span>
+              )}
+            </div>
+          )}
+          {(release.sections ?? []).length > 0 ? (
+            release.sections.map((section) => (
+              <div key={section.kind} className="mb-2">
+                <div className={`text-xs font-medium ${sectionColors[section.kind] ?? sectionColors.other}`}>
+                  {sectionLabels[section.kind] ?? section.title}
+                </div>
+                <ul className="list-disc pl-5">
+                  {section.items.map((item, index) => (
+                    <li key={index}>{item}</li>
+                  ))}
+                </ul>
+              </div>
+            ))
+          ) : (
+            release.body && <pre className="whitespace-pre-wrap">{release.body}</pre>
+          )}
+        </div>
+      ))}
+    </div>
+  );
+};
+
+export default ReleaseNotesView;
@@ -47,20 +47,14 @@ export function URLListView({
 
             {/* Favicon */}
             <div className="flex-shrink-0">
-              {url.favicon ? (
-                <img
-                  src={url.favicon}
-                  alt={`${url.title} favicon`}
-                  className="w-5 h-5 rounded-sm"
-                  onError={(e) => {
-                    (e.target as HTMLImageElement).style.display = 'none';
-                  }}
-                />
-              ) : (
-                <div className="w-5 h-5 rounded-sm bg-muted flex items-center justify-center">
-                  <ExternalLink className="w-3 h-3 text-muted-foreground" />
-                </div>
-              )}
+              <img
+                src={`/favicon/${encodeURIComponent(url.id)}`}
+                alt={`${url.title} favicon`}
+                className="w-5 h-5 rounded-sm"
+                onError={(e) => {
+                  (e.target as HTMLImageElement).style.visibility = 'hidden';
+                }}
+              />
             </div>
 
             {/* Category Indicator */}
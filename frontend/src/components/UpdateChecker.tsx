@@ -1,9 +1,11 @@
 import React, { useState, useEffect, useRef } from 'react';
-import { Download, CheckCircle, AlertCircle, RefreshCw, X } from 'lucide-react';
+import { Download, CheckCircle, AlertCircle, RefreshCw, RotateCcw, Sparkles, X } from 'lucide-react';
 import { Button } from '@/components/ui/button';
 import { Dialog, DialogContent, DialogDescription, DialogFooter, DialogHeader, DialogTitle } from '@/components/ui/dialog';
-import { UpdateInfo, UpdateProgress } from '@/types';
+import { MockUpdateServerStatus, PendingUpdate, UpdateHistory, UpdateInfo, UpdateProgress, WhatsNew } from '@/types';
+import ReleaseNotesView from '@/components/ReleaseNotesView';
 import * as AppService from '../../wailsjs/go/main/App';
+import { onAppEvent } from '@/lib/events';
 
 const UpdateChecker: React.FC = () => {
   const [updateInfo, setUpdateInfo] = useState<UpdateInfo | null>(null);
@@ -13,7 +15,18 @@ const UpdateChecker: React.FC = () => {
   const [showProgressDialog, setShowProgressDialog] = useState(false);
   const [currentVersion, setCurrentVersion] = useState<string>('');
   const [updateProgress, setUpdateProgress] = useState<UpdateProgress | null>(null);
-  const progressIntervalRef = useRef<number | null>(null);
+  const [channel, setChannel] = useState<string>('stable');
+  const [lastCheckedAt, setLastCheckedAt] = useState<string>('');
+  const [updateHistory, setUpdateHistory] = useState<UpdateHistory | null>(null);
+  const [showRollbackNotice, setShowRollbackNotice] = useState(false);
+  const [pendingUpdate, setPendingUpdate] = useState<PendingUpdate | null>(null);
+  const [installLaterMode, setInstallLaterMode] = useState(false);
+  const [whatsNew, setWhatsNew] = useState<WhatsNew | null>(null);
+  const [mockServer, setMockServer] = useState<MockUpdateServerStatus | null>(null);
+  const [mockFailure, setMockFailure] = useState('');
+  const [mockNoUpdate, setMockNoUpdate] = useState(false);
+  const [mockOmitLength, setMockOmitLength] = useState(false);
+  const unsubscribeProgressRef = useRef<(() => void) | null>(null);
 
     // 检查更新
   const checkForUpdates = async () => {
@@ -25,6 +38,7 @@ const UpdateChecker: React.FC = () => {
       ]);
       setUpdateInfo(updateData);
       setCurrentVersion(version);
+      setLastCheckedAt(new Date().toISOString());
 
       if (updateData.hasUpdate) {
         setShowUpdateDialog(true);
@@ -36,51 +50,57 @@ const UpdateChecker: React.FC = () => {
     }
   };
 
-  // 开始进度监控
+  // 订阅后端推送的更新进度
   const startProgressMonitoring = () => {
-    if (progressIntervalRef.current) {
-      clearInterval(progressIntervalRef.current);
-    }
+    stopProgressMonitoring();
 
-    progressIntervalRef.current = setInterval(async () => {
-      try {
-        const progress = await AppService.GetUpdateProgress();
-        setUpdateProgress(progress);
+    unsubscribeProgressRef.current = onAppEvent('update:progress', (progress) => {
+      setUpdateProgress(progress);
 
-        // 如果更新完成或出错，停止监控
-        if (progress?.phase === 'completed' || progress?.phase === 'error') {
-          if (progressIntervalRef.current) {
-            clearInterval(progressIntervalRef.current);
-            progressIntervalRef.current = null;
-          }
+      // 如果更新完成、已暂存、出错或已取消，停止监控
+      const failed = progress.phase === 'error' || progress.phase === 'verification_failed';
+      const cancelled = progress.phase === 'cancelled';
+      const staged = progress.phase === 'staged';
+      if (progress.phase === 'completed' || staged || failed || cancelled) {
+        stopProgressMonitoring();
 
-          // 如果出错，显示错误信息
-          if (progress.phase === 'error') {
-            setIsUpdating(false);
-            alert(`更新失败: ${progress.error || progress.message}`);
-            setShowProgressDialog(false);
-          }
+        // 如果出错，显示错误信息
+        if (failed) {
+          setIsUpdating(false);
+          alert(`更新失败: ${progress.error || progress.message}`);
+          setShowProgressDialog(false);
+        }
+        if (cancelled) {
+          setIsUpdating(false);
+          setShowProgressDialog(false);
+        }
+        // 已下载并验证，退出或下次启动时安装
+        if (staged) {
+          setIsUpdating(false);
+          setShowProgressDialog(false);
+          AppService.GetPendingUpdate()
+            .then((pending) => setPendingUpdate(pending))
+            .catch((error) => console.error('Failed to load pending update:', error));
         }
-      } catch (error) {
-        console.error('Failed to get update progress:', error);
       }
-    }, 500); // 每500ms检查一次进度
+    });
   };
 
   // 停止进度监控
   const stopProgressMonitoring = () => {
-    if (progressIntervalRef.current) {
-      clearInterval(progressIntervalRef.current);
-      progressIntervalRef.current = null;
+    if (unsubscribeProgressRef.current) {
+      unsubscribeProgressRef.current();
+      unsubscribeProgressRef.current = null;
     }
   };
 
-  // 下载并应用更新
-  const downloadAndApplyUpdate = async () => {
+  // 下载并应用更新；installLater 为 true 时在退出或下次启动时安装
+  const downloadAndApplyUpdate = async (installLater = false) => {
     if (!updateInfo?.updateUrl) return;
 
     try {
       setIsUpdating(true);
+      setInstallLaterMode(installLater);
       setShowUpdateDialog(false);
       setShowProgressDialog(true);
 
@@ -88,23 +108,163 @@ const UpdateChecker: React.FC = () => {
       startProgressMonitoring();
 
       // 开始下载更新
+      if (installLater) {
+        await AppService.StageUpdate(updateInfo.updateUrl);
+        return;
+      }
       await AppService.DownloadAndApplyUpdate(updateInfo.updateUrl);
       // 如果更新成功，应用会重启，这里的代码可能不会执行
     } catch (error) {
       console.error('Failed to update:', error);
-      alert('更新失败，请稍后重试');
+      // 取消和失败已由进度事件处理，不再重复提示
+      const progress = await AppService.GetUpdateProgress().catch(() => null);
+      const handled = ['cancelled', 'error', 'verification_failed'].includes(progress?.phase ?? '');
+      if (!handled) {
+        alert('更新失败，请稍后重试');
+      }
       setIsUpdating(false);
       setShowProgressDialog(false);
       stopProgressMonitoring();
     }
   };
 
-  // 组件挂载时检查更新
+  // 取消正在进行的下载，已下载的部分下次继续
+  const cancelUpdate = async () => {
+    try {
+      await AppService.CancelUpdate();
+    } catch (error) {
+      console.error('Failed to cancel update:', error);
+    }
+  };
+
+  // 立即安装已下载的更新，成功后应用会重启
+  const installPendingUpdate = async () => {
+    try {
+      await AppService.InstallPendingUpdate();
+    } catch (error) {
+      console.error('Failed to install pending update:', error);
+      alert(`安装失败: ${error}`);
+      setPendingUpdate(null);
+    }
+  };
+
+  // 回滚到上一版本，成功后应用会重启
+  const rollbackUpdate = async () => {
+    const version = updateHistory?.previousVersion ? ` v${updateHistory.previousVersion}` : '';
+    if (!confirm(`确定要回滚到上一版本${version}吗？应用将会重启。`)) return;
+    try {
+      await AppService.RollbackUpdate();
+    } catch (error) {
+      console.error('Failed to roll back update:', error);
+      alert(`回滚失败: ${error}`);
+    }
+  };
+
+  // 切换更新通道后重新检查
+  const changeChannel = async (value: string) => {
+    try {
+      await AppService.SetUpdateChannel(value);
+      setChannel(value);
+      await checkForUpdates();
+    } catch (error) {
+      console.error('Failed to change update channel:', error);
+    }
+  };
+
+  // 跳过当前提示的版本
+  const skipVersion = async () => {
+    if (!updateInfo?.latestVersion) return;
+    try {
+      await AppService.SkipUpdateVersion(updateInfo.latestVersion);
+      setShowUpdateDialog(false);
+    } catch (error) {
+      console.error('Failed to skip version:', error);
+    }
+  };
+
+  // 关闭更新内容，之后不再显示
+  const dismissWhatsNew = async () => {
+    setWhatsNew(null);
+    try {
+      await AppService.DismissWhatsNew();
+    } catch (error) {
+      console.error('Failed to dismiss what\'s new:', error);
+    }
+  };
+
+  // 启动或停止模拟更新服务器（仅调试模式），启动后立即检查
+  const toggleMockServer = async () => {
+    try {
+      if (mockServer?.running) {
+        await AppService.StopMockUpdateServer();
+        setMockServer(await AppService.GetMockUpdateServer());
+        setUpdateInfo(null);
+        return;
+      }
+      const status = await AppService.StartMockUpdateServer({
+        hasUpdate: !mockNoUpdate,
+        version: '',
+        latencyMs: 300,
+        bytesPerSecond: 0,
+        omitContentLength: mockOmitLength,
+        failure: mockFailure,
+      });
+      setMockServer(status);
+      await checkForUpdates();
+    } catch (error) {
+      console.error('Failed to toggle mock update server:', error);
+      alert(`模拟更新服务器操作失败: ${error}`);
+    }
+  };
+
+  // 组件挂载时读取更新通道和上次检查结果，检查由后台定时完成
   useEffect(() => {
-    checkForUpdates();
+    AppService.GetSettings()
+      .then((settings) => setChannel(settings.update?.channel || 'stable'))
+      .catch((error) => console.error('Failed to load settings:', error));
+    Promise.all([AppService.GetUpdateCheckStatus(), AppService.GetCurrentVersion()])
+      .then(([status, version]) => {
+        setCurrentVersion(version);
+        setLastCheckedAt(status.lastCheckedAt);
+        if (status.lastResult) {
+          setUpdateInfo(status.lastResult);
+          if (status.lastResult.hasUpdate && status.lastResult.updateUrl) {
+            setShowUpdateDialog(true);
+          }
+        }
+      })
+      .catch((error) => console.error('Failed to load update status:', error));
+    AppService.GetPendingUpdate()
+      .then((pending) => setPendingUpdate(pending))
+      .catch((error) => console.error('Failed to load pending update:', error));
+    AppService.GetMockUpdateServer()
+      .then((status) => setMockServer(status))
+      .catch((error) => console.error('Failed to load mock update server:', error));
+    // 更新后首次启动时展示更新内容
+    AppService.GetWhatsNew()
+      .then((notes) => setWhatsNew(notes))
+      .catch((error) => console.error('Failed to load what\'s new:', error));
+    AppService.GetUpdateHistory()
+      .then((history) => {
+        setUpdateHistory(history);
+        // 最近一天内自动回滚过时提示用户
+        const latest = history.entries[0];
+        if (latest?.status === 'rolled_back' && latest.automatic && latest.rolledBackAt &&
+            Date.now() - new Date(latest.rolledBackAt).getTime() < 24 * 60 * 60 * 1000) {
+          setShowRollbackNotice(true);
+        }
+      })
+      .catch((error) => console.error('Failed to load update history:', error));
+
+    // 后台检查发现新版本时提示
+    return onAppEvent('update:available', (info) => {
+      setUpdateInfo(info);
+      setLastCheckedAt(new Date().toISOString());
+      setShowUpdateDialog(true);
+    });
   }, []);
 
-  // 组件卸载时清理定时器
+  // 组件卸载时取消订阅
   useEffect(() => {
     return () => {
       stopProgressMonitoring();
@@ -115,11 +275,23 @@ const UpdateChecker: React.FC = () => {
 
   return (
     <>
-      <div className="flex justify-center">
+      <div className="flex justify-center items-center gap-2">
+        <select
+          value={channel}
+          onChange={(e) => changeChannel(e.target.value)}
+          disabled={isCheckingUpdate}
+          className="h-9 rounded-md border border-input bg-background px-2 text-sm"
+          title="更新通道"
+        >
+          <option value="stable">稳定版</option>
+          <option value="beta">测试版</option>
+          <option value="nightly">每日构建</option>
+        </select>
         <Button
           variant="outline"
           onClick={checkForUpdates}
           disabled={isCheckingUpdate}
+          title={lastCheckedAt && !lastCheckedAt.startsWith('0001') ? `上次检查: ${new Date(lastCheckedAt).toLocaleString()}` : undefined}
         >
           {isCheckingUpdate ? (
             <>
@@ -133,8 +305,86 @@ const UpdateChecker: React.FC = () => {
             </>
           )}
         </Button>
+        {pendingUpdate && (
+          <Button
+            onClick={installPendingUpdate}
+            disabled={isUpdating}
+            className="bg-green-600 hover:bg-green-700"
+            title="更新已下载，也会在退出时自动安装"
+          >
+            <RefreshCw className="h-4 w-4 mr-2" />
+            重启以安装{pendingUpdate.version ? ` v${pendingUpdate.version}` : ''}
+          </Button>
+        )}
+        {updateHistory?.rollbackAvailable && (
+          <Button
+            variant="ghost"
+            onClick={rollbackUpdate}
+            disabled={isUpdating}
+            title="回滚到上一版本"
+          >
+            <RotateCcw className="h-4 w-4" />
+          </Button>
+        )}
       </div>
 
+      {/* 调试：模拟更新服务器 */}
+      {mockServer?.available && (
+        <div className="flex justify-center items-center gap-2 mt-2 text-xs text-gray-500">
+          <select
+            value={mockFailure}
+            onChange={(e) => setMockFailure(e.target.value)}
+            disabled={mockServer.running}
+            className="h-7 rounded-md border border-input bg-background px-1"
+            title="模拟的失败"
+          >
+            <option value="">正常</option>
+            <option value="check_error">检查失败</option>
+            <option value="rate_limit">访问限制</option>
+            <option value="download_error">下载失败</option>
+            <option value="disconnect">下载中断</option>
+            <option value="checksum">校验和错误</option>
+            <option value="signature">签名错误</option>
+          </select>
+          <label className="flex items-center gap-1">
+            <input
+              type="checkbox"
+              checked={mockNoUpdate}
+              onChange={(e) => setMockNoUpdate(e.target.checked)}
+              disabled={mockServer.running}
+            />
+            无更新
+          </label>
+          <label className="flex items-center gap-1">
+            <input
+              type="checkbox"
+              checked={mockOmitLength}
+              onChange={(e) => setMockOmitLength(e.target.checked)}
+              disabled={mockServer.running}
+            />
+            无文件大小
+          </label>
+          <Button variant="ghost" size="sm" onClick={toggleMockServer} disabled={isUpdating} title={mockServer.url}>
+            {mockServer.running ? '停止模拟更新' : '模拟更新'}
+          </Button>
+        </div>
+      )}
+
+      {/* 自动回滚提示 */}
+      {showRollbackNotice && updateHistory?.entries[0] && (
+        <div className="fixed bottom-4 left-4 bg-orange-100 border border-orange-400 text-orange-700 px-4 py-3 rounded shadow-lg">
+          <div className="flex items-center">
+            <AlertCircle className="h-4 w-4 mr-2" />
+            <span className="text-sm">
+              v{updateHistory.entries[0].toVersion} 启动失败，已自动回滚到 v{updateHistory.entries[0].fromVersion}
+            </span>
+            <button className="ml-3" onClick={() => setShowRollbackNotice(false)}>
+              <X className="h-4 w-4" />
+            </button>
+          </div>
+        </div>
+      )}
+
       {/* 更新对话框 */}
       <Dialog open={showUpdateDialog} onOpenChange={setShowUpdateDialog}>
         <DialogContent>
@@ -158,25 +408,35 @@ const UpdateChecker: React.FC = () => {
                 <span className="text-sm font-medium">最新版本:</span>
                 <span className="text-sm text-green-600 font-medium">
                   {updateInfo?.latestVersion}
+                  {updateInfo?.prerelease && (
+                    <span className="ml-2 text-xs text-orange-600">预发布</span>
+                  )}
                 </span>
               </div>
             </div>
 
-            {updateInfo?.releaseNotes && (
+            {(updateInfo?.notes?.length || updateInfo?.releaseNotes) && (
               <div>
                 <h4 className="text-sm font-medium mb-2">更新内容:</h4>
-                <div className="bg-gray-50 p-3 rounded text-sm text-gray-700 max-h-32 overflow-y-auto">
-                  <pre className="whitespace-pre-wrap">{updateInfo.releaseNotes}</pre>
+                <div className="bg-gray-50 p-3 rounded text-sm text-gray-700 max-h-48 overflow-y-auto">
+                  <ReleaseNotesView notes={updateInfo?.notes ?? []} fallback={updateInfo?.releaseNotes} />
                 </div>
               </div>
             )}
 
             <div className="text-sm text-gray-500">
-              更新后应用将自动重启
+              立即更新后应用将自动重启；也可以先下载，在退出或下次启动时安装
             </div>
           </div>
 
           <DialogFooter>
+            <Button
+              variant="ghost"
+              onClick={skipVersion}
+              disabled={isUpdating}
+            >
+              跳过此版本
+            </Button>
             <Button
               variant="outline"
               onClick={() => setShowUpdateDialog(false)}
@@ -185,7 +445,14 @@ const UpdateChecker: React.FC = () => {
               稍后更新
             </Button>
             <Button
-              onClick={downloadAndApplyUpdate}
+              variant="outline"
+              onClick={() => downloadAndApplyUpdate(true)}
+              disabled={isUpdating}
+            >
+              退出时安装
+            </Button>
+            <Button
+              onClick={() => downloadAndApplyUpdate()}
               disabled={isUpdating}
               className="bg-green-600 hover:bg-green-700"
             >
@@ -205,7 +472,9 @@ const UpdateChecker: React.FC = () => {
               正在更新
             </DialogTitle>
             <DialogDescription>
-              请不要关闭应用程序，更新完成后将自动重启
+              {installLaterMode
+                ? '下载并验证完成后，更新将在退出或下次启动时安装'
+                : '请不要关闭应用程序，更新完成后将自动重启'}
             </DialogDescription>
           </DialogHeader>
 
@@ -216,9 +485,12 @@ const UpdateChecker: React.FC = () => {
                 <span className="font-medium">
                   {updateProgress?.message || '准备中...'}
                 </span>
-                <span className="text-gray-500">
-                  {updateProgress?.progress || 0}%
-                </span>
+                {/* 文件大小未知时不显示百分比 */}
+                {(updateProgress?.phase !== 'downloading' || !!updateProgress?.total) && (
+                  <span className="text-gray-500">
+                    {updateProgress?.progress || 0}%
+                  </span>
+                )}
               </div>
               <div className="w-full bg-gray-200 rounded-full h-2">
                 <div
@@ -254,6 +526,12 @@ const UpdateChecker: React.FC = () => {
                   <span>正在下载更新文件...</span>
                 </>
               )}
+              {updateProgress?.phase === 'verifying' && (
+                <>
+                  <RefreshCw className="h-4 w-4 text-blue-500 animate-spin" />
+                  <span>正在验证更新签名...</span>
+                </>
+              )}
               {updateProgress?.phase === 'installing' && (
                 <>
                   <RefreshCw className="h-4 w-4 text-orange-500 animate-spin" />
@@ -272,11 +550,26 @@ const UpdateChecker: React.FC = () => {
                   <span>更新失败: {updateProgress.error}</span>
                 </>
               )}
+              {updateProgress?.phase === 'verification_failed' && (
+                <>
+                  <AlertCircle className="h-4 w-4 text-red-500" />
+                  <span>更新验证失败，已取消安装: {updateProgress.error}</span>
+                </>
+              )}
             </div>
           </div>
 
+          {/* 下载期间可以取消 */}
+          {(!updateProgress || updateProgress.phase === 'downloading') && (
+            <DialogFooter>
+              <Button variant="outline" onClick={cancelUpdate}>
+                取消更新
+              </Button>
+            </DialogFooter>
+          )}
+
           {/* 只有出错时才显示关闭按钮 */}
-          {updateProgress?.phase === 'error' && (
+          {(updateProgress?.phase === 'error' || updateProgress?.phase === 'verification_failed') && (
             <DialogFooter>
               <Button
                 variant="outline"
@@ -293,6 +586,37 @@ const UpdateChecker: React.FC = () => {
         </DialogContent>
       </Dialog>
 
+      {/* 更新后的更新内容，只显示一次 */}
+      <Dialog open={!!whatsNew} onOpenChange={(open) => !open && dismissWhatsNew()}>
+        <DialogContent>
+          <DialogHeader>
+            <DialogTitle className="flex items-center">
+              <Sparkles className="h-5 w-5 mr-2" />
+              已更新到 v{whatsNew?.version}
+            </DialogTitle>
+            {whatsNew?.fromVersion && (
+              <DialogDescription>
+                从 v{whatsNew.fromVersion} 更新以来的变化
+              </DialogDescription>
+            )}
+          </DialogHeader>
+
+          <div className="bg-gray-50 p-3 rounded text-sm text-gray-700 max-h-80 overflow-y-auto">
+            {whatsNew?.notes?.length ? (
+              <ReleaseNotesView notes={whatsNew.notes} />
+            ) : (
+              <span>此版本没有更新说明</span>
+            )}
+          </div>
+
+          <DialogFooter>
+            <Button onClick={dismissWhatsNew}>
+              知道了
+            </Button>
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
+
       {/* 无更新提示 */}
       {updateInfo && !updateInfo.hasUpdate && !isCheckingUpdate && (
         <div className="fixed bottom-4 right-4 bg-green-100 border border-green-400 text-green-700 px-4 py-3 rounded shadow-lg">
@@ -0,0 +1,24 @@
+import { EventsOn } from '../../wailsjs/runtime/runtime';
+import { main } from '../../wailsjs/go/models';
+import { JobProgress, LinkCheckProgress, UpdateInfo, UpdateProgress, URLItem } from '@/types';
+
+// Events pushed by the backend, keyed by name. Mirrors the event constants
+// in events.go, linkcheck.go, watch.go and metadata.go.
+export interface AppEvents {
+  'update:progress': UpdateProgress;
+  'update:available': UpdateInfo;
+  'import:progress': JobProgress;
+  'linkcheck:progress': LinkCheckProgress;
+  'linkcheck:completed': main.LinkCheckSummary;
+  'watch:checked': { urlId: string; changed: boolean; error?: string };
+  'watch:changed': { urlId: string; title: string; url: string; change: main.PageChange };
+  'url:metadata': URLItem;
+}
+
+// Subscribes to a backend event; returns the function that unsubscribes
+export function onAppEvent<K extends keyof AppEvents>(
+  name: K,
+  handler: (payload: AppEvents[K]) => void
+): () => void {
+  return EventsOn(name, handler);
+}
@@ -5,10 +5,45 @@ export interface URLItem {
   description: string;
   category: string;
   tags: string[];
+  keyword?: string;
   favicon?: string;
   order: number;
   createdAt: string;
   updatedAt: string;
+  health?: LinkHealth;
+  watch?: WatchSettings;
+}
+
+export interface WatchSettings {
+  enabled: boolean;
+  intervalMinutes: number;
+  selector?: string;
+  lastCheckedAt?: string;
+  lastChangedAt?: string;
+  lastHash?: string;
+  lastError?: string;
+}
+
+export type LinkStatus =
+  | 'ok'
+  | 'redirected'
+  | 'restricted'
+  | 'rate_limited'
+  | 'broken'
+  | 'timeout'
+  | 'tls_error'
+  | 'error';
+
+export interface LinkHealth {
+  status: LinkStatus;
+  statusCode?: number;
+  finalUrl?: string;
+  redirects?: { url: string; statusCode: number }[];
+  latencyMs: number;
+  errorKind?: string;
+  error?: string;
+  checkedAt: string;
+  history?: { status: LinkStatus; statusCode?: number; latencyMs: number; checkedAt: string }[];
 }
 
 export interface Category {
@@ -24,11 +59,36 @@ export interface UpdateInfo {
   latestVersion: string;
   updateUrl: string;
   releaseNotes: string;
+  channel?: string;       // "stable", "beta", "nightly"
+  prerelease?: boolean;
   errorMessage?: string;
+  notes?: ReleaseNotes[];  // Releases after the current version, newest first
+}
+
+export interface ReleaseNoteSection {
+  kind: string;           // "breaking", "features", "fixes", "other"
+  title: string;
+  items: string[];
+}
+
+export interface ReleaseNotes {
+  version: string;
+  name?: string;
+  publishedAt?: string;
+  prerelease: boolean;
+  sections: ReleaseNoteSection[];
+  body: string;           // Raw Markdown
+}
+
+// Notes shown once after an update was installed
+export interface WhatsNew {
+  fromVersion: string;
+  version: string;
+  notes: ReleaseNotes[] | null;
 }
 
 export interface UpdateProgress {
-  phase: string;          // "downloading", "installing", "completed", "error"
+  phase: string;          // "downloading", "verifying", "installing", "staged", "completed", "error", "verification_failed", "cancelled"
   progress: number;       // 0-100
   speed?: string;         // Download speed (e.g. "1.2 MB/s")
   eta?: string;           // Estimated time (e.g. "2m 30s")
@@ -38,6 +98,75 @@ export interface UpdateProgress {
   error?: string;         // Error message if any
 }
 
+// Outcome of the last update check, kept across restarts
+export interface UpdateCheckStatus {
+  lastCheckedAt: string;
+  lastResult?: UpdateInfo;
+  lastError?: string;
+  failures: number;
+  nextCheckAt: string;
+  notifiedVersion?: string;
+}
+
+// A downloaded update waiting to be installed on quit or next launch
+export interface PendingUpdate {
+  version: string;
+  fromVersion: string;
+  targetPath: string;
+  binaryPath: string;
+  sha256: string;
+  stagedAt: string;
+}
+
+export interface UpdateHistoryEntry {
+  fromVersion: string;
+  toVersion: string;
+  installedAt: string;
+  status: string;         // "installed", "confirmed", "rolled_back"
+  rolledBackAt?: string;
+  automatic?: boolean;    // Rolled back after the update failed to start
+}
+
+export interface UpdateHistory {
+  entries: UpdateHistoryEntry[];  // Newest first
+  rollbackAvailable: boolean;
+  previousVersion?: string;
+}
+
+// Options of the mock update server used to test updates in dev builds
+export interface MockUpdateOptions {
+  hasUpdate: boolean;
+  version: string;            // Defaults to the next minor version
+  latencyMs: number;
+  bytesPerSecond: number;     // 0 for the default, negative for unlimited
+  omitContentLength: boolean;
+  failure: string;            // "", "check_error", "rate_limit", "download_error", "disconnect", "checksum", "signature"
+}
+
+export interface MockUpdateServerStatus {
+  available: boolean;
+  running: boolean;
+  url?: string;
+  options: MockUpdateOptions;
+}
+
+// Progress of a job without a payload of its own, e.g. an import
+export interface JobProgress {
+  job: string;            // "import"
+  phase: string;          // "running", "completed", "error"
+  current: number;
+  total: number;
+  message?: string;
+}
+
+export interface LinkCheckProgress {
+  checked: number;
+  total: number;
+  urlId: string;
+  url: string;
+  status: LinkStatus;
+}
+
 export interface AdvancedSearchOptions {
   query: string;
   category: string;
@@ -8,53 +8,137 @@ export function AddURL(arg1:string,arg2:string,arg3:string,arg4:string,arg5:Arra
 
 export function AdvancedSearchURLs(arg1:main.AdvancedSearchOptions):Promise<Array<main.URLItem>>;
 
+export function ApplyPendingUpdateOnExit():Promise<void>;
+
+export function ApplyURLRewrites(arg1:Array<main.URLRewrite>):Promise<number>;
+
+export function CancelLinkCheck():Promise<void>;
+
+export function CancelResolveRedirects():Promise<void>;
+
+export function CancelUpdate():Promise<void>;
+
 export function CheckForUpdates():Promise<main.UpdateInfo>;
 
+export function CheckLinks(arg1:main.AdvancedSearchOptions):Promise<main.LinkCheckSummary>;
+
+export function CheckPageNow(arg1:string):Promise<main.PageChange>;
+
+export function ClearFaviconCache():Promise<void>;
+
+export function CreateSnapshot(arg1:string):Promise<main.Snapshot>;
+
 export function DebugVersionInfo():Promise<Record<string, any>>;
 
+export function DeleteSnapshot(arg1:string,arg2:string):Promise<void>;
+
 export function DeleteURL(arg1:string):Promise<void>;
 
+export function DismissWhatsNew():Promise<void>;
+
 export function DownloadAndApplyUpdate(arg1:string):Promise<void>;
 
 export function EnsureDataDir():Promise<void>;
 
 export function ExportBookmarks():Promise<string>;
 
+export function ExportCSV(arg1:main.CSVExportOptions):Promise<string>;
+
+export function ExportMarkdown(arg1:main.MarkdownExportOptions):Promise<string>;
+
+export function ExportSite(arg1:main.SiteExportOptions,arg2:string):Promise<main.SiteExportResult>;
+
+export function FetchMetadata(arg1:string):Promise<main.PageMetadata>;
+
 export function ForceReloadVersion():Promise<void>;
 
 export function GetCategories():Promise<Array<main.Category>>;
 
+export function GetChanges(arg1:string):Promise<Array<main.PageChange>>;
+
 export function GetCurrentVersion():Promise<string>;
 
 export function GetCurrentVersionWithSource():Promise<Record<string, any>>;
 
 export function GetDataDir():Promise<string>;
 
+export function GetMockUpdateServer():Promise<main.MockUpdateServerStatus>;
+
+export function GetPendingUpdate():Promise<main.PendingUpdate>;
+
+export function GetSettings():Promise<main.Settings>;
+
 export function GetURLs():Promise<Array<main.URLItem>>;
 
+export function GetUpdateCheckStatus():Promise<main.UpdateCheckStatus>;
+
+export function GetUpdateHistory():Promise<main.UpdateHistory>;
+
 export function GetUpdateProgress():Promise<main.UpdateProgress>;
 
 export function GetVersionFromWails():Promise<string>;
 
 export function GetVersionInfo():Promise<main.VersionInfo>;
 
+export function GetWhatsNew():Promise<main.WhatsNew>;
+
+export function ImportBrowserProfile(arg1:string,arg2:main.ChromeImportOptions):Promise<number>;
+
+export function ImportCSV(arg1:string,arg2:main.CSVImportOptions):Promise<number>;
+
 export function ImportChromeBookmarks(arg1:string):Promise<number>;
 
+export function ImportChromeBookmarksWithOptions(arg1:string,arg2:main.ChromeImportOptions):Promise<number>;
+
+export function ImportFirefoxBookmarks(arg1:string):Promise<number>;
+
+export function ImportMarkdown(arg1:string):Promise<number>;
+
 export function ImportNetscapeBookmarks(arg1:string):Promise<number>;
 
+export function ImportServiceExport(arg1:string,arg2:string):Promise<number>;
+
+export function InstallPendingUpdate():Promise<void>;
+
+export function ListBrowserProfiles():Promise<Array<main.BrowserProfile>>;
+
+export function ListSnapshots(arg1:string):Promise<Array<main.Snapshot>>;
+
+export function PinUpdateVersion(arg1:string):Promise<void>;
+
+export function PreviewRewrite(arg1:Array<main.RewriteRule>,arg2:main.AdvancedSearchOptions):Promise<Array<main.URLRewrite>>;
+
+export function RefreshMetadata(arg1:Array<string>):Promise<main.MetadataRefreshResult>;
+
 export function ReorderURLs(arg1:Array<string>):Promise<void>;
 
+export function ResolveRedirects(arg1:Array<string>):Promise<Array<main.RedirectResolution>>;
+
 export function RestartApplication():Promise<void>;
 
+export function RollbackUpdate():Promise<void>;
+
 export function SaveCategories(arg1:Array<main.Category>):Promise<void>;
 
+export function SaveSettings(arg1:main.Settings):Promise<void>;
+
 export function SaveURLs(arg1:Array<main.URLItem>):Promise<void>;
 
 export function SearchURLs(arg1:string):Promise<Array<main.URLItem>>;
 
-export function TestNoUpdate():Promise<main.UpdateInfo>;
+export function SetUpdateChannel(arg1:string):Promise<void>;
+
+export function SetWatch(arg1:string,arg2:boolean,arg3:number,arg4:string):Promise<main.URLItem>;
+
+export function SkipUpdateVersion(arg1:string):Promise<void>;
+
+export function StageUpdate(arg1:string):Promise<void>;
+
+export function StartMockUpdateServer(arg1:main.MockUpdateOptions):Promise<main.MockUpdateServerStatus>;
+
+export function StopMockUpdateServer():Promise<void>;
 
-export function TestUpdateAvailable():Promise<main.UpdateInfo>;
+export function TestNetworkSettings(arg1:main.NetworkSettings,arg2:string):Promise<main.NetworkTestResult>;
 
 export function UpdateURL(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:Array<string>):Promise<main.URLItem>;
 
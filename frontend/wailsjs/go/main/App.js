@@ -14,18 +14,62 @@ export function AdvancedSearchURLs(arg1) {
   return window['go']['main']['App']['AdvancedSearchURLs'](arg1);
 }
 
+export function ApplyPendingUpdateOnExit() {
+  return window['go']['main']['App']['ApplyPendingUpdateOnExit']();
+}
+
+export function ApplyURLRewrites(arg1) {
+  return window['go']['main']['App']['ApplyURLRewrites'](arg1);
+}
+
+export function CancelLinkCheck() {
+  return window['go']['main']['App']['CancelLinkCheck']();
+}
+
+export function CancelResolveRedirects() {
+  return window['go']['main']['App']['CancelResolveRedirects']();
+}
+
+export function CancelUpdate() {
+  return window['go']['main']['App']['CancelUpdate']();
+}
+
 export function CheckForUpdates() {
   return window['go']['main']['App']['CheckForUpdates']();
 }
 
+export function CheckLinks(arg1) {
+  return window['go']['main']['App']['CheckLinks'](arg1);
+}
+
+export function CheckPageNow(arg1) {
+  return window['go']['main']['App']['CheckPageNow'](arg1);
+}
+
+export function ClearFaviconCache() {
+  return window['go']['main']['App']['ClearFaviconCache']();
+}
+
+export function CreateSnapshot(arg1) {
+  return window['go']['main']['App']['CreateSnapshot'](arg1);
+}
+
 export function DebugVersionInfo() {
   return window['go']['main']['App']['DebugVersionInfo']();
 }
 
+export function DeleteSnapshot(arg1, arg2) {
+  return window['go']['main']['App']['DeleteSnapshot'](arg1, arg2);
+}
+
 export function DeleteURL(arg1) {
   return window['go']['main']['App']['DeleteURL'](arg1);
 }
 
+export function DismissWhatsNew() {
+  return window['go']['main']['App']['DismissWhatsNew']();
+}
+
 export function DownloadAndApplyUpdate(arg1) {
   return window['go']['main']['App']['DownloadAndApplyUpdate'](arg1);
 }
@@ -38,6 +82,22 @@ export function ExportBookmarks() {
   return window['go']['main']['App']['ExportBookmarks']();
 }
 
+export function ExportCSV(arg1) {
+  return window['go']['main']['App']['ExportCSV'](arg1);
+}
+
+export function ExportMarkdown(arg1) {
+  return window['go']['main']['App']['ExportMarkdown'](arg1);
+}
+
+export function ExportSite(arg1, arg2) {
+  return window['go']['main']['App']['ExportSite'](arg1, arg2);
+}
+
+export function FetchMetadata(arg1) {
+  return window['go']['main']['App']['FetchMetadata'](arg1);
+}
+
 export function ForceReloadVersion() {
   return window['go']['main']['App']['ForceReloadVersion']();
 }
@@ -46,6 +106,10 @@ export function GetCategories() {
   return window['go']['main']['App']['GetCategories']();
 }
 
+export function GetChanges(arg1) {
+  return window['go']['main']['App']['GetChanges'](arg1);
+}
+
 export function GetCurrentVersion() {
   return window['go']['main']['App']['GetCurrentVersion']();
 }
@@ -58,10 +122,30 @@ export function GetDataDir() {
   return window['go']['main']['App']['GetDataDir']();
 }
 
+export function GetMockUpdateServer() {
+  return window['go']['main']['App']['GetMockUpdateServer']();
+}
+
+export function GetPendingUpdate() {
+  return window['go']['main']['App']['GetPendingUpdate']();
+}
+
+export function GetSettings() {
+  return window['go']['main']['App']['GetSettings']();
+}
+
 export function GetURLs() {
   return window['go']['main']['App']['GetURLs']();
 }
 
+export function GetUpdateCheckStatus() {
+  return window['go']['main']['App']['GetUpdateCheckStatus']();
+}
+
+export function GetUpdateHistory() {
+  return window['go']['main']['App']['GetUpdateHistory']();
+}
+
 export function GetUpdateProgress() {
   return window['go']['main']['App']['GetUpdateProgress']();
 }
@@ -74,26 +158,90 @@ export function GetVersionInfo() {
   return window['go']['main']['App']['GetVersionInfo']();
 }
 
+export function GetWhatsNew() {
+  return window['go']['main']['App']['GetWhatsNew']();
+}
+
+export function ImportBrowserProfile(arg1, arg2) {
+  return window['go']['main']['App']['ImportBrowserProfile'](arg1, arg2);
+}
+
+export function ImportCSV(arg1, arg2) {
+  return window['go']['main']['App']['ImportCSV'](arg1, arg2);
+}
+
 export function ImportChromeBookmarks(arg1) {
   return window['go']['main']['App']['ImportChromeBookmarks'](arg1);
 }
 
+export function ImportChromeBookmarksWithOptions(arg1, arg2) {
+  return window['go']['main']['App']['ImportChromeBookmarksWithOptions'](arg1, arg2);
+}
+
+export function ImportFirefoxBookmarks(arg1) {
+  return window['go']['main']['App']['ImportFirefoxBookmarks'](arg1);
+}
+
+export function ImportMarkdown(arg1) {
+  return window['go']['main']['App']['ImportMarkdown'](arg1);
+}
+
 export function ImportNetscapeBookmarks(arg1) {
   return window['go']['main']['App']['ImportNetscapeBookmarks'](arg1);
 }
 
+export function ImportServiceExport(arg1, arg2) {
+  return window['go']['main']['App']['ImportServiceExport'](arg1, arg2);
+}
+
+export function InstallPendingUpdate() {
+  return window['go']['main']['App']['InstallPendingUpdate']();
+}
+
+export function ListBrowserProfiles() {
+  return window['go']['main']['App']['ListBrowserProfiles']();
+}
+
+export function ListSnapshots(arg1) {
+  return window['go']['main']['App']['ListSnapshots'](arg1);
+}
+
+export function PinUpdateVersion(arg1) {
+  return window['go']['main']['App']['PinUpdateVersion'](arg1);
+}
+
+export function PreviewRewrite(arg1, arg2) {
+  return window['go']['main']['App']['PreviewRewrite'](arg1, arg2);
+}
+
+export function RefreshMetadata(arg1) {
+  return window['go']['main']['App']['RefreshMetadata'](arg1);
+}
+
 export function ReorderURLs(arg1) {
   return window['go']['main']['App']['ReorderURLs'](arg1);
 }
 
+export function ResolveRedirects(arg1) {
+  return window['go']['main']['App']['ResolveRedirects'](arg1);
+}
+
 export function RestartApplication() {
   return window['go']['main']['App']['RestartApplication']();
 }
 
+export function RollbackUpdate() {
+  return window['go']['main']['App']['RollbackUpdate']();
+}
+
 export function SaveCategories(arg1) {
   return window['go']['main']['App']['SaveCategories'](arg1);
 }
 
+export function SaveSettings(arg1) {
+  return window['go']['main']['App']['SaveSettings'](arg1);
+}
+
 export function SaveURLs(arg1) {
   return window['go']['main']['App']['SaveURLs'](arg1);
 }
@@ -102,12 +250,32 @@ export function SearchURLs(arg1) {
   return window['go']['main']['App']['SearchURLs'](arg1);
 }
 
-export function TestNoUpdate() {
-  return window['go']['main']['App']['TestNoUpdate']();
+export function SetUpdateChannel(arg1) {
+  return window['go']['main']['App']['SetUpdateChannel'](arg1);
+}
+
+export function SetWatch(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SetWatch'](arg1, arg2, arg3, arg4);
+}
+
+export function SkipUpdateVersion(arg1) {
+  return window['go']['main']['App']['SkipUpdateVersion'](arg1);
+}
+
+export function StageUpdate(arg1) {
+  return window['go']['main']['App']['StageUpdate'](arg1);
+}
+
+export function StartMockUpdateServer(arg1) {
+  return window['go']['main']['App']['StartMockUpdateServer'](arg1);
+}
+
+export function StopMockUpdateServer() {
+  return window['go']['main']['App']['StopMockUpdateServer']();
 }
 
-export function TestUpdateAvailable() {
-  return window['go']['main']['App']['TestUpdateAvailable']();
+export function TestNetworkSettings(arg1, arg2) {
+  return window['go']['main']['App']['TestNetworkSettings'](arg1, arg2);
 }
 
 export function UpdateURL(arg1, arg2, arg3, arg4, arg5, arg6) {
@@ -24,23 +24,900 @@ export namespace main {
 	        this.searchIn = source["searchIn"];
 	    }
 	}
+	export class BrowserProfile {
+	    id: string;
+	    browser: string;
+	    browserName: string;
+	    name: string;
+	    path: string;
+	    bookmarkCount: number;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BrowserProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.browser = source["browser"];
+	        this.browserName = source["browserName"];
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.bookmarkCount = source["bookmarkCount"];
+	        this.error = source["error"];
+	    }
+	}
+	export class CSVColumnMapping {
+	    title: string;
+	    url: string;
+	    description: string;
+	    category: string;
+	    tags: string;
+	    created: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CSVColumnMapping(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.url = source["url"];
+	        this.description = source["description"];
+	        this.category = source["category"];
+	        this.tags = source["tags"];
+	        this.created = source["created"];
+	    }
+	}
+	export class CSVExportOptions {
+	    columns: string[];
+	    delimiter: string;
+	    utf8Bom: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CSVExportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.columns = source["columns"];
+	        this.delimiter = source["delimiter"];
+	        this.utf8Bom = source["utf8Bom"];
+	    }
+	}
+	export class CSVImportOptions {
+	    mapping: CSVColumnMapping;
+	    delimiter: string;
+	    encoding: string;
+	    tagSeparator: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CSVImportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mapping = this.convertValues(source["mapping"], CSVColumnMapping);
+	        this.delimiter = source["delimiter"];
+	        this.encoding = source["encoding"];
+	        this.tagSeparator = source["tagSeparator"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class Category {
 	    id: string;
-	    name: string;
-	    description: string;
-	    color: string;
+	    name: string;
+	    description: string;
+	    color: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Category(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.color = source["color"];
+	    }
+	}
+	export class ChromeImportOptions {
+	    includeSynced: boolean;
+	    skipChecksum: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChromeImportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.includeSynced = source["includeSynced"];
+	        this.skipChecksum = source["skipChecksum"];
+	    }
+	}
+	export class DiffLine {
+	    op: string;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.op = source["op"];
+	        this.text = source["text"];
+	    }
+	}
+	export class HostHeader {
+	    host: string;
+	    name: string;
+	    value: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HostHeader(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.name = source["name"];
+	        this.value = source["value"];
+	    }
+	}
+	export class LinkCheckRecord {
+	    status: string;
+	    statusCode?: number;
+	    latencyMs: number;
+	    // Go type: time
+	    checkedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new LinkCheckRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.statusCode = source["statusCode"];
+	        this.latencyMs = source["latencyMs"];
+	        this.checkedAt = this.convertValues(source["checkedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LinkCheckSettings {
+	    enabled: boolean;
+	    intervalHours: number;
+	    concurrency: number;
+	    perHostLimit: number;
+	    perHostDelayMs: number;
+	    timeoutSeconds: number;
+	    historyLength: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LinkCheckSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.intervalHours = source["intervalHours"];
+	        this.concurrency = source["concurrency"];
+	        this.perHostLimit = source["perHostLimit"];
+	        this.perHostDelayMs = source["perHostDelayMs"];
+	        this.timeoutSeconds = source["timeoutSeconds"];
+	        this.historyLength = source["historyLength"];
+	    }
+	}
+	export class LinkCheckSummary {
+	    total: number;
+	    checked: number;
+	    broken: number;
+	    counts: Record<string, number>;
+	    cancelled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LinkCheckSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total = source["total"];
+	        this.checked = source["checked"];
+	        this.broken = source["broken"];
+	        this.counts = source["counts"];
+	        this.cancelled = source["cancelled"];
+	    }
+	}
+	export class LinkRedirect {
+	    url: string;
+	    statusCode: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LinkRedirect(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.statusCode = source["statusCode"];
+	    }
+	}
+	export class LinkHealth {
+	    status: string;
+	    statusCode?: number;
+	    finalUrl?: string;
+	    redirects?: LinkRedirect[];
+	    latencyMs: number;
+	    errorKind?: string;
+	    error?: string;
+	    // Go type: time
+	    checkedAt: any;
+	    history?: LinkCheckRecord[];
+	
+	    static createFrom(source: any = {}) {
+	        return new LinkHealth(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.statusCode = source["statusCode"];
+	        this.finalUrl = source["finalUrl"];
+	        this.redirects = this.convertValues(source["redirects"], LinkRedirect);
+	        this.latencyMs = source["latencyMs"];
+	        this.errorKind = source["errorKind"];
+	        this.error = source["error"];
+	        this.checkedAt = this.convertValues(source["checkedAt"], null);
+	        this.history = this.convertValues(source["history"], LinkCheckRecord);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class MarkdownExportOptions {
+	    title: string;
+	    style: string;
+	    includeTags: boolean;
+	    includeEmpty: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MarkdownExportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.style = source["style"];
+	        this.includeTags = source["includeTags"];
+	        this.includeEmpty = source["includeEmpty"];
+	    }
+	}
+	export class MetadataRefreshResult {
+	    updated: number;
+	    failed: number;
+	    errors?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new MetadataRefreshResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.updated = source["updated"];
+	        this.failed = source["failed"];
+	        this.errors = source["errors"];
+	    }
+	}
+	export class MockUpdateOptions {
+	    hasUpdate: boolean;
+	    version: string;
+	    latencyMs: number;
+	    bytesPerSecond: number;
+	    omitContentLength: boolean;
+	    failure: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MockUpdateOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hasUpdate = source["hasUpdate"];
+	        this.version = source["version"];
+	        this.latencyMs = source["latencyMs"];
+	        this.bytesPerSecond = source["bytesPerSecond"];
+	        this.omitContentLength = source["omitContentLength"];
+	        this.failure = source["failure"];
+	    }
+	}
+	export class MockUpdateServerStatus {
+	    available: boolean;
+	    running: boolean;
+	    url?: string;
+	    options: MockUpdateOptions;
+	
+	    static createFrom(source: any = {}) {
+	        return new MockUpdateServerStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.available = source["available"];
+	        this.running = source["running"];
+	        this.url = source["url"];
+	        this.options = this.convertValues(source["options"], MockUpdateOptions);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NetworkSettings {
+	    proxyMode: string;
+	    proxyUrl: string;
+	    pacUrl: string;
+	    noProxy: string;
+	    caBundlePath: string;
+	    connectTimeoutSeconds: number;
+	    requestTimeoutSeconds: number;
+	    downloadTimeoutMinutes: number;
+	    userAgent: string;
+	    hostHeaders: HostHeader[];
+	
+	    static createFrom(source: any = {}) {
+	        return new NetworkSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.proxyMode = source["proxyMode"];
+	        this.proxyUrl = source["proxyUrl"];
+	        this.pacUrl = source["pacUrl"];
+	        this.noProxy = source["noProxy"];
+	        this.caBundlePath = source["caBundlePath"];
+	        this.connectTimeoutSeconds = source["connectTimeoutSeconds"];
+	        this.requestTimeoutSeconds = source["requestTimeoutSeconds"];
+	        this.downloadTimeoutMinutes = source["downloadTimeoutMinutes"];
+	        this.userAgent = source["userAgent"];
+	        this.hostHeaders = this.convertValues(source["hostHeaders"], HostHeader);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NetworkTestResult {
+	    statusCode: number;
+	    proxy: string;
+	    latencyMs: number;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new NetworkTestResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.statusCode = source["statusCode"];
+	        this.proxy = source["proxy"];
+	        this.latencyMs = source["latencyMs"];
+	        this.error = source["error"];
+	    }
+	}
+	export class PageChange {
+	    id: string;
+	    urlId: string;
+	    // Go type: time
+	    detectedAt: any;
+	    hash: string;
+	    previousHash: string;
+	    added: number;
+	    removed: number;
+	    diff: DiffLine[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PageChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.urlId = source["urlId"];
+	        this.detectedAt = this.convertValues(source["detectedAt"], null);
+	        this.hash = source["hash"];
+	        this.previousHash = source["previousHash"];
+	        this.added = source["added"];
+	        this.removed = source["removed"];
+	        this.diff = this.convertValues(source["diff"], DiffLine);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PageMetadata {
+	    title: string;
+	    description: string;
+	    siteName: string;
+	    image: string;
+	    favicon: string;
+	    finalUrl: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PageMetadata(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.description = source["description"];
+	        this.siteName = source["siteName"];
+	        this.image = source["image"];
+	        this.favicon = source["favicon"];
+	        this.finalUrl = source["finalUrl"];
+	    }
+	}
+	export class PendingUpdate {
+	    version: string;
+	    fromVersion: string;
+	    targetPath: string;
+	    binaryPath: string;
+	    sha256: string;
+	    // Go type: time
+	    stagedAt: any;
+	    mock?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PendingUpdate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.fromVersion = source["fromVersion"];
+	        this.targetPath = source["targetPath"];
+	        this.binaryPath = source["binaryPath"];
+	        this.sha256 = source["sha256"];
+	        this.stagedAt = this.convertValues(source["stagedAt"], null);
+	        this.mock = source["mock"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RedirectResolution {
+	    urlId: string;
+	    title: string;
+	    url: string;
+	    finalUrl: string;
+	    chain: LinkRedirect[];
+	    permanent: boolean;
+	    status: string;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RedirectResolution(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.urlId = source["urlId"];
+	        this.title = source["title"];
+	        this.url = source["url"];
+	        this.finalUrl = source["finalUrl"];
+	        this.chain = this.convertValues(source["chain"], LinkRedirect);
+	        this.permanent = source["permanent"];
+	        this.status = source["status"];
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ReleaseNoteSection {
+	    kind: string;
+	    title: string;
+	    items: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ReleaseNoteSection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.title = source["title"];
+	        this.items = source["items"];
+	    }
+	}
+	export class ReleaseNotes {
+	    version: string;
+	    name?: string;
+	    // Go type: time
+	    publishedAt?: any;
+	    prerelease: boolean;
+	    sections: ReleaseNoteSection[];
+	    body: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReleaseNotes(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.name = source["name"];
+	        this.publishedAt = this.convertValues(source["publishedAt"], null);
+	        this.prerelease = source["prerelease"];
+	        this.sections = this.convertValues(source["sections"], ReleaseNoteSection);
+	        this.body = source["body"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RewriteRule {
+	    type: string;
+	    pattern: string;
+	    replacement: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RewriteRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.pattern = source["pattern"];
+	        this.replacement = source["replacement"];
+	    }
+	}
+	export class UpdateSettings {
+	    source: string;
+	    baseUrl: string;
+	    owner: string;
+	    repo: string;
+	    channel: string;
+	    pinnedVersion: string;
+	    skippedVersions: string[];
+	    autoCheck: boolean;
+	    checkIntervalHours: number;
+	    token?: string;
+	    autoRollback: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.baseUrl = source["baseUrl"];
+	        this.owner = source["owner"];
+	        this.repo = source["repo"];
+	        this.channel = source["channel"];
+	        this.pinnedVersion = source["pinnedVersion"];
+	        this.skippedVersions = source["skippedVersions"];
+	        this.autoCheck = source["autoCheck"];
+	        this.checkIntervalHours = source["checkIntervalHours"];
+	        this.token = source["token"];
+	        this.autoRollback = source["autoRollback"];
+	    }
+	}
+	export class Settings {
+	    linkCheck: LinkCheckSettings;
+	    network: NetworkSettings;
+	    update: UpdateSettings;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.linkCheck = this.convertValues(source["linkCheck"], LinkCheckSettings);
+	        this.network = this.convertValues(source["network"], NetworkSettings);
+	        this.update = this.convertValues(source["update"], UpdateSettings);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SiteExportOptions {
+	    title: string;
+	    category: string;
+	    filter?: AdvancedSearchOptions;
+	    formats: string[];
+	    theme: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SiteExportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.category = source["category"];
+	        this.filter = this.convertValues(source["filter"], AdvancedSearchOptions);
+	        this.formats = source["formats"];
+	        this.theme = source["theme"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SiteExportResult {
+	    files: string[];
+	    bookmarkCount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SiteExportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.files = source["files"];
+	        this.bookmarkCount = source["bookmarkCount"];
+	    }
+	}
+	export class Snapshot {
+	    id: string;
+	    urlId: string;
+	    url: string;
+	    title: string;
+	    size: number;
+	    resources: number;
+	    failed: number;
+	    // Go type: time
+	    createdAt: any;
 	
 	    static createFrom(source: any = {}) {
-	        return new Category(source);
+	        return new Snapshot(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.id = source["id"];
-	        this.name = source["name"];
-	        this.description = source["description"];
-	        this.color = source["color"];
+	        this.urlId = source["urlId"];
+	        this.url = source["url"];
+	        this.title = source["title"];
+	        this.size = source["size"];
+	        this.resources = source["resources"];
+	        this.failed = source["failed"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class WatchSettings {
+	    enabled: boolean;
+	    intervalMinutes: number;
+	    selector?: string;
+	    // Go type: time
+	    lastCheckedAt?: any;
+	    // Go type: time
+	    lastChangedAt?: any;
+	    lastHash?: string;
+	    lastError?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WatchSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.intervalMinutes = source["intervalMinutes"];
+	        this.selector = source["selector"];
+	        this.lastCheckedAt = this.convertValues(source["lastCheckedAt"], null);
+	        this.lastChangedAt = this.convertValues(source["lastChangedAt"], null);
+	        this.lastHash = source["lastHash"];
+	        this.lastError = source["lastError"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class URLItem {
 	    id: string;
@@ -49,11 +926,16 @@ export namespace main {
 	    description: string;
 	    category: string;
 	    tags: string[];
+	    keyword?: string;
+	    favicon?: string;
+	    sourceGuid?: string;
 	    order: number;
 	    // Go type: time
 	    createdAt: any;
 	    // Go type: time
 	    updatedAt: any;
+	    health?: LinkHealth;
+	    watch?: WatchSettings;
 	
 	    static createFrom(source: any = {}) {
 	        return new URLItem(source);
@@ -67,9 +949,14 @@ export namespace main {
 	        this.description = source["description"];
 	        this.category = source["category"];
 	        this.tags = source["tags"];
+	        this.keyword = source["keyword"];
+	        this.favicon = source["favicon"];
+	        this.sourceGuid = source["sourceGuid"];
 	        this.order = source["order"];
 	        this.createdAt = this.convertValues(source["createdAt"], null);
 	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	        this.health = this.convertValues(source["health"], LinkHealth);
+	        this.watch = this.convertValues(source["watch"], WatchSettings);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -90,13 +977,36 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class URLRewrite {
+	    urlId: string;
+	    title: string;
+	    oldUrl: string;
+	    newUrl: string;
+	    duplicate: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new URLRewrite(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.urlId = source["urlId"];
+	        this.title = source["title"];
+	        this.oldUrl = source["oldUrl"];
+	        this.newUrl = source["newUrl"];
+	        this.duplicate = source["duplicate"];
+	    }
+	}
 	export class UpdateInfo {
 	    hasUpdate: boolean;
 	    currentVersion: string;
 	    latestVersion: string;
 	    updateUrl: string;
 	    releaseNotes: string;
+	    channel: string;
+	    prerelease: boolean;
 	    errorMessage?: string;
+	    notes?: ReleaseNotes[];
 	
 	    static createFrom(source: any = {}) {
 	        return new UpdateInfo(source);
@@ -109,9 +1019,150 @@ export namespace main {
 	        this.latestVersion = source["latestVersion"];
 	        this.updateUrl = source["updateUrl"];
 	        this.releaseNotes = source["releaseNotes"];
+	        this.channel = source["channel"];
+	        this.prerelease = source["prerelease"];
 	        this.errorMessage = source["errorMessage"];
+	        this.notes = this.convertValues(source["notes"], ReleaseNotes);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UpdateCheckStatus {
+	    // Go type: time
+	    lastCheckedAt: any;
+	    lastResult?: UpdateInfo;
+	    lastError?: string;
+	    failures: number;
+	    // Go type: time
+	    nextCheckAt: any;
+	    notifiedVersion?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateCheckStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.lastCheckedAt = this.convertValues(source["lastCheckedAt"], null);
+	        this.lastResult = this.convertValues(source["lastResult"], UpdateInfo);
+	        this.lastError = source["lastError"];
+	        this.failures = source["failures"];
+	        this.nextCheckAt = this.convertValues(source["nextCheckAt"], null);
+	        this.notifiedVersion = source["notifiedVersion"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UpdateHistoryEntry {
+	    fromVersion: string;
+	    toVersion: string;
+	    // Go type: time
+	    installedAt: any;
+	    status: string;
+	    // Go type: time
+	    rolledBackAt?: any;
+	    automatic?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateHistoryEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fromVersion = source["fromVersion"];
+	        this.toVersion = source["toVersion"];
+	        this.installedAt = this.convertValues(source["installedAt"], null);
+	        this.status = source["status"];
+	        this.rolledBackAt = this.convertValues(source["rolledBackAt"], null);
+	        this.automatic = source["automatic"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UpdateHistory {
+	    entries: UpdateHistoryEntry[];
+	    rollbackAvailable: boolean;
+	    previousVersion?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateHistory(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entries = this.convertValues(source["entries"], UpdateHistoryEntry);
+	        this.rollbackAvailable = source["rollbackAvailable"];
+	        this.previousVersion = source["previousVersion"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
+	
+	
 	export class UpdateProgress {
 	    phase: string;
 	    progress: number;
@@ -138,6 +1189,7 @@ export namespace main {
 	        this.error = source["error"];
 	    }
 	}
+	
 	export class VersionInfo {
 	    version: string;
 	    github_owner: string;
@@ -160,6 +1212,41 @@ export namespace main {
 	        this.is_default = source["is_default"];
 	    }
 	}
+	
+	export class WhatsNew {
+	    fromVersion: string;
+	    version: string;
+	    notes: ReleaseNotes[];
+	
+	    static createFrom(source: any = {}) {
+	        return new WhatsNew(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fromVersion = source["fromVersion"];
+	        this.version = source["version"];
+	        this.notes = this.convertValues(source["notes"], ReleaseNotes);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
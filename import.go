@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Default category used when an imported bookmark has no folder
+const (
+	importDefaultCategory    = "导入"
+	importCategoryDesc       = "从浏览器导入的书签"
+	importDefaultCategoryHex = "#6366f1"
+	importFolderCategoryHex  = "#6b7280"
+)
+
+// importedBookmark is a bookmark read from an external source before it is stored
+type importedBookmark struct {
+	Title       string
+	URL         string
+	Description string
+	Category    string
+	Color       string // Color for a newly created category, optional
+	Tags        []string
+	Keyword     string
+	GUID        string // Identifier in the source, used to skip re-imports
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ensureCategory adds a category with the given name if it does not exist yet
+func (a *App) ensureCategory(name, description, color string) error {
+	categories, err := a.GetCategories()
+	if err != nil {
+		return err
+	}
+
+	for _, cat := range categories {
+		if cat.Name == name {
+			return nil
+		}
+	}
+
+	_, err = a.AddCategory(name, description, color)
+	return err
+}
+
+// saveImportedBookmarks stores parsed bookmarks with a single write and
+// creates any category they refer to. Bookmarks without a URL, and bookmarks
+// whose source GUID has been imported before, are skipped. Progress is
+// reported with import:progress events.
+func (a *App) saveImportedBookmarks(bookmarks []importedBookmark) (int, error) {
+	if len(bookmarks) == 0 {
+		return 0, nil
+	}
+
+	progress := a.newProgressEmitter(eventImportProgress)
+	fail := func(count int, err error) (int, error) {
+		progress.Final(JobProgress{Job: "import", Phase: "error", Total: len(bookmarks), Message: err.Error()})
+		return count, err
+	}
+
+	importedCount := 0
+	err := a.modifyURLs(func(urls []URLItem) ([]URLItem, error) {
+		// Calculate next order (highest order + 1)
+		order := 0
+		for _, existingURL := range urls {
+			if existingURL.Order >= order {
+				order = existingURL.Order + 1
+			}
+		}
+
+		importedGUIDs := make(map[string]bool)
+		for _, existingURL := range urls {
+			if existingURL.SourceGUID != "" {
+				importedGUIDs[existingURL.SourceGUID] = true
+			}
+		}
+
+		now := time.Now()
+		baseID := now.UnixNano()
+		seenCategories := make(map[string]bool)
+
+		for i, bookmark := range bookmarks {
+			progress.Emit(JobProgress{Job: "import", Phase: "running", Current: i, Total: len(bookmarks)})
+
+			url := strings.TrimSpace(bookmark.URL)
+			if url == "" || (bookmark.GUID != "" && importedGUIDs[bookmark.GUID]) {
+				continue
+			}
+			if bookmark.GUID != "" {
+				importedGUIDs[bookmark.GUID] = true
+			}
+
+			title := strings.TrimSpace(bookmark.Title)
+			if title == "" {
+				title = url
+			}
+
+			category := strings.TrimSpace(bookmark.Category)
+			if category == "" {
+				category = importDefaultCategory
+			}
+
+			if !seenCategories[category] {
+				seenCategories[category] = true
+				color := bookmark.Color
+				if color == "" {
+					color = importFolderCategoryHex
+				}
+				if category == importDefaultCategory {
+					color = importDefaultCategoryHex
+				}
+				if err := a.ensureCategory(category, importCategoryDesc, color); err != nil {
+					return nil, err
+				}
+			}
+
+			tags := bookmark.Tags
+			if tags == nil {
+				tags = []string{}
+			}
+
+			createdAt := bookmark.CreatedAt
+			if createdAt.IsZero() {
+				createdAt = now
+			}
+			updatedAt := bookmark.UpdatedAt
+			if updatedAt.IsZero() {
+				updatedAt = createdAt
+			}
+
+			urls = append(urls, URLItem{
+				ID:          fmt.Sprintf("%d", baseID+int64(importedCount)),
+				Title:       title,
+				URL:         url,
+				Description: bookmark.Description,
+				Category:    category,
+				Tags:        tags,
+				Keyword:     bookmark.Keyword,
+				SourceGUID:  bookmark.GUID,
+				Order:       order + importedCount,
+				CreatedAt:   createdAt,
+				UpdatedAt:   updatedAt,
+			})
+			importedCount++
+		}
+
+		return urls, nil
+	})
+	if err != nil {
+		return fail(0, err)
+	}
+
+	progress.Final(JobProgress{
+		Job:     "import",
+		Phase:   "completed",
+		Current: len(bookmarks),
+		Total:   len(bookmarks),
+		Message: fmt.Sprintf("已导入 %d 个书签", importedCount),
+	})
+	return importedCount, nil
+}
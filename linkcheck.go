@@ -0,0 +1,591 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Link health states stored in LinkHealth.Status
+const (
+	linkStatusOK          = "ok"           // 2xx without permanent redirects
+	linkStatusRedirected  = "redirected"   // 2xx after a permanent redirect
+	linkStatusRestricted  = "restricted"   // 401/403, reachable but needs login
+	linkStatusRateLimited = "rate_limited" // 429
+	linkStatusBroken      = "broken"       // 4xx/5xx, soft 404, DNS failure or refused
+	linkStatusTimeout     = "timeout"
+	linkStatusTLSError    = "tls_error"
+	linkStatusError       = "error"
+	linkStatusUnchecked   = "unchecked" // Only used in search filters
+)
+
+// Link check events sent to the frontend
+const (
+	eventLinkCheckProgress  = "linkcheck:progress"
+	eventLinkCheckCompleted = "linkcheck:completed"
+)
+
+// linkCheckMaxRedirects limits how many redirects are followed
+const linkCheckMaxRedirects = 10
+
+// linkCheckSaveBatch is the number of results saved at once
+const linkCheckSaveBatch = 20
+
+// LinkRedirect is one hop of a redirect chain
+type LinkRedirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode"`
+}
+
+// LinkCheckRecord is a past check result kept in the history
+type LinkCheckRecord struct {
+	Status     string    `json:"status"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	LatencyMs  int64     `json:"latencyMs"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// LinkHealth is the result of the latest check of a bookmark's URL
+type LinkHealth struct {
+	Status     string            `json:"status"`
+	StatusCode int               `json:"statusCode,omitempty"`
+	FinalURL   string            `json:"finalUrl,omitempty"`
+	Redirects  []LinkRedirect    `json:"redirects,omitempty"`
+	LatencyMs  int64             `json:"latencyMs"`
+	ErrorKind  string            `json:"errorKind,omitempty"` // dns, connection_refused, timeout, tls_*, ...
+	Error      string            `json:"error,omitempty"`
+	CheckedAt  time.Time         `json:"checkedAt"`
+	History    []LinkCheckRecord `json:"history,omitempty"`
+}
+
+// LinkCheckProgress is sent as links are checked, at most 10 times a second
+type LinkCheckProgress struct {
+	Checked int    `json:"checked"`
+	Total   int    `json:"total"`
+	URLID   string `json:"urlId"`
+	URL     string `json:"url"`
+	Status  string `json:"status"`
+}
+
+// LinkCheckSummary counts the results of a link check run
+type LinkCheckSummary struct {
+	Total     int            `json:"total"`
+	Checked   int            `json:"checked"`
+	Broken    int            `json:"broken"`
+	Counts    map[string]int `json:"counts"`
+	Cancelled bool           `json:"cancelled"`
+}
+
+// isBrokenLinkStatus reports whether a status means the link is dead
+func isBrokenLinkStatus(status string) bool {
+	switch status {
+	case linkStatusBroken, linkStatusTimeout, linkStatusTLSError, linkStatusError:
+		return true
+	}
+	return false
+}
+
+// matchesLinkStatus reports whether a bookmark matches a status: filter.
+// "broken" covers every dead state, "unchecked" bookmarks never checked.
+func matchesLinkStatus(health *LinkHealth, filter string) bool {
+	switch filter {
+	case linkStatusUnchecked:
+		return health == nil
+	case linkStatusBroken:
+		return health != nil && isBrokenLinkStatus(health.Status)
+	default:
+		return health != nil && health.Status == filter
+	}
+}
+
+// matchesAnyLinkStatus reports whether a bookmark matches one of the
+// status filters; an empty filter list matches everything
+func matchesAnyLinkStatus(health *LinkHealth, filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, filter := range filters {
+		if matchesLinkStatus(health, filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractStatusFilters removes status:xxx tokens from a search query and
+// returns the remaining query and the requested statuses
+func extractStatusFilters(query string) (string, []string) {
+	var rest, statuses []string
+	for _, field := range strings.Fields(query) {
+		if value, ok := strings.CutPrefix(strings.ToLower(field), "status:"); ok && value != "" {
+			statuses = append(statuses, value)
+			continue
+		}
+		rest = append(rest, field)
+	}
+	return strings.Join(rest, " "), statuses
+}
+
+// hostLimiter bounds parallel requests and request rate for one host
+type hostLimiter struct {
+	slots chan struct{}
+	mu    sync.Mutex
+	next  time.Time
+}
+
+// linkChecker probes URLs with per-host concurrency and rate limits
+type linkChecker struct {
+	client       *http.Client
+	userAgent    string
+	perHostLimit int
+	perHostDelay time.Duration
+
+	hostsMu sync.Mutex
+	hosts   map[string]*hostLimiter
+}
+
+// newLinkChecker creates a checker from the link check settings. Redirects
+// are not followed by the client so that every hop can be recorded.
+func newLinkChecker(settings LinkCheckSettings, client *http.Client) *linkChecker {
+	checkClient := *client
+	checkClient.Timeout = time.Duration(settings.TimeoutSeconds) * time.Second
+	checkClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	perHostLimit := settings.PerHostLimit
+	if perHostLimit <= 0 {
+		perHostLimit = 1
+	}
+
+	return &linkChecker{
+		client:       &checkClient,
+		userAgent:    fmt.Sprintf("%s/%s (link checker)", AppName, strings.TrimPrefix(Version, "v")),
+		perHostLimit: perHostLimit,
+		perHostDelay: time.Duration(settings.PerHostDelayMs) * time.Millisecond,
+		hosts:        make(map[string]*hostLimiter),
+	}
+}
+
+// acquire waits for a free slot and the rate limit of a host. The returned
+// function releases the slot.
+func (c *linkChecker) acquire(ctx context.Context, host string) (func(), error) {
+	c.hostsMu.Lock()
+	limiter, ok := c.hosts[host]
+	if !ok {
+		limiter = &hostLimiter{slots: make(chan struct{}, c.perHostLimit)}
+		c.hosts[host] = limiter
+	}
+	c.hostsMu.Unlock()
+
+	select {
+	case limiter.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-limiter.slots }
+
+	limiter.mu.Lock()
+	wait := time.Until(limiter.next)
+	start := time.Now()
+	if wait > 0 {
+		start = limiter.next
+	}
+	limiter.next = start.Add(c.perHostDelay)
+	limiter.mu.Unlock()
+
+	if wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	return release, nil
+}
+
+// do sends a single request and discards the body
+func (c *linkChecker) do(ctx context.Context, method, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+
+	release, err := c.acquire(ctx, req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the status matters; read a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	return resp, nil
+}
+
+// check probes a URL with HEAD and falls back to GET when the server
+// rejects or fails HEAD requests, following redirects manually
+func (c *linkChecker) check(ctx context.Context, rawURL string) LinkHealth {
+	start := time.Now()
+	health := LinkHealth{CheckedAt: start}
+	finish := func() LinkHealth {
+		health.LatencyMs = time.Since(start).Milliseconds()
+		return health
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		health.Status = linkStatusError
+		health.ErrorKind = "unsupported_url"
+		health.Error = "only http and https links can be checked"
+		return finish()
+	}
+
+	current := rawURL
+	method := http.MethodHead
+	permanent := false
+
+	for hops := 0; hops <= linkCheckMaxRedirects; {
+		resp, err := c.do(ctx, method, current)
+		if err != nil {
+			status, kind := classifyLinkError(err)
+			// Some servers drop HEAD connections; retry those with GET
+			if method == http.MethodHead && status == linkStatusError {
+				method = http.MethodGet
+				continue
+			}
+			health.Status, health.ErrorKind, health.Error = status, kind, err.Error()
+			return finish()
+		}
+
+		if resp.StatusCode >= 300 && resp.StatusCode < 400 && resp.Header.Get("Location") != "" {
+			next, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+			if err != nil {
+				health.Status, health.ErrorKind, health.Error = linkStatusError, "bad_redirect", err.Error()
+				return finish()
+			}
+			health.Redirects = append(health.Redirects, LinkRedirect{URL: next.String(), StatusCode: resp.StatusCode})
+			if resp.StatusCode == http.StatusMovedPermanently || resp.StatusCode == http.StatusPermanentRedirect {
+				permanent = true
+			}
+			current = next.String()
+			method = http.MethodHead
+			hops++
+			continue
+		}
+
+		// Many servers answer HEAD with 4xx/5xx while GET works
+		if method == http.MethodHead && resp.StatusCode >= 400 && resp.StatusCode != http.StatusTooManyRequests {
+			method = http.MethodGet
+			continue
+		}
+
+		health.StatusCode = resp.StatusCode
+		health.FinalURL = current
+		switch {
+		case resp.StatusCode < 300 && isSoft404Redirect(parsed, resp.Request.URL):
+			health.Status, health.ErrorKind = linkStatusBroken, "soft_404"
+			health.Error = "redirected to the home page"
+		case resp.StatusCode < 300:
+			health.Status = linkStatusOK
+			if permanent {
+				health.Status = linkStatusRedirected
+			}
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			health.Status = linkStatusRestricted
+		case resp.StatusCode == http.StatusTooManyRequests:
+			health.Status = linkStatusRateLimited
+		default:
+			health.Status = linkStatusBroken
+		}
+		return finish()
+	}
+
+	health.Status, health.ErrorKind = linkStatusError, "redirect_loop"
+	health.Error = fmt.Sprintf("more than %d redirects", linkCheckMaxRedirects)
+	return finish()
+}
+
+// isSoft404Redirect reports whether a link to a page ended up on the home
+// page, which is how many sites answer requests for removed pages
+func isSoft404Redirect(original, final *url.URL) bool {
+	isRoot := func(u *url.URL) bool {
+		return strings.Trim(u.Path, "/") == "" && u.RawQuery == ""
+	}
+	return !isRoot(original) && isRoot(final)
+}
+
+// classifyLinkError maps a request error to a link status and error kind
+func classifyLinkError(err error) (string, string) {
+	var dnsErr *net.DNSError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var recordHeaderErr tls.RecordHeaderError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return linkStatusTLSError, "tls_unknown_authority"
+	case errors.As(err, &hostnameErr):
+		return linkStatusTLSError, "tls_hostname"
+	case errors.As(err, &invalidCert):
+		if invalidCert.Reason == x509.Expired {
+			return linkStatusTLSError, "tls_expired"
+		}
+		return linkStatusTLSError, "tls_invalid"
+	case errors.As(err, &recordHeaderErr), strings.Contains(err.Error(), "tls:"):
+		return linkStatusTLSError, "tls_other"
+	case errors.As(err, &dnsErr):
+		return linkStatusBroken, "dns"
+	case isConnectionRefused(err):
+		return linkStatusBroken, "connection_refused"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return linkStatusTimeout, "timeout"
+	case errors.Is(err, context.Canceled):
+		return linkStatusError, "cancelled"
+	}
+	return linkStatusError, "other"
+}
+
+var (
+	// 当前链接检查任务
+	linkCheckMutex  sync.Mutex
+	linkCheckCancel context.CancelFunc
+)
+
+// CheckLinks checks the bookmarks matching the filter and stores the
+// results. Progress is sent as linkcheck:progress events.
+func (a *App) CheckLinks(filter AdvancedSearchOptions) (*LinkCheckSummary, error) {
+	urls, err := a.AdvancedSearchURLs(filter)
+	if err != nil {
+		return nil, err
+	}
+	return a.runLinkCheck(urls)
+}
+
+// CancelLinkCheck stops a running link check
+func (a *App) CancelLinkCheck() {
+	linkCheckMutex.Lock()
+	defer linkCheckMutex.Unlock()
+	if linkCheckCancel != nil {
+		linkCheckCancel()
+	}
+}
+
+// runLinkCheck checks the given bookmarks; only one run can be active
+func (a *App) runLinkCheck(urls []URLItem) (*LinkCheckSummary, error) {
+	settings, err := a.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	client, err := newHTTPClient(settings.Network, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	linkCheckMutex.Lock()
+	if linkCheckCancel != nil {
+		linkCheckMutex.Unlock()
+		return nil, fmt.Errorf("a link check is already running")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	linkCheckCancel = cancel
+	linkCheckMutex.Unlock()
+
+	defer func() {
+		linkCheckMutex.Lock()
+		linkCheckCancel = nil
+		linkCheckMutex.Unlock()
+		cancel()
+	}()
+
+	summary, err := a.checkURLs(ctx, newLinkChecker(settings.LinkCheck, client), urls, settings.LinkCheck)
+	a.emitEvent(eventLinkCheckCompleted, summary)
+	return summary, err
+}
+
+// checkURLs probes the bookmarks with a worker pool and saves the results
+// in batches. Checking continues when saving a batch fails; the first
+// save error is returned with the summary.
+func (a *App) checkURLs(ctx context.Context, checker *linkChecker, urls []URLItem, settings LinkCheckSettings) (*LinkCheckSummary, error) {
+	summary := &LinkCheckSummary{Total: len(urls), Counts: make(map[string]int)}
+
+	type result struct {
+		id     string
+		url    string
+		health LinkHealth
+	}
+
+	jobs := make(chan URLItem)
+	results := make(chan result)
+
+	workers := settings.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				results <- result{id: item.ID, url: item.URL, health: checker.check(ctx, item.URL)}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, item := range urls {
+			select {
+			case jobs <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	progress := a.newProgressEmitter(eventLinkCheckProgress)
+	pending := make(map[string]LinkHealth)
+	var saveErr error
+	save := func() {
+		if err := a.saveLinkHealth(pending, settings.HistoryLength); err != nil && saveErr == nil {
+			saveErr = fmt.Errorf("failed to save link check results: %w", err)
+		}
+		pending = make(map[string]LinkHealth)
+	}
+	for res := range results {
+		if ctx.Err() != nil && res.health.ErrorKind == "cancelled" {
+			continue
+		}
+
+		summary.Checked++
+		summary.Counts[res.health.Status]++
+		if isBrokenLinkStatus(res.health.Status) {
+			summary.Broken++
+		}
+
+		pending[res.id] = res.health
+		if len(pending) >= linkCheckSaveBatch {
+			save()
+		}
+
+		progress.Emit(LinkCheckProgress{
+			Checked: summary.Checked,
+			Total:   summary.Total,
+			URLID:   res.id,
+			URL:     res.url,
+			Status:  res.health.Status,
+		})
+	}
+
+	progress.Flush()
+	if len(pending) > 0 {
+		save()
+	}
+
+	summary.Cancelled = ctx.Err() != nil
+	return summary, saveErr
+}
+
+// saveLinkHealth stores check results on their bookmarks, moving the
+// previous result into the history
+func (a *App) saveLinkHealth(results map[string]LinkHealth, historyLength int) error {
+	historyLength = max(historyLength, 0)
+	return a.modifyURLs(func(urls []URLItem) ([]URLItem, error) {
+		for i := range urls {
+			health, ok := results[urls[i].ID]
+			if !ok {
+				continue
+			}
+
+			var history []LinkCheckRecord
+			if previous := urls[i].Health; previous != nil {
+				history = append([]LinkCheckRecord{{
+					Status:     previous.Status,
+					StatusCode: previous.StatusCode,
+					LatencyMs:  previous.LatencyMs,
+					CheckedAt:  previous.CheckedAt,
+				}}, previous.History...)
+			}
+			if len(history) > historyLength {
+				history = history[:historyLength]
+			}
+
+			health.History = history
+			urls[i].Health = &health
+		}
+		return urls, nil
+	})
+}
+
+// startLinkCheckScheduler periodically rechecks links older than the
+// configured interval while background checks are enabled
+func (a *App) startLinkCheckScheduler(ctx context.Context) {
+	go func() {
+		// Give the app time to finish starting before the first run
+		timer := time.NewTimer(time.Minute)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			a.runScheduledLinkCheck()
+			timer.Reset(time.Hour)
+		}
+	}()
+}
+
+// runScheduledLinkCheck checks the bookmarks whose last check is too old
+func (a *App) runScheduledLinkCheck() {
+	settings, err := a.GetSettings()
+	if err != nil || !settings.LinkCheck.Enabled {
+		return
+	}
+
+	urls, err := a.GetURLs()
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-time.Duration(settings.LinkCheck.IntervalHours) * time.Hour)
+	var due []URLItem
+	for _, item := range urls {
+		if item.Health == nil || item.Health.CheckedAt.Before(cutoff) {
+			due = append(due, item)
+		}
+	}
+
+	if len(due) > 0 {
+		a.runLinkCheck(due)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newLinkCheckServer serves pages with the responses the checker classifies
+func newLinkCheckServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/removed-article", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	mux.HandleFunc("/busy", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLinkCheckerCheck(t *testing.T) {
+	server := newLinkCheckServer(t)
+	checker := newLinkChecker(LinkCheckSettings{TimeoutSeconds: 1, PerHostLimit: 4}, server.Client())
+	checker.client.Timeout = 200 * time.Millisecond
+
+	tests := []struct {
+		path          string
+		wantStatus    string
+		wantCode      int
+		wantKind      string
+		wantRedirects int
+		wantFinal     string
+	}{
+		{path: "/ok", wantStatus: linkStatusOK, wantCode: 200, wantFinal: "/ok"},
+		{path: "/", wantStatus: linkStatusOK, wantCode: 200, wantFinal: "/"},
+		{path: "/moved", wantStatus: linkStatusRedirected, wantCode: 200, wantRedirects: 1, wantFinal: "/ok"},
+		{path: "/temporary", wantStatus: linkStatusOK, wantCode: 200, wantRedirects: 1, wantFinal: "/ok"},
+		{path: "/loop", wantStatus: linkStatusError, wantKind: "redirect_loop", wantRedirects: linkCheckMaxRedirects + 1},
+		{path: "/removed-article", wantStatus: linkStatusBroken, wantCode: 200, wantKind: "soft_404", wantRedirects: 1, wantFinal: "/"},
+		{path: "/missing", wantStatus: linkStatusBroken, wantCode: 404, wantFinal: "/missing"},
+		{path: "/gone", wantStatus: linkStatusBroken, wantCode: 410, wantFinal: "/gone"},
+		{path: "/no-head", wantStatus: linkStatusOK, wantCode: 200, wantFinal: "/no-head"},
+		{path: "/login", wantStatus: linkStatusRestricted, wantCode: 403, wantFinal: "/login"},
+		{path: "/busy", wantStatus: linkStatusRateLimited, wantCode: 429, wantFinal: "/busy"},
+		{path: "/slow", wantStatus: linkStatusTimeout, wantKind: "timeout"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			health := checker.check(context.Background(), server.URL+test.path)
+			if health.Status != test.wantStatus || health.ErrorKind != test.wantKind {
+				t.Fatalf("status = %q (%q: %s), want %q (%q)",
+					health.Status, health.ErrorKind, health.Error, test.wantStatus, test.wantKind)
+			}
+			if health.StatusCode != test.wantCode {
+				t.Errorf("status code = %d, want %d", health.StatusCode, test.wantCode)
+			}
+			if len(health.Redirects) != test.wantRedirects {
+				t.Errorf("redirects = %+v, want %d", health.Redirects, test.wantRedirects)
+			}
+			if test.wantFinal != "" && health.FinalURL != server.URL+test.wantFinal {
+				t.Errorf("final URL = %q, want %q", health.FinalURL, server.URL+test.wantFinal)
+			}
+		})
+	}
+}
+
+func TestLinkCheckerRejectsUnsupportedURL(t *testing.T) {
+	checker := newLinkChecker(LinkCheckSettings{TimeoutSeconds: 1}, http.DefaultClient)
+	health := checker.check(context.Background(), "ftp://example.com/file")
+	if health.Status != linkStatusError || health.ErrorKind != "unsupported_url" {
+		t.Errorf("status = %q (%q), want error (unsupported_url)", health.Status, health.ErrorKind)
+	}
+}
+
+func TestCheckURLsSavesHistory(t *testing.T) {
+	setTestHome(t)
+	server := newLinkCheckServer(t)
+	a := NewApp()
+
+	item := URLItem{ID: "1", Title: "Gone", URL: server.URL + "/gone"}
+	if err := a.SaveURLs([]URLItem{item}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A negative history length from an old settings file keeps no history
+	settings := LinkCheckSettings{Concurrency: 2, PerHostLimit: 2, TimeoutSeconds: 1, HistoryLength: -1}
+	checker := newLinkChecker(settings, server.Client())
+	for run := 0; run < 2; run++ {
+		summary, err := a.checkURLs(context.Background(), checker, []URLItem{item}, settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary.Checked != 1 || summary.Broken != 1 {
+			t.Fatalf("summary = %+v, want 1 checked and broken", summary)
+		}
+	}
+
+	urls, err := a.GetURLs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	health := urls[0].Health
+	if health == nil || health.Status != linkStatusBroken || len(health.History) != 0 {
+		t.Errorf("health = %+v, want broken without history", health)
+	}
+}
+
+func TestLinkCheckSettingsNormalized(t *testing.T) {
+	defaults := defaultSettings().LinkCheck
+	tests := []struct {
+		name string
+		in   LinkCheckSettings
+		want LinkCheckSettings
+	}{
+		{name: "defaults kept", in: defaults, want: defaults},
+		{
+			name: "zero and negative values",
+			in:   LinkCheckSettings{PerHostDelayMs: -5, HistoryLength: -1},
+			want: LinkCheckSettings{
+				IntervalHours: defaults.IntervalHours, Concurrency: defaults.Concurrency,
+				PerHostLimit: defaults.PerHostLimit, TimeoutSeconds: defaults.TimeoutSeconds,
+			},
+		},
+		{
+			name: "upper limits",
+			in:   LinkCheckSettings{IntervalHours: 1, Concurrency: 1000, PerHostLimit: 3, TimeoutSeconds: 3600, HistoryLength: 5000},
+			want: LinkCheckSettings{IntervalHours: 1, Concurrency: linkCheckMaxConcurrency, PerHostLimit: 3, TimeoutSeconds: linkCheckMaxTimeout, HistoryLength: linkCheckMaxHistory},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.in.normalized(); got != test.want {
+				t.Errorf("normalized() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSaveSettingsNormalizesLinkCheck(t *testing.T) {
+	setTestHome(t)
+	a := NewApp()
+
+	settings := defaultSettings()
+	settings.LinkCheck.TimeoutSeconds = 0
+	settings.LinkCheck.HistoryLength = -3
+	if err := a.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := a.GetSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.LinkCheck.TimeoutSeconds <= 0 || saved.LinkCheck.HistoryLength != 0 {
+		t.Errorf("saved link check settings = %+v", saved.LinkCheck)
+	}
+}
+
+func TestLinkCheckerConnectionRefused(t *testing.T) {
+	// A port that was just released refuses connections on every platform
+	server := httptest.NewServer(http.NotFoundHandler())
+	address := server.URL
+	server.Close()
+
+	checker := newLinkChecker(LinkCheckSettings{TimeoutSeconds: 2}, http.DefaultClient)
+	health := checker.check(context.Background(), address+"/")
+	if health.Status != linkStatusBroken || health.ErrorKind != "connection_refused" {
+		t.Errorf("status = %q (%q: %s), want broken (connection_refused)", health.Status, health.ErrorKind, health.Error)
+	}
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"errors"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// isConnectionRefused reports whether a request failed because nothing
+// listens on the port. Windows reports WSAECONNREFUSED rather than
+// ECONNREFUSED.
+func isConnectionRefused(err error) bool {
+	return errors.Is(err, windows.WSAECONNREFUSED) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -5,6 +5,7 @@ import (
 	"embed"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -41,22 +42,70 @@ func (a *App) OnStartup(ctx context.Context) {
 	}
 	fmt.Printf("编译时注入版本: %s\n", Version)
 	fmt.Printf("GitHub信息: %s/%s\n", GitHubOwner, GitHubRepo)
+
+	// 后台定期检查失效链接
+	a.startLinkCheckScheduler(ctx)
+
+	// 监控页面内容变化
+	a.startWatchScheduler(ctx)
+
+	// 调试时可通过环境变量启用模拟更新服务器
+	a.startMockUpdateServerFromEnv()
+
+	// 后台定期检查更新
+	a.startUpdateCheckScheduler(ctx)
+
+	// 刚安装的更新正常运行一段时间后确认可用
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-time.After(updateHealthyAfter):
+			a.confirmUpdateHealth()
+		}
+	}()
+}
+
+// OnShutdown is called when the app quits normally
+func (a *App) OnShutdown(ctx context.Context) {
+	// 正常退出说明刚安装的更新可以启动
+	a.confirmUpdateHealth()
+
+	// 安装已下载的更新，下次启动即为新版本
+	if err := a.ApplyPendingUpdateOnExit(); err != nil {
+		fmt.Printf("警告: 退出时安装更新失败: %v\n", err)
+	}
+
+	// 模拟更新服务器随应用一起停止
+	a.StopMockUpdateServer()
 }
 
 func main() {
+	// Command line modes run without opening a window
+	if len(os.Args) > 1 && os.Args[1] == "export-site" {
+		os.Exit(runExportSiteCommand(os.Args[2:]))
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 
+	// Install an update staged for the next launch, then restore the
+	// previous version if a fresh update keeps failing to start
+	if app.applyPendingUpdateOnLaunch() || app.checkUpdateHealth() {
+		return
+	}
+
 	// Create application with options
 	err := wails.Run(&options.App{
 		Title:  "URL Navigator",
 		Width:  1200,
 		Height: 800,
 		AssetServer: &assetserver.Options{
-			Assets: assets,
+			Assets:  assets,
+			Handler: newAssetHandler(app),
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.OnStartup,
+		OnShutdown:       app.OnShutdown,
 		Fullscreen:       false,
 		WindowStartState: options.Normal,
 		MinWidth:         800,
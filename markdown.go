@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Markdown export layouts
+const (
+	markdownStyleHeadings = "headings" // "## Category" followed by a bookmark list
+	markdownStyleList     = "list"     // Categories as top-level items with nested bookmarks
+)
+
+// MarkdownExportOptions controls the layout of an exported Markdown document
+type MarkdownExportOptions struct {
+	Title        string `json:"title"`        // Document heading, omitted when empty
+	Style        string `json:"style"`        // "headings" (default) or "list"
+	IncludeTags  bool   `json:"includeTags"`  // Append tags as inline code
+	IncludeEmpty bool   `json:"includeEmpty"` // Also list categories without bookmarks
+}
+
+var (
+	markdownHeadingRegex = regexp.MustCompile(`^(#{1,6})\s+(.+?)(?:\s+#+)?\s*$`)
+	markdownListRegex    = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+(.*)$`)
+	markdownEscapeRegex  = regexp.MustCompile(`\\([\\\[\]()*_` + "`" + `#+\-.!|<>])`)
+	// The link destination is either <...> with backslash escapes, or bare
+	// with balanced parentheses
+	markdownLinkRegex = regexp.MustCompile(`\[((?:[^\[\]\\]|\\.)*)\]\(\s*(?:<((?:[^<>\\\n]|\\.)*)>|([^\s<>()]+(?:\([^\s()]*\)[^\s<>()]*)*))(?:\s+"[^"]*")?\s*\)`)
+	// A leading "1." or "1)" that would start an ordered list
+	markdownOrderedPrefixRegex = regexp.MustCompile(`^\d+([.)])`)
+)
+
+// ExportMarkdown exports all bookmarks as a Markdown document grouped by category
+func (a *App) ExportMarkdown(options MarkdownExportOptions) (string, error) {
+	urls, err := a.GetURLs()
+	if err != nil {
+		return "", err
+	}
+
+	categories, err := a.GetCategories()
+	if err != nil {
+		return "", err
+	}
+
+	return renderMarkdown(urls, categories, options), nil
+}
+
+// renderMarkdown renders bookmarks grouped by category
+func renderMarkdown(urls []URLItem, categories []Category, options MarkdownExportOptions) string {
+	var b strings.Builder
+	if options.Title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", escapeMarkdownHeading(options.Title))
+	}
+
+	for _, group := range groupURLsByCategory(urls, categories, options.IncludeEmpty) {
+		heading := group.Name
+		if heading == "" {
+			heading = "未分类"
+		}
+
+		indent := ""
+		if options.Style == markdownStyleList {
+			fmt.Fprintf(&b, "- %s\n", escapeMarkdownHeading(heading))
+			indent = "  "
+		} else {
+			fmt.Fprintf(&b, "## %s\n\n", escapeMarkdownHeading(heading))
+		}
+
+		for _, item := range group.URLs {
+			b.WriteString(indent)
+			b.WriteString(markdownBookmarkLine(item, options.IncludeTags))
+			b.WriteString("\n")
+		}
+
+		if options.Style != markdownStyleList {
+			b.WriteString("\n")
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// markdownBookmarkLine renders one bookmark as a list item
+func markdownBookmarkLine(item URLItem, includeTags bool) string {
+	title := item.Title
+	if title == "" {
+		title = item.URL
+	}
+
+	line := fmt.Sprintf("- [%s](%s)", escapeMarkdownText(title), escapeMarkdownURL(item.URL))
+	if item.Description != "" {
+		line += " - " + escapeMarkdownText(strings.Join(strings.Fields(item.Description), " "))
+	}
+	if includeTags {
+		for _, tag := range item.Tags {
+			line += " `" + strings.ReplaceAll(tag, "`", "'") + "`"
+		}
+	}
+	return line
+}
+
+// escapeMarkdownText escapes characters that would break link text
+func escapeMarkdownText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`)
+	return replacer.Replace(text)
+}
+
+// markdownHeadingReplacer escapes inline markup, HTML and "#"
+var markdownHeadingReplacer = strings.NewReplacer(
+	`\`, `\\`, `[`, `\[`, `]`, `\]`, `*`, `\*`, `_`, `\_`, "`", "\\`",
+	`#`, `\#`, `<`, `\<`, `>`, `\>`, `|`, `\|`,
+)
+
+// escapeMarkdownHeading escapes a category name used as a heading or list
+// item so that it renders literally and reads back unchanged. A leading
+// list marker or number is escaped as well.
+func escapeMarkdownHeading(text string) string {
+	text = markdownHeadingReplacer.Replace(strings.Join(strings.Fields(text), " "))
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		return `\` + text
+	}
+	if match := markdownOrderedPrefixRegex.FindStringSubmatchIndex(text); match != nil {
+		return text[:match[2]] + `\` + text[match[2]:]
+	}
+	return text
+}
+
+// markdownURLReplacer escapes the characters that end a <...> destination
+var markdownURLReplacer = strings.NewReplacer(`\`, `\\`, `<`, `\<`, `>`, `\>`)
+
+// escapeMarkdownURL writes a link destination that reads back as the same
+// URL. URLs with spaces, parentheses, angle brackets or backslashes go
+// between < and >.
+func escapeMarkdownURL(url string) string {
+	if !strings.ContainsAny(url, ` ()<>\`) {
+		return url
+	}
+	return "<" + markdownURLReplacer.Replace(url) + ">"
+}
+
+// ImportMarkdown imports [title](url) links from a Markdown document. The
+// nearest heading above a link, or a parent list item without a link,
+// becomes its category.
+func (a *App) ImportMarkdown(markdown string) (int, error) {
+	return a.saveImportedBookmarks(parseMarkdownBookmarks(markdown))
+}
+
+// parseMarkdownBookmarks extracts links with their category and description
+func parseMarkdownBookmarks(markdown string) []importedBookmark {
+	type listParent struct {
+		indent int
+		title  string
+	}
+
+	var bookmarks []importedBookmark
+	var parents []listParent
+	headingCategory := ""
+	inCodeBlock := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock || trimmed == "" {
+			continue
+		}
+
+		if match := markdownHeadingRegex.FindStringSubmatch(trimmed); match != nil {
+			headingCategory = unescapeMarkdown(match[2])
+			parents = nil
+			continue
+		}
+
+		content := trimmed
+		if match := markdownListRegex.FindStringSubmatch(line); match != nil {
+			indent := len(strings.ReplaceAll(match[1], "\t", "    "))
+			for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+				parents = parents[:len(parents)-1]
+			}
+			content = match[2]
+
+			// A list item without links is a category for its nested items
+			if !markdownLinkRegex.MatchString(content) {
+				parents = append(parents, listParent{indent: indent, title: unescapeMarkdown(content)})
+				continue
+			}
+		} else {
+			// A paragraph ends any enclosing list
+			parents = nil
+		}
+
+		category := headingCategory
+		if len(parents) > 0 {
+			category = parents[len(parents)-1].title
+		}
+
+		links := markdownLinkRegex.FindAllStringSubmatchIndex(content, -1)
+		for i, link := range links {
+			title := unescapeMarkdown(content[link[2]:link[3]])
+			var url string
+			if link[4] >= 0 {
+				url = unescapeMarkdown(content[link[4]:link[5]])
+			} else {
+				url = content[link[6]:link[7]]
+			}
+			if !strings.Contains(url, "://") {
+				continue
+			}
+
+			// Text after a single link on the line is its description
+			description := ""
+			var tags []string
+			if len(links) == 1 && i == 0 {
+				rest := content[link[1]:]
+				description = strings.TrimLeft(strings.TrimSpace(stripInlineCode(rest)), "-–—:： ")
+				description = unescapeMarkdown(strings.TrimSpace(description))
+				tags = inlineCodeTags(rest)
+			}
+
+			bookmarks = append(bookmarks, importedBookmark{
+				Title:       title,
+				URL:         url,
+				Description: description,
+				Category:    category,
+				Tags:        tags,
+			})
+		}
+	}
+
+	return bookmarks
+}
+
+var markdownInlineCodeRegex = regexp.MustCompile("`([^`]+)`")
+
+// inlineCodeTags returns inline code spans, which the exporter uses for tags
+func inlineCodeTags(text string) []string {
+	var tags []string
+	for _, match := range markdownInlineCodeRegex.FindAllStringSubmatch(text, -1) {
+		tags = append(tags, strings.TrimSpace(match[1]))
+	}
+	return tags
+}
+
+// stripInlineCode removes inline code spans from text
+func stripInlineCode(text string) string {
+	return markdownInlineCodeRegex.ReplaceAllString(text, "")
+}
+
+// unescapeMarkdown removes backslash escapes
+func unescapeMarkdown(text string) string {
+	return markdownEscapeRegex.ReplaceAllString(text, "$1")
+}
@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestMarkdownCategoryRoundTrip(t *testing.T) {
+	categories := []string{
+		"C# and .NET",
+		"**bold** _em_ `code`",
+		"# not a heading",
+		"- not a list",
+		"1. not ordered",
+		"a [link](https://example.com/)",
+		`back\slash`,
+		"<b>html</b> | pipe",
+		"ends with ##",
+	}
+
+	for _, style := range []string{markdownStyleHeadings, markdownStyleList} {
+		for _, category := range categories {
+			t.Run(style+"/"+category, func(t *testing.T) {
+				urls := []URLItem{{Title: "Example", URL: "https://example.org/", Category: category}}
+				markdown := renderMarkdown(urls, []Category{{Name: category}}, MarkdownExportOptions{Style: style})
+
+				bookmarks := parseMarkdownBookmarks(markdown)
+				if len(bookmarks) != 1 {
+					t.Fatalf("got %d bookmarks from %q, want 1", len(bookmarks), markdown)
+				}
+				if bookmarks[0].Category != category {
+					t.Errorf("category = %q, want %q\n%s", bookmarks[0].Category, category, markdown)
+				}
+			})
+		}
+	}
+}
+
+func TestMarkdownURLRoundTrip(t *testing.T) {
+	urls := []string{
+		"https://example.org/",
+		"https://en.wikipedia.org/wiki/Go_(programming_language)",
+		"https://example.org/a)b(c",
+		"https://example.org/search?q=a b",
+		"https://example.org/<tag>",
+		`https://example.org/back\slash`,
+		"https://example.org/%28already%29",
+	}
+
+	for _, url := range urls {
+		t.Run(url, func(t *testing.T) {
+			items := []URLItem{{Title: "Example", URL: url, Category: "Links"}}
+			markdown := renderMarkdown(items, []Category{{Name: "Links"}}, MarkdownExportOptions{})
+
+			bookmarks := parseMarkdownBookmarks(markdown)
+			if len(bookmarks) != 1 {
+				t.Fatalf("got %d bookmarks from %q, want 1", len(bookmarks), markdown)
+			}
+			if bookmarks[0].URL != url {
+				t.Errorf("URL = %q, want %q\n%s", bookmarks[0].URL, url, markdown)
+			}
+		})
+	}
+}
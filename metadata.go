@@ -0,0 +1,500 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// metadataMaxBody limits how much of a page is read for metadata
+const metadataMaxBody = 512 * 1024
+
+// metadataTimeout is the time allowed for a single page fetch
+const metadataTimeout = 10 * time.Second
+
+// eventURLMetadata is sent with the URLItem after fillMetadata changed it
+const eventURLMetadata = "url:metadata"
+
+// metadataConcurrency is the number of pages fetched in parallel by RefreshMetadata
+const metadataConcurrency = 4
+
+// PageMetadata is the information extracted from a web page
+type PageMetadata struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	SiteName    string `json:"siteName"`
+	Image       string `json:"image"`   // OpenGraph/Twitter card image
+	Favicon     string `json:"favicon"` // Absolute icon URL
+	FinalURL    string `json:"finalUrl"`
+	Content     string `json:"-"` // Readable text for the content index
+}
+
+// MetadataRefreshResult counts the bookmarks updated by RefreshMetadata
+type MetadataRefreshResult struct {
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Errors  map[string]string `json:"errors,omitempty"` // Bookmark ID to error
+}
+
+// metaCharsetRegex finds a charset declared in the first bytes of a page
+var metaCharsetRegex = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-zA-Z0-9_\-]+)`)
+
+// FetchMetadata fetches a page and returns its metadata without saving it
+func (a *App) FetchMetadata(rawURL string) (*PageMetadata, error) {
+	client, err := a.httpClient(0)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	defer cancel()
+	return fetchPageMetadata(ctx, client, rawURL)
+}
+
+// RefreshMetadata fetches the pages of the given bookmarks and fills in
+// their empty title and description. The favicon is always refreshed.
+func (a *App) RefreshMetadata(ids []string) (*MetadataRefreshResult, error) {
+	urls, err := a.GetURLs()
+	if err != nil {
+		return nil, err
+	}
+	client, err := a.httpClient(0)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var items []URLItem
+	for _, item := range urls {
+		if wanted[item.ID] {
+			items = append(items, item)
+		}
+	}
+
+	result := &MetadataRefreshResult{Errors: make(map[string]string)}
+	fetched := make(map[string]*PageMetadata)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, metadataConcurrency)
+
+	for _, item := range items {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(item URLItem) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+			defer cancel()
+			meta, err := fetchPageMetadata(ctx, client, item.URL)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed++
+				result.Errors[item.ID] = err.Error()
+				return
+			}
+			fetched[item.ID] = meta
+		}(item)
+	}
+	wg.Wait()
+
+	for id, meta := range fetched {
+		a.indexPageContent(id, meta.Content)
+	}
+
+	err = a.modifyURLs(func(urls []URLItem) ([]URLItem, error) {
+		for i := range urls {
+			meta, ok := fetched[urls[i].ID]
+			if !ok {
+				continue
+			}
+			if applyMetadata(&urls[i], meta, true) {
+				urls[i].UpdatedAt = time.Now()
+				result.Updated++
+			}
+		}
+		return urls, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// fillMetadata fetches the page of a newly added bookmark, indexes its
+// content and fills in the favicon and empty fields. It runs in the
+// background; failures are ignored so that unreachable pages can still be
+// bookmarked.
+func (a *App) fillMetadata(id, rawURL string) {
+	client, err := a.httpClient(0)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	meta, err := fetchPageMetadata(ctx, client, rawURL)
+	cancel()
+	if err != nil {
+		return
+	}
+
+	found := false
+	var updated *URLItem
+	err = a.modifyURLs(func(urls []URLItem) ([]URLItem, error) {
+		for i := range urls {
+			// The page no longer matches a bookmark deleted or edited meanwhile
+			if urls[i].ID != id || urls[i].URL != rawURL {
+				continue
+			}
+			found = true
+			if applyMetadata(&urls[i], meta, false) {
+				urls[i].UpdatedAt = time.Now()
+				item := urls[i]
+				updated = &item
+			}
+		}
+		return urls, nil
+	})
+	if err != nil || !found {
+		return
+	}
+
+	a.indexPageContent(id, meta.Content)
+	if updated != nil {
+		a.emitEvent(eventURLMetadata, updated)
+	}
+}
+
+// applyMetadata fills the empty fields of a bookmark from page metadata
+// and reports whether anything changed. The favicon is replaced only when
+// overwriteFavicon is set.
+func applyMetadata(item *URLItem, meta *PageMetadata, overwriteFavicon bool) bool {
+	changed := false
+	if strings.TrimSpace(item.Title) == "" && meta.Title != "" {
+		item.Title = meta.Title
+		changed = true
+	}
+	if strings.TrimSpace(item.Description) == "" && meta.Description != "" {
+		item.Description = meta.Description
+		changed = true
+	}
+	if meta.Favicon != "" && (item.Favicon == "" || overwriteFavicon) && item.Favicon != meta.Favicon {
+		item.Favicon = meta.Favicon
+		changed = true
+	}
+	return changed
+}
+
+// fetchPageMetadata downloads the head of a page and parses its metadata
+func fetchPageMetadata(ctx context.Context, client *http.Client, rawURL string) (*PageMetadata, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("unsupported URL: %s", rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", AppName, strings.TrimPrefix(Version, "v")))
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "" &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("not an HTML page: %s", mediaType)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, metadataMaxBody))
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := decodeHTML(body, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := parsePageMetadata(text, resp.Request.URL)
+	meta.FinalURL = resp.Request.URL.String()
+	if doc, err := html.Parse(strings.NewReader(text)); err == nil {
+		meta.Content = readableText(doc)
+	}
+	return meta, nil
+}
+
+// decodeHTML converts a page to UTF-8 using the charset from the
+// Content-Type header or a <meta> tag. Undeclared pages that are not valid
+// UTF-8 are assumed to be GBK, the most common legacy Chinese encoding.
+func decodeHTML(body []byte, contentType string) (string, error) {
+	var labels []string
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		labels = append(labels, params["charset"])
+	}
+	head := body
+	if len(head) > 2048 {
+		head = head[:2048]
+	}
+	if match := metaCharsetRegex.FindSubmatch(head); match != nil {
+		labels = append(labels, string(match[1]))
+	}
+
+	// Servers often claim UTF-8 for legacy pages, so a UTF-8 label is only
+	// trusted when the body is actually valid UTF-8
+	for _, label := range labels {
+		if enc, name := charset.Lookup(label); enc != nil && name != "utf-8" {
+			decoded, err := enc.NewDecoder().Bytes(body)
+			return string(decoded), err
+		}
+	}
+
+	if utf8.Valid(trimPartialRune(body)) {
+		return string(bytes.TrimPrefix(body, []byte("\ufeff"))), nil
+	}
+
+	decoded, err := simplifiedchinese.GB18030.NewDecoder().Bytes(body)
+	return string(decoded), err
+}
+
+// trimPartialRune drops a UTF-8 sequence cut off by the body size limit
+func trimPartialRune(data []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				return data[:len(data)-i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+// parsePageMetadata extracts the title, description and icons from the
+// <head> of a page. OpenGraph and Twitter cards take precedence over the
+// plain <title> and meta description.
+func parsePageMetadata(page string, base *url.URL) *PageMetadata {
+	var title, ogTitle, twitterTitle string
+	var description, ogDescription, twitterDescription string
+	var siteName, ogImage, twitterImage string
+	var icons []pageIcon
+
+	tokenizer := html.NewTokenizer(strings.NewReader(page))
+	inTitle := false
+
+parse:
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			break parse
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				inTitle = title == ""
+			case "meta":
+				attrs := htmlAttrs(token)
+				key := strings.ToLower(attrs["property"])
+				if key == "" {
+					key = strings.ToLower(attrs["name"])
+				}
+				content := strings.TrimSpace(attrs["content"])
+				switch key {
+				case "og:title":
+					ogTitle = content
+				case "twitter:title":
+					twitterTitle = content
+				case "description":
+					description = content
+				case "og:description":
+					ogDescription = content
+				case "twitter:description":
+					twitterDescription = content
+				case "og:site_name", "application-name":
+					if siteName == "" {
+						siteName = content
+					}
+				case "og:image", "og:image:url":
+					if ogImage == "" {
+						ogImage = content
+					}
+				case "twitter:image", "twitter:image:src":
+					if twitterImage == "" {
+						twitterImage = content
+					}
+				}
+			case "link":
+				attrs := htmlAttrs(token)
+				if icon, ok := parseIconLink(attrs); ok {
+					icons = append(icons, icon)
+				}
+			case "base":
+				if href := htmlAttrs(token)["href"]; href != "" {
+					if resolved, err := base.Parse(href); err == nil {
+						base = resolved
+					}
+				}
+			case "body":
+				break parse
+			}
+		case html.TextToken:
+			if inTitle {
+				title += string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				break parse
+			}
+		}
+	}
+
+	meta := &PageMetadata{
+		Title:       cleanMetadataText(firstNonEmpty(ogTitle, twitterTitle, title)),
+		Description: cleanMetadataText(firstNonEmpty(ogDescription, twitterDescription, description)),
+		SiteName:    cleanMetadataText(siteName),
+		Image:       resolveMetadataURL(base, firstNonEmpty(ogImage, twitterImage)),
+	}
+
+	if icon := bestIcon(icons); icon != "" {
+		meta.Favicon = resolveMetadataURL(base, icon)
+	} else {
+		// Browsers fall back to /favicon.ico at the site root
+		meta.Favicon = resolveMetadataURL(base, "/favicon.ico")
+	}
+
+	return meta
+}
+
+// pageIcon is an icon declared with <link rel="icon">
+type pageIcon struct {
+	href  string
+	size  int // Largest declared size, 0 if unknown or "any"
+	score int // Preference of the rel type and format
+}
+
+// parseIconLink reads an icon from the attributes of a <link> tag
+func parseIconLink(attrs map[string]string) (pageIcon, bool) {
+	href := strings.TrimSpace(attrs["href"])
+	if href == "" {
+		return pageIcon{}, false
+	}
+
+	icon := pageIcon{href: href}
+	rels := strings.Fields(strings.ToLower(attrs["rel"]))
+	switch {
+	case indexOf(rels, "icon") >= 0:
+		icon.score = 3
+	case indexOf(rels, "apple-touch-icon") >= 0, indexOf(rels, "apple-touch-icon-precomposed") >= 0:
+		icon.score = 2
+	case indexOf(rels, "mask-icon") >= 0:
+		// Monochrome SVG masks render poorly as colour icons
+		icon.score = 1
+	default:
+		return pageIcon{}, false
+	}
+
+	for _, size := range strings.Fields(strings.ToLower(attrs["sizes"])) {
+		var w, h int
+		if _, err := fmt.Sscanf(size, "%dx%d", &w, &h); err == nil && w > icon.size {
+			icon.size = w
+		}
+	}
+
+	return icon, true
+}
+
+// bestIcon picks the preferred icon, favouring rel="icon" and sizes close
+// to what a bookmark list displays
+func bestIcon(icons []pageIcon) string {
+	const preferredSize = 64
+
+	best := -1
+	for i, icon := range icons {
+		if best < 0 || betterIcon(icon, icons[best], preferredSize) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return icons[best].href
+}
+
+// betterIcon reports whether icon a should be preferred over icon b
+func betterIcon(a, b pageIcon, preferredSize int) bool {
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	distance := func(size int) int {
+		if size == 0 {
+			return preferredSize / 2
+		}
+		if size < preferredSize {
+			return (preferredSize - size) * 2 // Upscaled icons look blurry
+		}
+		return size - preferredSize
+	}
+	return distance(a.size) < distance(b.size)
+}
+
+// resolveMetadataURL makes a URL from a page absolute
+func resolveMetadataURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	resolved, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return resolved.String()
+}
+
+// cleanMetadataText collapses whitespace and unescapes leftover entities
+func cleanMetadataText(text string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
+
+// firstNonEmpty returns the first value that is not blank
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
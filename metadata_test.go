@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddURLFillsMetadataInBackground(t *testing.T) {
+	setTestHome(t)
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Fixture page</title>` +
+			`<meta name="description" content="From the page"></head>` +
+			`<body><p>Readable fixture text</p></body></html>`))
+	}))
+	defer server.Close()
+
+	a := NewApp()
+	item, err := a.AddURL("", server.URL+"/page", "Kept", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The bookmark is saved before the page has answered
+	urls, err := a.GetURLs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 1 || urls[0].Title != "" {
+		t.Fatalf("bookmarks before the fetch = %+v", urls)
+	}
+	close(release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		urls, err := a.GetURLs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := os.ReadFile(a.contentPath(item.ID))
+		if urls[0].Title != "" && strings.Contains(string(content), "Readable fixture text") {
+			if urls[0].Title != "Fixture page" || urls[0].Description != "Kept" {
+				t.Errorf("bookmark after the fetch = %+v", urls[0])
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("metadata was not filled in: %+v", urls[0])
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Proxy modes of NetworkSettings.ProxyMode
+const (
+	proxyModeSystem = "system" // Operating system settings or HTTP(S)_PROXY variables
+	proxyModeNone   = "none"   // Always connect directly
+	proxyModeManual = "manual" // ProxyURL with NoProxy exceptions
+	proxyModePAC    = "pac"    // Proxy auto-config script at PACURL
+)
+
+// HostHeader is an extra header sent to matching hosts, e.g. an
+// Authorization header for an internal wiki
+type HostHeader struct {
+	Host  string `json:"host"` // Exact host or wildcard such as *.corp.example.com
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// NetworkSettings configures every outbound HTTP request of the app
+type NetworkSettings struct {
+	ProxyMode              string       `json:"proxyMode"`
+	ProxyURL               string       `json:"proxyUrl"`
+	PACURL                 string       `json:"pacUrl"`
+	NoProxy                string       `json:"noProxy"`      // Comma separated hosts, domains and CIDRs
+	CABundlePath           string       `json:"caBundlePath"` // PEM file trusted in addition to the system roots
+	ConnectTimeoutSeconds  int          `json:"connectTimeoutSeconds"`
+	RequestTimeoutSeconds  int          `json:"requestTimeoutSeconds"`
+	DownloadTimeoutMinutes int          `json:"downloadTimeoutMinutes"`
+	UserAgent              string       `json:"userAgent"` // Replaces the default user agent when set
+	HostHeaders            []HostHeader `json:"hostHeaders"`
+}
+
+// networkTransportMaxAge is how long the shared transport is kept before
+// it is rebuilt, so that changes to the system proxy settings are seen
+const networkTransportMaxAge = 10 * time.Minute
+
+// The transport shared by all clients, rebuilt when the network settings
+// change so that connections and proxy lookups are reused
+var (
+	networkTransportMutex    sync.Mutex
+	networkTransportShared   *headerTransport
+	networkTransportSettings NetworkSettings
+	networkTransportBuilt    time.Time
+)
+
+// NetworkTestResult is the outcome of TestNetworkSettings
+type NetworkTestResult struct {
+	StatusCode int    `json:"statusCode"`
+	Proxy      string `json:"proxy"` // Empty for direct connections
+	LatencyMs  int64  `json:"latencyMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// defaultNetworkSettings returns the network settings used when none are saved
+func defaultNetworkSettings() NetworkSettings {
+	return NetworkSettings{
+		ProxyMode:              proxyModeSystem,
+		ConnectTimeoutSeconds:  10,
+		RequestTimeoutSeconds:  15,
+		DownloadTimeoutMinutes: 30,
+	}
+}
+
+// httpClient returns a client configured from the saved network settings.
+// A zero timeout leaves the deadline to the request context.
+func (a *App) httpClient(timeout time.Duration) (*http.Client, error) {
+	settings, err := a.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	return newHTTPClient(settings.Network, timeout)
+}
+
+// requestTimeout returns the configured timeout for API requests
+func (a *App) requestTimeout() time.Duration {
+	settings, err := a.GetSettings()
+	if err != nil {
+		return networkRequestTimeout(defaultNetworkSettings())
+	}
+	return networkRequestTimeout(settings.Network)
+}
+
+// networkRequestTimeout returns the request timeout of the settings, or
+// the default when the field was cleared
+func networkRequestTimeout(settings NetworkSettings) time.Duration {
+	if settings.RequestTimeoutSeconds <= 0 {
+		return time.Duration(defaultNetworkSettings().RequestTimeoutSeconds) * time.Second
+	}
+	return time.Duration(settings.RequestTimeoutSeconds) * time.Second
+}
+
+// downloadTimeout returns the configured timeout for update downloads
+func (a *App) downloadTimeout() time.Duration {
+	settings, err := a.GetSettings()
+	if err != nil || settings.Network.DownloadTimeoutMinutes <= 0 {
+		return time.Duration(defaultNetworkSettings().DownloadTimeoutMinutes) * time.Minute
+	}
+	return time.Duration(settings.Network.DownloadTimeoutMinutes) * time.Minute
+}
+
+// TestNetworkSettings requests a URL with the given settings before they
+// are saved, reporting the proxy that was chosen
+func (a *App) TestNetworkSettings(settings NetworkSettings, testURL string) (*NetworkTestResult, error) {
+	if testURL == "" {
+		testURL = "https://api.github.com"
+	}
+
+	// The settings are not saved yet, so the shared transport is left alone
+	transport, err := newNetworkTransport(settings)
+	if err != nil {
+		return nil, err
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Timeout: networkRequestTimeout(settings), Transport: transport}
+
+	req, err := http.NewRequest(http.MethodHead, testURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &NetworkTestResult{}
+	if proxy, err := proxyFunc(settings)(req); err == nil && proxy != nil {
+		result.Proxy = proxy.Redacted()
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+	return result, nil
+}
+
+// newHTTPClient returns a client with the proxy, trusted CAs, timeouts
+// and headers of the network settings. Clients share one transport and
+// differ only in their timeout.
+func newHTTPClient(settings NetworkSettings, timeout time.Duration) (*http.Client, error) {
+	transport, err := sharedNetworkTransport(settings)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// sharedNetworkTransport returns the shared transport, building a new one
+// when the settings differ from those it was built with or it is too old.
+// Idle connections of the replaced transport are closed.
+func sharedNetworkTransport(settings NetworkSettings) (*headerTransport, error) {
+	networkTransportMutex.Lock()
+	defer networkTransportMutex.Unlock()
+
+	if networkTransportShared != nil && reflect.DeepEqual(settings, networkTransportSettings) &&
+		time.Since(networkTransportBuilt) < networkTransportMaxAge {
+		return networkTransportShared, nil
+	}
+
+	transport, err := newNetworkTransport(settings)
+	if err != nil {
+		return nil, err
+	}
+	if networkTransportShared != nil {
+		networkTransportShared.CloseIdleConnections()
+	}
+	networkTransportShared = transport
+	networkTransportSettings = settings
+	networkTransportSettings.HostHeaders = append([]HostHeader(nil), settings.HostHeaders...)
+	networkTransportBuilt = time.Now()
+	return transport, nil
+}
+
+// newNetworkTransport builds a transport from the network settings
+func newNetworkTransport(settings NetworkSettings) (*headerTransport, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if settings.CABundlePath != "" {
+		pool, err := loadCABundle(settings.CABundlePath)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	connectTimeout := time.Duration(settings.ConnectTimeoutSeconds) * time.Second
+	if connectTimeout <= 0 {
+		connectTimeout = time.Duration(defaultNetworkSettings().ConnectTimeoutSeconds) * time.Second
+	}
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}
+
+	transport := &http.Transport{
+		Proxy:                 proxyFunc(settings),
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   connectTimeout,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &headerTransport{
+		base:      transport,
+		userAgent: strings.TrimSpace(settings.UserAgent),
+		headers:   append([]HostHeader(nil), settings.HostHeaders...),
+	}, nil
+}
+
+// loadCABundle returns the system roots extended with the certificates of
+// a PEM file
+func loadCABundle(bundlePath string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", bundlePath)
+	}
+	return pool, nil
+}
+
+// proxyFunc returns the proxy selection of the configured mode
+func proxyFunc(settings NetworkSettings) func(*http.Request) (*url.URL, error) {
+	switch settings.ProxyMode {
+	case proxyModeNone:
+		return func(*http.Request) (*url.URL, error) { return nil, nil }
+	case proxyModeManual:
+		config := &httpproxy.Config{
+			HTTPProxy:  settings.ProxyURL,
+			HTTPSProxy: settings.ProxyURL,
+			NoProxy:    settings.NoProxy,
+		}
+		proxy := config.ProxyFunc()
+		return func(req *http.Request) (*url.URL, error) { return proxy(req.URL) }
+	case proxyModePAC:
+		return pacProxyFunc(settings.PACURL)
+	default:
+		return systemProxyFunc()
+	}
+}
+
+// environmentProxyFunc reads HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+func environmentProxyFunc() func(*http.Request) (*url.URL, error) {
+	proxy := httpproxy.FromEnvironment().ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) { return proxy(req.URL) }
+}
+
+// parseProxyList picks the proxy for a scheme from a Windows style list
+// such as "http=proxy:8080;https=proxy:8443" or "proxy:8080"
+func parseProxyList(list, scheme string) (*url.URL, error) {
+	var fallback string
+	for _, entry := range strings.FieldsFunc(list, func(r rune) bool { return r == ';' || r == ' ' }) {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			if strings.EqualFold(key, scheme) {
+				return parseProxyAddress(value)
+			}
+			continue
+		}
+		if fallback == "" {
+			fallback = entry
+		}
+	}
+	if fallback == "" {
+		return nil, nil
+	}
+	return parseProxyAddress(fallback)
+}
+
+// parseProxyAddress accepts "host:port" as well as full proxy URLs
+func parseProxyAddress(address string) (*url.URL, error) {
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	return url.Parse(address)
+}
+
+// headerTransport adds the configured user agent and per-host headers
+type headerTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	headers   []HostHeader
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.userAgent == "" && len(t.headers) == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(req.Context())
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	host := strings.ToLower(req.URL.Hostname())
+	for _, header := range t.headers {
+		if header.Name != "" && matchHostPattern(strings.ToLower(header.Host), host) {
+			req.Header.Set(header.Name, header.Value)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the base transport
+func (t *headerTransport) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// matchHostPattern matches a host against an exact name or a shell
+// pattern such as *.example.com
+func matchHostPattern(pattern, host string) bool {
+	if pattern == host {
+		return true
+	}
+	matched, err := path.Match(pattern, host)
+	return err == nil && matched
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// systemProxyFunc uses the proxy environment variables, which is how
+// proxies are configured for command line and desktop apps on macOS and Linux
+func systemProxyFunc() func(*http.Request) (*url.URL, error) {
+	return environmentProxyFunc()
+}
+
+// pacProxyFunc reports that auto-config scripts need the Windows proxy
+// resolver; there is no JavaScript engine to evaluate them elsewhere
+func pacProxyFunc(pacURL string) func(*http.Request) (*url.URL, error) {
+	return func(*http.Request) (*url.URL, error) {
+		return nil, fmt.Errorf("proxy auto-config (%s) is only supported on Windows, use a manual proxy instead", pacURL)
+	}
+}
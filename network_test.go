@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientSharesTransport(t *testing.T) {
+	settings := defaultNetworkSettings()
+	settings.ProxyMode = proxyModeNone
+
+	first, err := newHTTPClient(settings, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := newHTTPClient(settings, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Transport != second.Transport {
+		t.Error("clients with the same settings use different transports")
+	}
+	if second.Timeout != time.Minute {
+		t.Errorf("timeout = %v, want 1m", second.Timeout)
+	}
+
+	changed := settings
+	changed.UserAgent = "fixture-agent"
+	third, err := newHTTPClient(changed, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Transport == first.Transport {
+		t.Error("changed settings reused the old transport")
+	}
+
+	// Testing unsaved settings leaves the shared transport in place
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	a := NewApp()
+	result, err := a.TestNetworkSettings(settings, server.URL)
+	if err != nil || result.Error != "" || result.StatusCode != http.StatusOK {
+		t.Fatalf("TestNetworkSettings = %+v, %v", result, err)
+	}
+	fourth, err := newHTTPClient(changed, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fourth.Transport != third.Transport {
+		t.Error("TestNetworkSettings replaced the shared transport")
+	}
+}
+
+func TestHeaderTransport(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	settings := defaultNetworkSettings()
+	settings.ProxyMode = proxyModeNone
+	settings.UserAgent = "fixture-agent"
+	settings.HostHeaders = []HostHeader{
+		{Host: "127.0.0.1", Name: "Authorization", Value: "Bearer fixture"},
+		{Host: "*.example.com", Name: "X-Other", Value: "no"},
+	}
+	client, err := newHTTPClient(settings, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got.Get("User-Agent") != "fixture-agent" || got.Get("Authorization") != "Bearer fixture" || got.Get("X-Other") != "" {
+		t.Errorf("request headers = %v", got)
+	}
+}
+
+func TestNetworkRequestTimeout(t *testing.T) {
+	defaults := time.Duration(defaultNetworkSettings().RequestTimeoutSeconds) * time.Second
+	tests := []struct {
+		seconds int
+		want    time.Duration
+	}{
+		{seconds: 5, want: 5 * time.Second},
+		{seconds: 0, want: defaults},
+		{seconds: -1, want: defaults},
+	}
+
+	for _, test := range tests {
+		settings := defaultNetworkSettings()
+		settings.RequestTimeoutSeconds = test.seconds
+		if got := networkRequestTimeout(settings); got != test.want {
+			t.Errorf("timeout for %d seconds = %v, want %v", test.seconds, got, test.want)
+		}
+	}
+}
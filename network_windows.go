@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// WinHTTP constants used for proxy auto-config
+const (
+	winhttpAccessTypeNoProxy    = 1
+	winhttpAccessTypeNamedProxy = 3
+	winhttpAutoProxyAutoDetect  = 0x1
+	winhttpAutoProxyConfigURL   = 0x2
+	winhttpAutoDetectTypeDHCP   = 0x1
+	winhttpAutoDetectTypeDNSA   = 0x2
+)
+
+// pacLookupTimeout bounds downloading and running an auto-config script
+const pacLookupTimeout = 10 * time.Second
+
+var (
+	winhttp                                   = windows.NewLazySystemDLL("winhttp.dll")
+	procWinHttpOpen                           = winhttp.NewProc("WinHttpOpen")
+	procWinHttpGetProxyForUrl                 = winhttp.NewProc("WinHttpGetProxyForUrl")
+	procWinHttpGetIEProxyConfigForCurrentUser = winhttp.NewProc("WinHttpGetIEProxyConfigForCurrentUser")
+	procGlobalFree                            = windows.NewLazySystemDLL("kernel32.dll").NewProc("GlobalFree")
+
+	// WinHTTP session used only to resolve proxies
+	winhttpSessionOnce sync.Once
+	winhttpSession     uintptr
+	winhttpSessionErr  error
+)
+
+// winhttpAutoProxyOptions mirrors WINHTTP_AUTOPROXY_OPTIONS
+type winhttpAutoProxyOptions struct {
+	flags                 uint32
+	autoDetectFlags       uint32
+	autoConfigURL         *uint16
+	reserved              uintptr
+	reserved2             uint32
+	autoLogonIfChallenged int32
+}
+
+// winhttpProxyInfo mirrors WINHTTP_PROXY_INFO
+type winhttpProxyInfo struct {
+	accessType  uint32
+	proxy       *uint16
+	proxyBypass *uint16
+}
+
+// winhttpIEProxyConfig mirrors WINHTTP_CURRENT_USER_IE_PROXY_CONFIG
+type winhttpIEProxyConfig struct {
+	autoDetect    int32
+	autoConfigURL *uint16
+	proxy         *uint16
+	proxyBypass   *uint16
+}
+
+// systemProxyFunc follows the Internet Options of the current user:
+// automatic detection, an auto-config script or a fixed proxy with
+// exceptions. Without any of them the proxy environment variables apply.
+func systemProxyFunc() func(*http.Request) (*url.URL, error) {
+	var config winhttpIEProxyConfig
+	ret, _, _ := procWinHttpGetIEProxyConfigForCurrentUser.Call(uintptr(unsafe.Pointer(&config)))
+	if ret == 0 {
+		return environmentProxyFunc()
+	}
+
+	autoConfigURL := takeWinHTTPString(config.autoConfigURL)
+	staticProxy := takeWinHTTPString(config.proxy)
+	bypass := takeWinHTTPString(config.proxyBypass)
+
+	static := func(req *http.Request) (*url.URL, error) {
+		if staticProxy == "" || bypassesProxy(bypass, req.URL.Hostname()) {
+			return nil, nil
+		}
+		return parseProxyList(staticProxy, req.URL.Scheme)
+	}
+
+	if config.autoDetect == 0 && autoConfigURL == "" {
+		if staticProxy == "" {
+			return environmentProxyFunc()
+		}
+		return static
+	}
+
+	resolver := newAutoProxyResolver(autoConfigURL, config.autoDetect != 0)
+	return func(req *http.Request) (*url.URL, error) {
+		proxy, err := resolver.lookup(req.URL)
+		if err != nil {
+			// Auto-detection often fails off the corporate network
+			return static(req)
+		}
+		return proxy, nil
+	}
+}
+
+// pacProxyFunc resolves proxies with the auto-config script at pacURL
+func pacProxyFunc(pacURL string) func(*http.Request) (*url.URL, error) {
+	resolver := newAutoProxyResolver(pacURL, false)
+	return func(req *http.Request) (*url.URL, error) {
+		return resolver.lookup(req.URL)
+	}
+}
+
+// autoProxyResolver evaluates auto-config scripts through WinHTTP and
+// caches the decision per scheme and host
+type autoProxyResolver struct {
+	configURL  string
+	autoDetect bool
+
+	mu    sync.Mutex
+	cache map[string]proxyCacheEntry
+}
+
+// newAutoProxyResolver creates a resolver for a script URL, for automatic
+// detection (WPAD), or both
+func newAutoProxyResolver(configURL string, autoDetect bool) *autoProxyResolver {
+	return &autoProxyResolver{
+		configURL:  configURL,
+		autoDetect: autoDetect,
+		cache:      make(map[string]proxyCacheEntry),
+	}
+}
+
+// lookup returns the proxy for a URL, or nil for a direct connection
+func (r *autoProxyResolver) lookup(target *url.URL) (*url.URL, error) {
+	key := target.Scheme + "://" + target.Host
+
+	r.mu.Lock()
+	if entry, ok := r.cache[key]; ok && time.Now().Before(entry.expires) {
+		r.mu.Unlock()
+		return entry.proxy, nil
+	}
+	r.mu.Unlock()
+
+	proxy, err := withTimeout(pacLookupTimeout, func() (*url.URL, error) {
+		return r.query(target)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cache[key] = proxyCacheEntry{proxy: proxy, expires: time.Now().Add(proxyCacheTTL)}
+	r.mu.Unlock()
+	return proxy, nil
+}
+
+// query asks WinHTTP to run the auto-config script for a URL
+func (r *autoProxyResolver) query(target *url.URL) (*url.URL, error) {
+	session, err := openWinHTTPSession()
+	if err != nil {
+		return nil, err
+	}
+
+	targetPtr, err := windows.UTF16PtrFromString(target.String())
+	if err != nil {
+		return nil, err
+	}
+
+	options := winhttpAutoProxyOptions{autoLogonIfChallenged: 1}
+	if r.configURL != "" {
+		options.flags |= winhttpAutoProxyConfigURL
+		if options.autoConfigURL, err = windows.UTF16PtrFromString(r.configURL); err != nil {
+			return nil, err
+		}
+	}
+	if r.autoDetect {
+		options.flags |= winhttpAutoProxyAutoDetect
+		options.autoDetectFlags = winhttpAutoDetectTypeDHCP | winhttpAutoDetectTypeDNSA
+	}
+
+	var info winhttpProxyInfo
+	ret, _, callErr := procWinHttpGetProxyForUrl.Call(
+		session,
+		uintptr(unsafe.Pointer(targetPtr)),
+		uintptr(unsafe.Pointer(&options)),
+		uintptr(unsafe.Pointer(&info)),
+	)
+	if ret == 0 {
+		return nil, fmt.Errorf("proxy auto-config failed: %v", callErr)
+	}
+
+	proxyList := takeWinHTTPString(info.proxy)
+	takeWinHTTPString(info.proxyBypass)
+
+	if info.accessType != winhttpAccessTypeNamedProxy || proxyList == "" {
+		return nil, nil
+	}
+	return parseProxyList(proxyList, target.Scheme)
+}
+
+// openWinHTTPSession opens the session shared by all proxy lookups
+func openWinHTTPSession() (uintptr, error) {
+	winhttpSessionOnce.Do(func() {
+		agent, _ := windows.UTF16PtrFromString(AppName)
+		session, _, err := procWinHttpOpen.Call(uintptr(unsafe.Pointer(agent)), winhttpAccessTypeNoProxy, 0, 0, 0)
+		if session == 0 {
+			winhttpSessionErr = fmt.Errorf("WinHttpOpen failed: %v", err)
+			return
+		}
+		winhttpSession = session
+	})
+	return winhttpSession, winhttpSessionErr
+}
+
+// takeWinHTTPString copies a string allocated by WinHTTP and frees it
+func takeWinHTTPString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	value := windows.UTF16PtrToString(p)
+	procGlobalFree.Call(uintptr(unsafe.Pointer(p)))
+	return value
+}
+
+// bypassesProxy checks a host against the proxy exceptions of Internet
+// Options, e.g. "*.corp;10.*;<local>"
+func bypassesProxy(bypass, host string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range strings.FieldsFunc(bypass, func(r rune) bool { return r == ';' || r == ' ' }) {
+		entry = strings.ToLower(entry)
+		if entry == "<local>" {
+			if !strings.Contains(host, ".") {
+				return true
+			}
+			continue
+		}
+		if matched, err := path.Match(entry, host); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyCacheTTL is how long a proxy decision from auto-config is reused
+const proxyCacheTTL = 5 * time.Minute
+
+// proxyCacheEntry is a cached auto-config decision for one host
+type proxyCacheEntry struct {
+	proxy   *url.URL
+	expires time.Time
+}
+
+// withTimeout runs fn with a deadline, used for auto-config lookups
+func withTimeout(timeout time.Duration, fn func() (*url.URL, error)) (*url.URL, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type result struct {
+		proxy *url.URL
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		proxy, err := fn()
+		done <- result{proxy, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.proxy, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("proxy auto-config timed out")
+	}
+}
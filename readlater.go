@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Read-later and bookmarking services whose export files can be imported
+const (
+	serviceInstapaper = "instapaper"
+	serviceLinkwarden = "linkwarden"
+	servicePinboard   = "pinboard"
+	servicePocket     = "pocket"
+	serviceRaindrop   = "raindrop"
+)
+
+// Tags added to items that a service marks as archived, favorite or unread
+const (
+	serviceTagArchived = "archived"
+	serviceTagFavorite = "favorite"
+	serviceTagToRead   = "toread"
+)
+
+// ImportServiceExport imports an export file of a read-later or bookmarking
+// service given as base64. Supported services are pocket (HTML or CSV),
+// raindrop (CSV), pinboard (JSON), instapaper (CSV) and linkwarden (JSON).
+func (a *App) ImportServiceExport(service string, base64Data string) (int, error) {
+	data, err := base64.StdEncoding.DecodeString(base64Data)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file data: %w", err)
+	}
+
+	bookmarks, err := parseServiceExport(service, data)
+	if err != nil {
+		return 0, err
+	}
+
+	return a.saveImportedBookmarks(bookmarks)
+}
+
+// parseServiceExport parses an export file of the given service
+func parseServiceExport(service string, data []byte) ([]importedBookmark, error) {
+	switch strings.ToLower(service) {
+	case servicePocket:
+		trimmed := bytes.TrimSpace(data)
+		if bytes.HasPrefix(trimmed, []byte("<")) {
+			return parsePocketHTML(data)
+		}
+		return parsePocketCSV(data)
+	case serviceRaindrop:
+		return parseRaindropCSV(data)
+	case servicePinboard:
+		return parsePinboardJSON(data)
+	case serviceInstapaper:
+		return parseInstapaperCSV(data)
+	case serviceLinkwarden:
+		return parseLinkwardenJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported service: %s", service)
+	}
+}
+
+// parsePocketHTML parses Pocket's ril_export.html. Links carry time_added
+// and tags attributes; items below the "Read Archive" heading are archived.
+func parsePocketHTML(data []byte) ([]importedBookmark, error) {
+	var bookmarks []importedBookmark
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	archived := false
+	inHeading := false
+	var current *importedBookmark
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return bookmarks, nil
+		case html.StartTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "h1", "h2":
+				inHeading = true
+			case "a":
+				attrs := htmlAttrs(token)
+				tags := splitTags(attrs["tags"], ",")
+				if archived {
+					tags = append(tags, serviceTagArchived)
+				}
+				current = &importedBookmark{
+					URL:       attrs["href"],
+					Category:  "Pocket",
+					Tags:      tags,
+					CreatedAt: parseFlexibleTime(attrs["time_added"]),
+				}
+			}
+		case html.TextToken:
+			text := strings.TrimSpace(string(tokenizer.Text()))
+			if inHeading {
+				archived = strings.Contains(strings.ToLower(text), "archive")
+			} else if current != nil {
+				current.Title += text
+			}
+		case html.EndTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "h1", "h2":
+				inHeading = false
+			case "a":
+				if current != nil {
+					bookmarks = append(bookmarks, *current)
+					current = nil
+				}
+			}
+		}
+	}
+}
+
+// htmlAttrs returns the attributes of a tag keyed by lowercase name
+func htmlAttrs(token html.Token) map[string]string {
+	attrs := make(map[string]string, len(token.Attr))
+	for _, attr := range token.Attr {
+		attrs[strings.ToLower(attr.Key)] = attr.Val
+	}
+	return attrs
+}
+
+// csvTable gives access to CSV rows by header name
+type csvTable struct {
+	columns map[string]int
+	rows    [][]string
+}
+
+// readCSVTable reads CSV data whose first record is a header
+func readCSVTable(data []byte) (*csvTable, error) {
+	records, err := readCSVRecords(data, "", "")
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return &csvTable{}, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[normalizeCSVHeader(name)] = i
+	}
+	return &csvTable{columns: columns, rows: records[1:]}, nil
+}
+
+// get returns the trimmed value of a column in a row, or "" when missing
+func (t *csvTable) get(row []string, column string) string {
+	index, ok := t.columns[column]
+	if !ok || index >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[index])
+}
+
+// requireColumns fails when a column the service always exports is missing
+func (t *csvTable) requireColumns(service string, columns ...string) error {
+	for _, column := range columns {
+		if _, ok := t.columns[column]; !ok {
+			return fmt.Errorf("not a %s export: missing column %q", service, column)
+		}
+	}
+	return nil
+}
+
+// parsePocketCSV parses Pocket's newer CSV export with the columns
+// title, url, time_added, tags (separated by |) and status
+func parsePocketCSV(data []byte) ([]importedBookmark, error) {
+	table, err := readCSVTable(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := table.requireColumns("Pocket", "url"); err != nil {
+		return nil, err
+	}
+
+	var bookmarks []importedBookmark
+	for _, row := range table.rows {
+		tags := splitTags(table.get(row, "tags"), "|")
+		if table.get(row, "status") == "archive" {
+			tags = append(tags, serviceTagArchived)
+		}
+
+		bookmarks = append(bookmarks, importedBookmark{
+			Title:     table.get(row, "title"),
+			URL:       table.get(row, "url"),
+			Category:  "Pocket",
+			Tags:      tags,
+			CreatedAt: parseFlexibleTime(table.get(row, "time_added")),
+		})
+	}
+	return bookmarks, nil
+}
+
+// parseRaindropCSV parses a Raindrop.io CSV export with the columns id,
+// title, note, excerpt, url, folder, tags, created, cover, highlights and favorite
+func parseRaindropCSV(data []byte) ([]importedBookmark, error) {
+	table, err := readCSVTable(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := table.requireColumns("Raindrop.io", "url", "title"); err != nil {
+		return nil, err
+	}
+
+	var bookmarks []importedBookmark
+	for _, row := range table.rows {
+		description := table.get(row, "note")
+		if description == "" {
+			description = table.get(row, "excerpt")
+		}
+
+		// Nested collections are exported as "Parent / Child"
+		category := table.get(row, "folder")
+		if index := strings.LastIndex(category, "/"); index >= 0 {
+			category = strings.TrimSpace(category[index+1:])
+		}
+		if category == "" || category == "Unsorted" {
+			category = "Raindrop"
+		}
+
+		tags := splitTags(table.get(row, "tags"), ",")
+		if table.get(row, "favorite") == "true" {
+			tags = append(tags, serviceTagFavorite)
+		}
+
+		bookmarks = append(bookmarks, importedBookmark{
+			Title:       table.get(row, "title"),
+			URL:         table.get(row, "url"),
+			Description: description,
+			Category:    category,
+			Tags:        tags,
+			CreatedAt:   parseFlexibleTime(table.get(row, "created")),
+		})
+	}
+	return bookmarks, nil
+}
+
+// pinboardPost is one entry of a Pinboard JSON export
+type pinboardPost struct {
+	Href        string `json:"href"`
+	Description string `json:"description"` // Pinboard's title
+	Extended    string `json:"extended"`    // Pinboard's notes
+	Time        string `json:"time"`
+	Shared      string `json:"shared"`
+	ToRead      string `json:"toread"`
+	Tags        string `json:"tags"` // Space separated
+}
+
+// parsePinboardJSON parses a Pinboard JSON export (posts/all format)
+func parsePinboardJSON(data []byte) ([]importedBookmark, error) {
+	var posts []pinboardPost
+	if err := json.Unmarshal(data, &posts); err != nil {
+		return nil, fmt.Errorf("not a Pinboard export: %w", err)
+	}
+
+	var bookmarks []importedBookmark
+	for _, post := range posts {
+		tags := strings.Fields(post.Tags)
+		if post.ToRead == "yes" {
+			tags = append(tags, serviceTagToRead)
+		}
+
+		bookmarks = append(bookmarks, importedBookmark{
+			Title:       post.Description,
+			URL:         post.Href,
+			Description: post.Extended,
+			Category:    "Pinboard",
+			Tags:        tags,
+			CreatedAt:   parseFlexibleTime(post.Time),
+		})
+	}
+	return bookmarks, nil
+}
+
+// parseInstapaperCSV parses an Instapaper CSV export with the columns URL,
+// Title, Selection, Folder, Timestamp and, in newer exports, Tags
+func parseInstapaperCSV(data []byte) ([]importedBookmark, error) {
+	table, err := readCSVTable(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := table.requireColumns("Instapaper", "url", "title", "folder"); err != nil {
+		return nil, err
+	}
+
+	var bookmarks []importedBookmark
+	for _, row := range table.rows {
+		var tags []string
+		category := table.get(row, "folder")
+		switch category {
+		case "Unread", "":
+			category = "Instapaper"
+			tags = append(tags, serviceTagToRead)
+		case "Archive":
+			category = "Instapaper"
+			tags = append(tags, serviceTagArchived)
+		case "Starred":
+			category = "Instapaper"
+			tags = append(tags, serviceTagFavorite)
+		}
+
+		// Tags are exported as a JSON array such as ["go","web"]
+		if rawTags := table.get(row, "tags"); rawTags != "" {
+			var exported []string
+			if err := json.Unmarshal([]byte(rawTags), &exported); err == nil {
+				tags = append(exported, tags...)
+			} else {
+				tags = append(splitTags(rawTags, ""), tags...)
+			}
+		}
+
+		bookmarks = append(bookmarks, importedBookmark{
+			Title:       table.get(row, "title"),
+			URL:         table.get(row, "url"),
+			Description: table.get(row, "selection"),
+			Category:    category,
+			Tags:        tags,
+			CreatedAt:   parseFlexibleTime(table.get(row, "timestamp")),
+		})
+	}
+	return bookmarks, nil
+}
+
+// linkwardenExport is the JSON backup produced by Linkwarden's migration export
+type linkwardenExport struct {
+	Collections []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Color       string `json:"color"`
+		Links       []struct {
+			Name        string `json:"name"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+			CreatedAt   string `json:"createdAt"`
+			UpdatedAt   string `json:"updatedAt"`
+			Tags        []struct {
+				Name string `json:"name"`
+			} `json:"tags"`
+		} `json:"links"`
+	} `json:"collections"`
+}
+
+// parseLinkwardenJSON parses a Linkwarden backup; collections become
+// categories with their colour
+func parseLinkwardenJSON(data []byte) ([]importedBookmark, error) {
+	var export linkwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("not a Linkwarden export: %w", err)
+	}
+
+	var bookmarks []importedBookmark
+	for _, collection := range export.Collections {
+		for _, link := range collection.Links {
+			var tags []string
+			for _, tag := range link.Tags {
+				if tag.Name != "" {
+					tags = append(tags, tag.Name)
+				}
+			}
+
+			bookmarks = append(bookmarks, importedBookmark{
+				Title:       link.Name,
+				URL:         link.URL,
+				Description: link.Description,
+				Category:    collection.Name,
+				Color:       collection.Color,
+				Tags:        tags,
+				CreatedAt:   parseFlexibleTime(link.CreatedAt),
+				UpdatedAt:   parseFlexibleTime(link.UpdatedAt),
+			})
+		}
+	}
+	return bookmarks, nil
+}
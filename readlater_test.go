@@ -0,0 +1,176 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const pocketHTMLFixture = `<!DOCTYPE html>
+<html>
+<head><title>Pocket Export</title></head>
+<body>
+<h1>Unread</h1>
+<ul>
+<li><a href="https://go.dev/" time_added="1700000000" tags="go,lang">The Go Programming Language</a></li>
+</ul>
+<h1>Read Archive</h1>
+<ul>
+<li><a href="https://example.com/old" time_added="1600000000" tags="">Old article</a></li>
+</ul>
+</body>
+</html>
+`
+
+const pocketCSVFixture = `title,url,time_added,tags,status
+Go,https://go.dev/,1700000000,go|lang,unread
+Old,https://example.com/old,1600000000,,archive
+`
+
+const raindropCSVFixture = `id,title,note,excerpt,url,folder,tags,created,cover,highlights,favorite
+1,Go,,Go homepage,https://go.dev/,Dev / Languages,"go, lang",2023-11-14T22:13:20Z,,,true
+2,Inbox item,A note,An excerpt,https://example.com/,Unsorted,,2020-09-13T12:26:40Z,,,false
+`
+
+const pinboardJSONFixture = `[
+  {"href":"https://go.dev/","description":"Go","extended":"The Go site","time":"2023-11-14T22:13:20Z","shared":"yes","toread":"no","tags":"go lang"},
+  {"href":"https://example.com/","description":"Later","extended":"","time":"2020-09-13T12:26:40Z","shared":"no","toread":"yes","tags":""}
+]`
+
+const instapaperCSVFixture = `URL,Title,Selection,Folder,Timestamp,Tags
+https://go.dev/,Go,Quoted text,Unread,1700000000,"[""go"",""lang""]"
+https://example.com/a,Archived,,Archive,1600000000,
+https://example.com/s,Starred,,Starred,1600000000,
+https://example.com/f,In folder,,Reading,1600000000,plain
+`
+
+const linkwardenJSONFixture = `{
+  "collections": [
+    {
+      "name": "Dev",
+      "description": "Development",
+      "color": "#0ea5e9",
+      "links": [
+        {
+          "name": "Go",
+          "url": "https://go.dev/",
+          "description": "The Go site",
+          "createdAt": "2023-11-14T22:13:20Z",
+          "updatedAt": "2023-11-15T00:00:00Z",
+          "tags": [{"name": "go"}, {"name": ""}, {"name": "lang"}]
+        }
+      ]
+    }
+  ]
+}`
+
+func TestParseServiceExport(t *testing.T) {
+	newer := time.Unix(1700000000, 0)
+	older := time.Unix(1600000000, 0)
+
+	tests := []struct {
+		name    string
+		service string
+		data    string
+		want    []importedBookmark
+		wantErr string
+	}{
+		{
+			name:    "pocket html",
+			service: servicePocket,
+			data:    pocketHTMLFixture,
+			want: []importedBookmark{
+				{Title: "The Go Programming Language", URL: "https://go.dev/", Category: "Pocket", Tags: []string{"go", "lang"}, CreatedAt: newer},
+				{Title: "Old article", URL: "https://example.com/old", Category: "Pocket", Tags: []string{serviceTagArchived}, CreatedAt: older},
+			},
+		},
+		{
+			name:    "pocket csv",
+			service: servicePocket,
+			data:    pocketCSVFixture,
+			want: []importedBookmark{
+				{Title: "Go", URL: "https://go.dev/", Category: "Pocket", Tags: []string{"go", "lang"}, CreatedAt: newer},
+				{Title: "Old", URL: "https://example.com/old", Category: "Pocket", Tags: []string{serviceTagArchived}, CreatedAt: older},
+			},
+		},
+		{
+			name:    "raindrop csv",
+			service: serviceRaindrop,
+			data:    raindropCSVFixture,
+			want: []importedBookmark{
+				{Title: "Go", URL: "https://go.dev/", Description: "Go homepage", Category: "Languages", Tags: []string{"go", "lang", serviceTagFavorite}, CreatedAt: newer},
+				{Title: "Inbox item", URL: "https://example.com/", Description: "A note", Category: "Raindrop", CreatedAt: older},
+			},
+		},
+		{
+			name:    "pinboard json",
+			service: servicePinboard,
+			data:    pinboardJSONFixture,
+			want: []importedBookmark{
+				{Title: "Go", URL: "https://go.dev/", Description: "The Go site", Category: "Pinboard", Tags: []string{"go", "lang"}, CreatedAt: newer},
+				{Title: "Later", URL: "https://example.com/", Category: "Pinboard", Tags: []string{serviceTagToRead}, CreatedAt: older},
+			},
+		},
+		{
+			name:    "instapaper csv",
+			service: serviceInstapaper,
+			data:    instapaperCSVFixture,
+			want: []importedBookmark{
+				{Title: "Go", URL: "https://go.dev/", Description: "Quoted text", Category: "Instapaper", Tags: []string{"go", "lang", serviceTagToRead}, CreatedAt: newer},
+				{Title: "Archived", URL: "https://example.com/a", Category: "Instapaper", Tags: []string{serviceTagArchived}, CreatedAt: older},
+				{Title: "Starred", URL: "https://example.com/s", Category: "Instapaper", Tags: []string{serviceTagFavorite}, CreatedAt: older},
+				{Title: "In folder", URL: "https://example.com/f", Category: "Reading", Tags: []string{"plain"}, CreatedAt: older},
+			},
+		},
+		{
+			name:    "linkwarden json",
+			service: serviceLinkwarden,
+			data:    linkwardenJSONFixture,
+			want: []importedBookmark{
+				{
+					Title: "Go", URL: "https://go.dev/", Description: "The Go site", Category: "Dev", Color: "#0ea5e9",
+					Tags: []string{"go", "lang"}, CreatedAt: newer, UpdatedAt: time.Date(2023, 11, 15, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+		{name: "service name is case insensitive", service: "Pinboard", data: "[]", want: nil},
+		{name: "instapaper missing folder", service: serviceInstapaper, data: "URL,Title\nhttps://go.dev/,Go\n", wantErr: `missing column "folder"`},
+		{name: "raindrop missing title", service: serviceRaindrop, data: "url\nhttps://go.dev/\n", wantErr: `missing column "title"`},
+		{name: "pinboard invalid json", service: servicePinboard, data: "{", wantErr: "not a Pinboard export"},
+		{name: "linkwarden invalid json", service: serviceLinkwarden, data: "[]", wantErr: "not a Linkwarden export"},
+		{name: "unknown service", service: "delicious", data: "", wantErr: "unsupported service"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseServiceExport(test.service, []byte(test.data))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d bookmarks, want %d: %+v", len(got), len(test.want), got)
+			}
+
+			for i := range got {
+				// Times are compared as instants; their locations differ by format
+				if !got[i].CreatedAt.Equal(test.want[i].CreatedAt) || !got[i].UpdatedAt.Equal(test.want[i].UpdatedAt) {
+					t.Errorf("bookmark %d times = %v, %v, want %v, %v", i,
+						got[i].CreatedAt, got[i].UpdatedAt, test.want[i].CreatedAt, test.want[i].UpdatedAt)
+				}
+				gotItem, wantItem := got[i], test.want[i]
+				gotItem.CreatedAt, gotItem.UpdatedAt = time.Time{}, time.Time{}
+				wantItem.CreatedAt, wantItem.UpdatedAt = time.Time{}, time.Time{}
+				if !reflect.DeepEqual(gotItem, wantItem) {
+					t.Errorf("bookmark %d = %+v, want %+v", i, gotItem, wantItem)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+)
+
+// restartExecutable replaces the running process with the executable,
+// keeping the arguments, environment and working directory. A macOS app
+// bundle is reopened through Launch Services instead so that it starts as
+// a regular app. It only returns on failure.
+func restartExecutable(exePath string, args []string) error {
+	if runtime.GOOS == "darwin" {
+		if bundle := macAppBundle(exePath); bundle != "" {
+			cmd := exec.Command("open", append([]string{"-n", bundle, "--args"}, args...)...)
+			if err := cmd.Run(); err != nil {
+				return err
+			}
+			os.Exit(0)
+		}
+	}
+	return syscall.Exec(exePath, append([]string{os.Args[0]}, args...), os.Environ())
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// restartExecutable starts a detached process of the executable with the
+// same arguments and working directory, then exits. The arguments are
+// passed as they are instead of through cmd /c start, which would parse
+// them again. It only returns on failure.
+func restartExecutable(exePath string, args []string) error {
+	cmd := exec.Command(exePath, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS,
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
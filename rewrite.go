@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rewrite rule types
+const (
+	rewriteRuleRegex = "regex" // Pattern is a regular expression on the whole URL
+	rewriteRuleHost  = "host"  // Pattern is a host replaced by Replacement
+)
+
+// RedirectResolution is the destination of a bookmark whose URL redirects
+type RedirectResolution struct {
+	URLID     string         `json:"urlId"`
+	Title     string         `json:"title"`
+	URL       string         `json:"url"`
+	FinalURL  string         `json:"finalUrl"`
+	Chain     []LinkRedirect `json:"chain"`
+	Permanent bool           `json:"permanent"` // Every hop is a 301 or 308
+	Status    string         `json:"status"`    // Link status of the destination
+	Error     string         `json:"error,omitempty"`
+}
+
+// RewriteRule rewrites bookmark URLs, either by regular expression or by
+// mapping one host to another
+type RewriteRule struct {
+	Type        string `json:"type"` // "regex" or "host"
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// URLRewrite is a proposed change of a bookmark's URL
+type URLRewrite struct {
+	URLID     string `json:"urlId"`
+	Title     string `json:"title"`
+	OldURL    string `json:"oldUrl"`
+	NewURL    string `json:"newUrl"`
+	Duplicate bool   `json:"duplicate"` // Another bookmark already has NewURL
+}
+
+var (
+	// 当前重定向解析任务
+	resolveRedirectsMutex  sync.Mutex
+	resolveRedirectsCancel context.CancelFunc
+)
+
+// ResolveRedirects follows the redirects of the given bookmarks, or of all
+// bookmarks when ids is empty, and returns those that lead elsewhere. A
+// cancelled run returns the redirects found so far.
+func (a *App) ResolveRedirects(ids []string) ([]RedirectResolution, error) {
+	urls, err := a.GetURLs()
+	if err != nil {
+		return nil, err
+	}
+	settings, err := a.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	client, err := newHTTPClient(settings.Network, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) > 0 {
+		wanted := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			wanted[id] = true
+		}
+		var selected []URLItem
+		for _, item := range urls {
+			if wanted[item.ID] {
+				selected = append(selected, item)
+			}
+		}
+		urls = selected
+	}
+
+	resolveRedirectsMutex.Lock()
+	if resolveRedirectsCancel != nil {
+		resolveRedirectsMutex.Unlock()
+		return nil, fmt.Errorf("redirects are already being resolved")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	resolveRedirectsCancel = cancel
+	resolveRedirectsMutex.Unlock()
+
+	defer func() {
+		resolveRedirectsMutex.Lock()
+		resolveRedirectsCancel = nil
+		resolveRedirectsMutex.Unlock()
+		cancel()
+	}()
+
+	return resolveRedirects(ctx, newLinkChecker(settings.LinkCheck, client), urls, settings.LinkCheck.Concurrency), nil
+}
+
+// CancelResolveRedirects stops a running ResolveRedirects
+func (a *App) CancelResolveRedirects() {
+	resolveRedirectsMutex.Lock()
+	defer resolveRedirectsMutex.Unlock()
+	if resolveRedirectsCancel != nil {
+		resolveRedirectsCancel()
+	}
+}
+
+// resolveRedirects checks the bookmarks with a worker pool and returns
+// those whose URL redirects to a different one
+func resolveRedirects(ctx context.Context, checker *linkChecker, urls []URLItem, workers int) []RedirectResolution {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, workers)
+	results := []RedirectResolution{}
+
+	for _, item := range urls {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(item URLItem) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			health := checker.check(ctx, item.URL)
+			if len(health.Redirects) == 0 || health.ErrorKind == "cancelled" {
+				return
+			}
+
+			resolution := RedirectResolution{
+				URLID:     item.ID,
+				Title:     item.Title,
+				URL:       item.URL,
+				FinalURL:  health.FinalURL,
+				Chain:     health.Redirects,
+				Permanent: true,
+				Status:    health.Status,
+				Error:     health.Error,
+			}
+			if resolution.FinalURL == "" {
+				resolution.FinalURL = health.Redirects[len(health.Redirects)-1].URL
+			}
+			for _, hop := range health.Redirects {
+				if hop.StatusCode != http.StatusMovedPermanently && hop.StatusCode != http.StatusPermanentRedirect {
+					resolution.Permanent = false
+				}
+			}
+			if resolution.FinalURL == item.URL {
+				return
+			}
+
+			mu.Lock()
+			results = append(results, resolution)
+			mu.Unlock()
+		}(item)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// PreviewRewrite returns the URL changes the rules would make to the
+// bookmarks matching the filter, without saving anything
+func (a *App) PreviewRewrite(rules []RewriteRule, filter AdvancedSearchOptions) ([]URLRewrite, error) {
+	compiled, err := compileRewriteRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	matching, err := a.AdvancedSearchURLs(filter)
+	if err != nil {
+		return nil, err
+	}
+	all, err := a.GetURLs()
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(all))
+	for _, item := range all {
+		existing[item.URL] = true
+	}
+
+	rewrites := []URLRewrite{}
+	for _, item := range matching {
+		newURL := applyRewriteRules(compiled, item.URL)
+		if newURL == item.URL {
+			continue
+		}
+		rewrites = append(rewrites, URLRewrite{
+			URLID:     item.ID,
+			Title:     item.Title,
+			OldURL:    item.URL,
+			NewURL:    newURL,
+			Duplicate: existing[newURL],
+		})
+	}
+
+	return rewrites, nil
+}
+
+// ApplyURLRewrites changes the URLs of bookmarks, e.g. from PreviewRewrite
+// or ResolveRedirects. Bookmarks changed since the preview are skipped.
+func (a *App) ApplyURLRewrites(rewrites []URLRewrite) (int, error) {
+	byID := make(map[string]URLRewrite, len(rewrites))
+	for _, rewrite := range rewrites {
+		if strings.TrimSpace(rewrite.NewURL) == "" {
+			return 0, fmt.Errorf("empty URL for bookmark %s", rewrite.URLID)
+		}
+		byID[rewrite.URLID] = rewrite
+	}
+
+	applied := 0
+	err := a.modifyURLs(func(urls []URLItem) ([]URLItem, error) {
+		for i := range urls {
+			rewrite, ok := byID[urls[i].ID]
+			if !ok || urls[i].URL != rewrite.OldURL {
+				continue
+			}
+			urls[i].URL = rewrite.NewURL
+			urls[i].UpdatedAt = time.Now()
+			// The check result belongs to the old URL
+			urls[i].Health = nil
+			applied++
+		}
+		return urls, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return applied, nil
+}
+
+// compiledRewriteRule is a validated rewrite rule
+type compiledRewriteRule struct {
+	rule  RewriteRule
+	regex *regexp.Regexp
+}
+
+// compileRewriteRules validates the rules and compiles regular expressions
+func compileRewriteRules(rules []RewriteRule) ([]compiledRewriteRule, error) {
+	var compiled []compiledRewriteRule
+	for i, rule := range rules {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("rule %d: empty pattern", i+1)
+		}
+		switch rule.Type {
+		case rewriteRuleRegex:
+			regex, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %v", i+1, err)
+			}
+			compiled = append(compiled, compiledRewriteRule{rule: rule, regex: regex})
+		case rewriteRuleHost:
+			if rule.Replacement == "" {
+				return nil, fmt.Errorf("rule %d: empty replacement host", i+1)
+			}
+			compiled = append(compiled, compiledRewriteRule{rule: rule})
+		default:
+			return nil, fmt.Errorf("rule %d: unknown rule type %q", i+1, rule.Type)
+		}
+	}
+	return compiled, nil
+}
+
+// applyRewriteRules applies the rules in order to a URL
+func applyRewriteRules(rules []compiledRewriteRule, rawURL string) string {
+	for _, rule := range rules {
+		if rule.regex != nil {
+			rawURL = rule.regex.ReplaceAllString(rawURL, rule.rule.Replacement)
+			continue
+		}
+		rawURL = rewriteHost(rawURL, rule.rule.Pattern, rule.rule.Replacement)
+	}
+	return rawURL
+}
+
+// rewriteHost replaces the host of a URL when it equals from. The
+// replacement may include a scheme, e.g. "https://wiki.corp", to also
+// change the scheme. Ports are compared only when from has one.
+func rewriteHost(rawURL, from, to string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+
+	from = strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(from, "https://"), "http://"), "/"))
+	host := strings.ToLower(parsed.Host)
+	if !strings.Contains(from, ":") {
+		host = strings.ToLower(parsed.Hostname())
+	}
+	if host != from {
+		return rawURL
+	}
+
+	to = strings.TrimSuffix(to, "/")
+	if scheme, rest, ok := strings.Cut(to, "://"); ok {
+		parsed.Scheme = scheme
+		to = rest
+	}
+	if !strings.Contains(from, ":") && parsed.Port() != "" && !strings.Contains(to, ":") {
+		to += ":" + parsed.Port()
+	}
+	parsed.Host = to
+	return parsed.String()
+}
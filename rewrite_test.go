@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRewriteHost(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		from, to string
+		want     string
+	}{
+		{name: "plain host", url: "http://old.example/a?b=1#c", from: "old.example", to: "new.example", want: "http://new.example/a?b=1#c"},
+		{name: "case insensitive", url: "http://OLD.example/", from: "old.example", to: "new.example", want: "http://new.example/"},
+		{name: "other host", url: "http://sub.old.example/", from: "old.example", to: "new.example", want: "http://sub.old.example/"},
+		{name: "scheme in replacement", url: "http://wiki/page", from: "wiki", to: "https://wiki.corp/", want: "https://wiki.corp/page"},
+		{name: "scheme in pattern", url: "http://wiki/page", from: "https://wiki/", to: "wiki.corp", want: "http://wiki.corp/page"},
+		{name: "port kept", url: "http://dev:8080/x", from: "dev", to: "dev.corp", want: "http://dev.corp:8080/x"},
+		{name: "port in pattern", url: "http://dev:8080/x", from: "dev:8080", to: "dev.corp", want: "http://dev.corp/x"},
+		{name: "other port", url: "http://dev:9090/x", from: "dev:8080", to: "dev.corp", want: "http://dev:9090/x"},
+		{name: "port in replacement", url: "http://dev:8080/x", from: "dev", to: "dev.corp:443", want: "http://dev.corp:443/x"},
+		{name: "no host", url: "mailto:someone@old.example", from: "old.example", to: "new.example", want: "mailto:someone@old.example"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := rewriteHost(test.url, test.from, test.to); got != test.want {
+				t.Errorf("rewriteHost(%q, %q, %q) = %q, want %q", test.url, test.from, test.to, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCompileRewriteRulesRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		rule    RewriteRule
+		wantErr string
+	}{
+		{rule: RewriteRule{Type: rewriteRuleRegex}, wantErr: "empty pattern"},
+		{rule: RewriteRule{Type: rewriteRuleRegex, Pattern: "("}, wantErr: "missing closing )"},
+		{rule: RewriteRule{Type: rewriteRuleHost, Pattern: "old.example"}, wantErr: "empty replacement host"},
+		{rule: RewriteRule{Type: "glob", Pattern: "*"}, wantErr: "unknown rule type"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.wantErr, func(t *testing.T) {
+			_, err := compileRewriteRules([]RewriteRule{test.rule})
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreviewAndApplyRewrite(t *testing.T) {
+	setTestHome(t)
+	a := NewApp()
+
+	urls := []URLItem{
+		{ID: "1", Title: "Docs", URL: "http://wiki.old/docs", Category: "Work", Health: &LinkHealth{Status: linkStatusBroken}},
+		{ID: "2", Title: "Home", URL: "http://wiki.old/", Category: "Work"},
+		{ID: "3", Title: "Existing", URL: "https://wiki.new/", Category: "Work"},
+		{ID: "4", Title: "Private", URL: "http://wiki.old/private", Category: "Home"},
+		{ID: "5", Title: "Other", URL: "http://other.example/", Category: "Work"},
+	}
+	if err := a.SaveURLs(urls); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := []RewriteRule{
+		{Type: rewriteRuleHost, Pattern: "wiki.old", Replacement: "https://wiki.new"},
+		{Type: rewriteRuleRegex, Pattern: `/docs$`, Replacement: "/documentation"},
+	}
+	rewrites, err := a.PreviewRewrite(rules, AdvancedSearchOptions{Category: "Work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(rewrites, func(i, j int) bool { return rewrites[i].URLID < rewrites[j].URLID })
+	want := []URLRewrite{
+		{URLID: "1", Title: "Docs", OldURL: "http://wiki.old/docs", NewURL: "https://wiki.new/documentation"},
+		{URLID: "2", Title: "Home", OldURL: "http://wiki.old/", NewURL: "https://wiki.new/", Duplicate: true},
+	}
+	if len(rewrites) != len(want) {
+		t.Fatalf("rewrites = %+v, want %+v", rewrites, want)
+	}
+	for i := range want {
+		if rewrites[i] != want[i] {
+			t.Errorf("rewrite %d = %+v, want %+v", i, rewrites[i], want[i])
+		}
+	}
+
+	// The preview saves nothing
+	stored, err := a.GetURLs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored[0].URL != urls[0].URL {
+		t.Fatalf("preview changed URL to %q", stored[0].URL)
+	}
+
+	// A bookmark edited after the preview is skipped
+	edited := stored[1]
+	if _, err := a.UpdateURL(edited.ID, edited.Title, "http://wiki.old/index", edited.Description, edited.Category, edited.Tags); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := a.ApplyURLRewrites(rewrites)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 1 {
+		t.Errorf("applied = %d, want 1", applied)
+	}
+	stored, err = a.GetURLs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]URLItem)
+	for _, item := range stored {
+		byID[item.ID] = item
+	}
+	if item := byID["1"]; item.URL != "https://wiki.new/documentation" || item.Health != nil {
+		t.Errorf("rewritten bookmark = %q (health %+v), want new URL without health", item.URL, item.Health)
+	}
+	if item := byID["2"]; item.URL != "http://wiki.old/index" {
+		t.Errorf("edited bookmark URL = %q, want the edit kept", item.URL)
+	}
+	if item := byID["4"]; item.URL != "http://wiki.old/private" {
+		t.Errorf("filtered out bookmark URL = %q, want it unchanged", item.URL)
+	}
+
+	if _, err := a.ApplyURLRewrites([]URLRewrite{{URLID: "5", OldURL: "http://other.example/", NewURL: " "}}); err == nil {
+		t.Error("expected an error for an empty URL")
+	}
+}
+
+func TestResolveRedirects(t *testing.T) {
+	server := newLinkCheckServer(t)
+	checker := newLinkChecker(LinkCheckSettings{TimeoutSeconds: 1, PerHostLimit: 4}, server.Client())
+
+	urls := []URLItem{
+		{ID: "moved", URL: server.URL + "/moved"},
+		{ID: "temporary", URL: server.URL + "/temporary"},
+		{ID: "ok", URL: server.URL + "/ok"},
+		{ID: "missing", URL: server.URL + "/missing"},
+	}
+	results := resolveRedirects(context.Background(), checker, urls, 2)
+	sort.Slice(results, func(i, j int) bool { return results[i].URLID < results[j].URLID })
+
+	if len(results) != 2 {
+		t.Fatalf("results = %+v, want moved and temporary", results)
+	}
+	if got := results[0]; got.URLID != "moved" || got.FinalURL != server.URL+"/ok" || !got.Permanent || len(got.Chain) != 1 {
+		t.Errorf("moved = %+v, want a permanent redirect to /ok", got)
+	}
+	if got := results[1]; got.URLID != "temporary" || got.FinalURL != server.URL+"/ok" || got.Permanent {
+		t.Errorf("temporary = %+v, want a temporary redirect to /ok", got)
+	}
+}
+
+func TestResolveRedirectsCancelled(t *testing.T) {
+	server := newLinkCheckServer(t)
+	checker := newLinkChecker(LinkCheckSettings{TimeoutSeconds: 30, PerHostLimit: 4}, server.Client())
+
+	var urls []URLItem
+	for i := 0; i < 20; i++ {
+		urls = append(urls, URLItem{ID: string(rune('a' + i)), URL: server.URL + "/slow"})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	results := resolveRedirects(ctx, checker, urls, 2)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled run took %v", elapsed)
+	}
+	if len(results) != 0 {
+		t.Errorf("results = %+v, want none", results)
+	}
+}
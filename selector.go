@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// cssSelector is a parsed CSS selector list. It supports type, universal,
+// id, class and attribute selectors combined with descendant (" ") and
+// child (">") combinators, which covers what page watches need.
+type cssSelector []complexSelector
+
+// complexSelector is a chain of compound selectors, rightmost last
+type complexSelector struct {
+	parts       []compoundSelector
+	combinators []byte // combinators[i] joins parts[i] and parts[i+1]
+}
+
+// compoundSelector matches a single element
+type compoundSelector struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrSelector
+}
+
+// attrSelector matches an attribute, e.g. [data-state^=open]
+type attrSelector struct {
+	name  string
+	op    string // "", "=", "~=", "^=", "$=", "*="
+	value string
+}
+
+// parseSelector parses a comma separated list of selectors
+func parseSelector(source string) (cssSelector, error) {
+	var list cssSelector
+	for _, group := range splitSelectorList(source) {
+		complex, err := parseComplexSelector(group)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, complex)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("empty selector")
+	}
+	return list, nil
+}
+
+// splitSelectorList splits on commas outside attribute brackets
+func splitSelectorList(source string) []string {
+	var groups []string
+	start := 0
+	for i := 0; i < len(source); i++ {
+		switch source[i] {
+		case '[':
+			if end := attrSelectorEnd(source[i:]); end > 0 {
+				i += end
+			}
+		case ',':
+			groups = append(groups, source[start:i])
+			start = i + 1
+		}
+	}
+	groups = append(groups, source[start:])
+
+	var result []string
+	for _, group := range groups {
+		if group = strings.TrimSpace(group); group != "" {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// parseComplexSelector parses compound selectors joined by combinators
+func parseComplexSelector(source string) (complexSelector, error) {
+	var complex complexSelector
+	pending := byte(0)
+
+	for i := 0; i < len(source); {
+		switch c := source[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			if len(complex.parts) > 0 && pending == 0 {
+				pending = ' '
+			}
+			i++
+		case c == '>':
+			if len(complex.parts) == 0 {
+				return complex, fmt.Errorf("selector %q starts with a combinator", source)
+			}
+			pending = '>'
+			i++
+		case c == '+' || c == '~':
+			return complex, fmt.Errorf("sibling combinators are not supported in %q", source)
+		default:
+			compound, n, err := parseCompoundSelector(source[i:])
+			if err != nil {
+				return complex, err
+			}
+			if len(complex.parts) > 0 {
+				complex.combinators = append(complex.combinators, pending)
+			}
+			complex.parts = append(complex.parts, compound)
+			pending = 0
+			i += n
+		}
+	}
+
+	if len(complex.parts) == 0 || pending == '>' {
+		return complex, fmt.Errorf("invalid selector %q", source)
+	}
+	return complex, nil
+}
+
+// parseCompoundSelector parses one compound selector and returns the
+// number of bytes consumed
+func parseCompoundSelector(source string) (compoundSelector, int, error) {
+	var compound compoundSelector
+	i := 0
+
+	if i < len(source) && source[i] == '*' {
+		i++
+	} else if name := readIdent(source[i:]); name != "" {
+		compound.tag = strings.ToLower(name)
+		i += len(name)
+	}
+
+	for i < len(source) {
+		switch source[i] {
+		case '#':
+			name := readIdent(source[i+1:])
+			if name == "" {
+				return compound, 0, fmt.Errorf("missing id in %q", source)
+			}
+			compound.id = name
+			i += 1 + len(name)
+		case '.':
+			name := readIdent(source[i+1:])
+			if name == "" {
+				return compound, 0, fmt.Errorf("missing class in %q", source)
+			}
+			compound.classes = append(compound.classes, name)
+			i += 1 + len(name)
+		case '[':
+			end := attrSelectorEnd(source[i:])
+			if end < 0 {
+				return compound, 0, fmt.Errorf("unterminated attribute selector in %q", source)
+			}
+			attr, err := parseAttrSelector(source[i+1 : i+end])
+			if err != nil {
+				return compound, 0, err
+			}
+			compound.attrs = append(compound.attrs, attr)
+			i += end + 1
+		case ':':
+			return compound, 0, fmt.Errorf("pseudo-classes are not supported in %q", source)
+		default:
+			if i == 0 {
+				return compound, 0, fmt.Errorf("unexpected %q in selector", source[i])
+			}
+			return compound, i, nil
+		}
+	}
+	return compound, i, nil
+}
+
+// attrSelectorEnd returns the index of the "]" closing the attribute
+// selector at the start of source, skipping quoted values, or -1
+func attrSelectorEnd(source string) int {
+	quote := byte(0)
+	for i := 1; i < len(source); i++ {
+		switch c := source[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseAttrSelector parses the inside of [name op value]. The operator
+// must directly follow the attribute name, so operator characters inside
+// the value are kept as they are.
+func parseAttrSelector(source string) (attrSelector, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return attrSelector{}, fmt.Errorf("empty attribute selector")
+	}
+	name := readIdent(source)
+	if name == "" {
+		return attrSelector{}, fmt.Errorf("invalid attribute name in [%s]", source)
+	}
+	attr := attrSelector{name: strings.ToLower(name)}
+
+	rest := strings.TrimSpace(source[len(name):])
+	if rest == "" {
+		return attr, nil
+	}
+	for _, op := range []string{"~=", "^=", "$=", "*=", "="} {
+		if value, ok := strings.CutPrefix(rest, op); ok {
+			value, err := unquoteAttrValue(strings.TrimSpace(value))
+			if err != nil {
+				return attrSelector{}, fmt.Errorf("%v in [%s]", err, source)
+			}
+			attr.op, attr.value = op, value
+			return attr, nil
+		}
+	}
+	return attrSelector{}, fmt.Errorf("unsupported attribute operator in [%s]", source)
+}
+
+// unquoteAttrValue removes the quotes around an attribute value
+func unquoteAttrValue(value string) (string, error) {
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		if strings.ContainsAny(value, " \t\"'") {
+			return "", fmt.Errorf("unquoted value %q", value)
+		}
+		return value, nil
+	}
+	if len(value) < 2 || value[len(value)-1] != value[0] {
+		return "", fmt.Errorf("unterminated string %s", value)
+	}
+	return value[1 : len(value)-1], nil
+}
+
+// readIdent reads a CSS identifier
+func readIdent(source string) string {
+	end := 0
+	for end < len(source) {
+		c := source[end]
+		if c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80 {
+			end++
+			continue
+		}
+		break
+	}
+	return source[:end]
+}
+
+// matchAll returns the elements matching any selector of the list in
+// document order
+func (s cssSelector) matchAll(root *html.Node) []*html.Node {
+	var matches []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for _, complex := range s {
+				if complex.matches(n) {
+					matches = append(matches, n)
+					break
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return matches
+}
+
+// matches checks the chain from right to left
+func (c complexSelector) matches(n *html.Node) bool {
+	return c.matchFrom(n, len(c.parts)-1)
+}
+
+// matchFrom reports whether n matches parts[index] and its ancestors
+// match the parts to the left
+func (c complexSelector) matchFrom(n *html.Node, index int) bool {
+	if !c.parts[index].matches(n) {
+		return false
+	}
+	if index == 0 {
+		return true
+	}
+
+	if c.combinators[index-1] == '>' {
+		parent := n.Parent
+		return parent != nil && parent.Type == html.ElementNode && c.matchFrom(parent, index-1)
+	}
+	for ancestor := n.Parent; ancestor != nil && ancestor.Type == html.ElementNode; ancestor = ancestor.Parent {
+		if c.matchFrom(ancestor, index-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether an element matches the compound selector
+func (s compoundSelector) matches(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if s.tag != "" && n.Data != s.tag {
+		return false
+	}
+	if s.id != "" && getAttr(n, "id") != s.id {
+		return false
+	}
+	if len(s.classes) > 0 {
+		classes := strings.Fields(getAttr(n, "class"))
+		for _, class := range s.classes {
+			if indexOf(classes, class) < 0 {
+				return false
+			}
+		}
+	}
+	for _, attr := range s.attrs {
+		if !attr.matches(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether an element has a matching attribute
+func (s attrSelector) matches(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		if strings.ToLower(attr.Key) != s.name {
+			continue
+		}
+		switch s.op {
+		case "":
+			return true
+		case "=":
+			return attr.Val == s.value
+		case "~=":
+			return indexOf(strings.Fields(attr.Val), s.value) >= 0
+		case "^=":
+			return s.value != "" && strings.HasPrefix(attr.Val, s.value)
+		case "$=":
+			return s.value != "" && strings.HasSuffix(attr.Val, s.value)
+		case "*=":
+			return s.value != "" && strings.Contains(attr.Val, s.value)
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseAttrSelector(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    attrSelector
+		wantErr bool
+	}{
+		{source: "href", want: attrSelector{name: "href"}},
+		{source: " Data-State ", want: attrSelector{name: "data-state"}},
+		{source: `href*="a=b"`, want: attrSelector{name: "href", op: "*=", value: "a=b"}},
+		{source: `href="a^=b"`, want: attrSelector{name: "href", op: "=", value: "a^=b"}},
+		{source: `title ~= 'x $= y'`, want: attrSelector{name: "title", op: "~=", value: "x $= y"}},
+		{source: "data-state^=open", want: attrSelector{name: "data-state", op: "^=", value: "open"}},
+		{source: `lang|="en"`, wantErr: true},
+		{source: "", wantErr: true},
+		{source: `="x"`, wantErr: true},
+		{source: `href="open`, wantErr: true},
+		{source: "href=a b", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			got, err := parseAttrSelector(test.source)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("parsed as %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSelectorMatchAll(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<div id="main">
+		<a href="/search?a=b" title="x]y">query</a>
+		<a href="/plain">plain</a>
+		<p class="note, item">note</p>
+	</div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{selector: `a[href*="a=b"]`, want: []string{"query"}},
+		{selector: `a[title="x]y"]`, want: []string{"query"}},
+		{selector: `a[href^="/p"], a[title="x]y"]`, want: []string{"query", "plain"}},
+		{selector: `#main > a[href]`, want: []string{"query", "plain"}},
+		{selector: `a[href="a=b"]`, want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.selector, func(t *testing.T) {
+			selector, err := parseSelector(test.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, n := range selector.matchAll(doc) {
+				got = append(got, n.FirstChild.Data)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("matched %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// semVersion is a parsed Semantic Versioning 2.0 version
+type semVersion struct {
+	Major, Minor, Patch uint64
+	Prerelease          []string // Dot separated identifiers after "-"
+}
+
+// parseSemVer parses versions such as "v1.5.0-beta.2+build.7". A missing
+// minor or patch number counts as zero; build metadata is ignored. Numbers
+// with leading zeros are rejected as the specification requires, so that
+// "01.2.3" falls back to plain string comparison instead of silently
+// equalling "1.2.3".
+func parseSemVer(version string) (semVersion, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	version, _, _ = strings.Cut(version, "+")
+	core, prerelease, hasPrerelease := strings.Cut(version, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return semVersion{}, false
+	}
+	var numbers [3]uint64
+	for i, part := range parts {
+		if !isNumericIdentifier(part) || hasLeadingZero(part) {
+			return semVersion{}, false
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return semVersion{}, false
+		}
+		numbers[i] = n
+	}
+
+	parsed := semVersion{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}
+	if hasPrerelease {
+		parsed.Prerelease = strings.Split(prerelease, ".")
+		for _, identifier := range parsed.Prerelease {
+			if identifier == "" || isNumericIdentifier(identifier) && hasLeadingZero(identifier) {
+				return semVersion{}, false
+			}
+		}
+	}
+	return parsed, true
+}
+
+// compareSemVer orders two versions by SemVer precedence: -1 if a < b,
+// 0 if equal, 1 if a > b
+func compareSemVer(a, b semVersion) int {
+	for _, pair := range [][2]uint64{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// A prerelease has lower precedence than the release itself
+	switch {
+	case len(a.Prerelease) == 0 && len(b.Prerelease) == 0:
+		return 0
+	case len(a.Prerelease) == 0:
+		return 1
+	case len(b.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.Prerelease) && i < len(b.Prerelease); i++ {
+		if c := comparePrereleaseIdentifier(a.Prerelease[i], b.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a.Prerelease) < len(b.Prerelease):
+		return -1
+	case len(a.Prerelease) > len(b.Prerelease):
+		return 1
+	}
+	return 0
+}
+
+// comparePrereleaseIdentifier compares numeric identifiers numerically
+// and others in ASCII order; numeric ones sort first
+func comparePrereleaseIdentifier(a, b string) int {
+	aNumeric, bNumeric := isNumericIdentifier(a), isNumericIdentifier(b)
+	switch {
+	case aNumeric && bNumeric:
+		// Without leading zeros, a longer number is a larger one
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// isNumericIdentifier reports whether s consists of digits only
+func isNumericIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hasLeadingZero reports whether a numeric identifier has a superfluous
+// leading zero
+func hasLeadingZero(s string) bool {
+	return len(s) > 1 && s[0] == '0'
+}
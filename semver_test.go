@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSemVer(t *testing.T) {
+	tests := []struct {
+		version string
+		want    semVersion
+		wantOK  bool
+	}{
+		{version: "1.2.3", want: semVersion{Major: 1, Minor: 2, Patch: 3}, wantOK: true},
+		{version: " v1.5.0-beta.2+build.7 ", want: semVersion{Major: 1, Minor: 5, Prerelease: []string{"beta", "2"}}, wantOK: true},
+		{version: "v2", want: semVersion{Major: 2}, wantOK: true},
+		{version: "1.4", want: semVersion{Major: 1, Minor: 4}, wantOK: true},
+		{version: "0.0.0", want: semVersion{}, wantOK: true},
+		{version: "1.0.0-0.3.7", want: semVersion{Major: 1, Prerelease: []string{"0", "3", "7"}}, wantOK: true},
+		{version: "1.0.0-x-y.01a", want: semVersion{Major: 1, Prerelease: []string{"x-y", "01a"}}, wantOK: true},
+		{version: "01.2.3"},
+		{version: "1.02.3"},
+		{version: "1.0.0-beta.01"},
+		{version: "1.2.3.4"},
+		{version: "1.2.x"},
+		{version: "1.2.3-"},
+		{version: "1.2.3-beta..1"},
+		{version: "-1.2.3"},
+		{version: ""},
+		{version: "nightly"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			got, ok := parseSemVer(test.version)
+			if ok != test.wantOK || (ok && !reflect.DeepEqual(got, test.want)) {
+				t.Errorf("parseSemVer(%q) = %+v, %v, want %+v, %v", test.version, got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestCompareSemVerPrecedence(t *testing.T) {
+	// The precedence example of the SemVer 2.0 specification, lowest first
+	chain := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0",
+		"1.0.1", "1.1.0-alpha", "1.1.0", "1.10.0", "2.0.0",
+	}
+
+	for i, lower := range chain {
+		for _, higher := range chain[i+1:] {
+			if c := compareVersions(lower, higher); c != -1 {
+				t.Errorf("compareVersions(%q, %q) = %d, want -1", lower, higher, c)
+			}
+			if c := compareVersions(higher, lower); c != 1 {
+				t.Errorf("compareVersions(%q, %q) = %d, want 1", higher, lower, c)
+			}
+		}
+		if c := compareVersions(lower, lower); c != 0 {
+			t.Errorf("compareVersions(%q, %q) = %d, want 0", lower, lower, c)
+		}
+	}
+}
+
+func TestCompareVersionsIgnoresBuildMetadata(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0.0+build.1", b: "1.0.0+build.2", want: 0},
+		{a: "v1.0.0", b: "1.0.0+20240101", want: 0},
+		{a: "1.0.0-rc.1+a", b: "1.0.0-rc.1+b", want: 0},
+		{a: "1.0.0-rc.1+zzz", b: "1.0.0", want: -1},
+		{a: "1.0", b: "1.0.0", want: 0},
+		{a: "1.0.0-beta.99999999999999999999", b: "1.0.0-beta.100000000000000000000", want: -1},
+		// Invalid versions rank below valid ones
+		{a: "01.0.0", b: "0.1.0", want: -1},
+	}
+
+	for _, test := range tests {
+		if got := compareVersions(test.a, test.b); got != test.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := sameVersion(test.a, test.b); got != (test.want == 0) {
+			t.Errorf("sameVersion(%q, %q) = %v, want %v", test.a, test.b, got, test.want == 0)
+		}
+	}
+}
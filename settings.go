@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// LinkCheckSettings configures the dead-link checker
+type LinkCheckSettings struct {
+	Enabled        bool `json:"enabled"`        // Run background checks
+	IntervalHours  int  `json:"intervalHours"`  // Recheck links older than this
+	Concurrency    int  `json:"concurrency"`    // Links checked in parallel
+	PerHostLimit   int  `json:"perHostLimit"`   // Parallel requests per host
+	PerHostDelayMs int  `json:"perHostDelayMs"` // Minimum delay between requests to one host
+	TimeoutSeconds int  `json:"timeoutSeconds"` // Per request timeout
+	HistoryLength  int  `json:"historyLength"`  // Check results kept per bookmark
+}
+
+// Limits applied to LinkCheckSettings when they are saved or loaded
+const (
+	linkCheckMaxConcurrency = 64
+	linkCheckMaxTimeout     = 300
+	linkCheckMaxHistory     = 100
+)
+
+// normalized returns the settings with values out of range replaced by
+// the default or clamped to the nearest limit
+func (s LinkCheckSettings) normalized() LinkCheckSettings {
+	defaults := defaultSettings().LinkCheck
+	if s.IntervalHours <= 0 {
+		s.IntervalHours = defaults.IntervalHours
+	}
+	if s.Concurrency <= 0 {
+		s.Concurrency = defaults.Concurrency
+	}
+	s.Concurrency = min(s.Concurrency, linkCheckMaxConcurrency)
+	if s.PerHostLimit <= 0 {
+		s.PerHostLimit = defaults.PerHostLimit
+	}
+	s.PerHostDelayMs = max(s.PerHostDelayMs, 0)
+	if s.TimeoutSeconds <= 0 {
+		s.TimeoutSeconds = defaults.TimeoutSeconds
+	}
+	s.TimeoutSeconds = min(s.TimeoutSeconds, linkCheckMaxTimeout)
+	s.HistoryLength = min(max(s.HistoryLength, 0), linkCheckMaxHistory)
+	return s
+}
+
+// Settings holds user preferences persisted in settings.json
+type Settings struct {
+	LinkCheck LinkCheckSettings `json:"linkCheck"`
+	Network   NetworkSettings   `json:"network"`
+	Update    UpdateSettings    `json:"update"`
+}
+
+// settingsMutex serializes reads and writes of settings.json
+var settingsMutex sync.Mutex
+
+// defaultSettings returns the settings used when none are saved
+func defaultSettings() Settings {
+	return Settings{
+		LinkCheck: LinkCheckSettings{
+			Enabled:        false,
+			IntervalHours:  24 * 7,
+			Concurrency:    8,
+			PerHostLimit:   2,
+			PerHostDelayMs: 500,
+			TimeoutSeconds: 15,
+			HistoryLength:  10,
+		},
+		Network: defaultNetworkSettings(),
+		Update:  defaultUpdateSettings(),
+	}
+}
+
+// GetSettings returns the saved settings merged over the defaults
+func (a *App) GetSettings() (Settings, error) {
+	settingsMutex.Lock()
+	defer settingsMutex.Unlock()
+	return a.loadSettings()
+}
+
+// SaveSettings saves the settings to settings.json
+func (a *App) SaveSettings(settings Settings) error {
+	settingsMutex.Lock()
+	defer settingsMutex.Unlock()
+	return a.writeSettings(settings)
+}
+
+// modifySettings applies fn to the saved settings and writes the result
+func (a *App) modifySettings(fn func(*Settings) error) error {
+	settingsMutex.Lock()
+	defer settingsMutex.Unlock()
+
+	settings, err := a.loadSettings()
+	if err != nil {
+		return err
+	}
+	if err := fn(&settings); err != nil {
+		return err
+	}
+	return a.writeSettings(settings)
+}
+
+// loadSettings reads settings.json; callers must hold settingsMutex
+func (a *App) loadSettings() (Settings, error) {
+	settings := defaultSettings()
+
+	data, err := os.ReadFile(filepath.Join(a.GetDataDir(), "settings.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return settings, nil
+		}
+		return settings, err
+	}
+
+	// Fields missing from the file keep their default values
+	err = json.Unmarshal(data, &settings)
+	settings.LinkCheck = settings.LinkCheck.normalized()
+	return settings, err
+}
+
+// writeSettings writes settings.json; callers must hold settingsMutex
+func (a *App) writeSettings(settings Settings) error {
+	if err := a.EnsureDataDir(); err != nil {
+		return err
+	}
+	settings.LinkCheck = settings.LinkCheck.normalized()
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(a.GetDataDir(), "settings.json"), data, 0644)
+}
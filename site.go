@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Site export formats
+const (
+	siteFormatHTML = "html"
+	siteFormatOPML = "opml"
+)
+
+// Output file names of a site export
+const (
+	siteHTMLFile = "index.html"
+	siteOPMLFile = "bookmarks.opml"
+)
+
+// SiteExportOptions selects the bookmarks and formats of a site export
+type SiteExportOptions struct {
+	Title    string                 `json:"title"`
+	Category string                 `json:"category"` // Only export this category when set
+	Filter   *AdvancedSearchOptions `json:"filter"`   // Optional saved filter applied before export
+	Formats  []string               `json:"formats"`  // "html" and/or "opml", both when empty
+	Theme    string                 `json:"theme"`    // "light", "dark" or "auto" (default)
+}
+
+// SiteExportResult lists the files written by a site export
+type SiteExportResult struct {
+	Files         []string `json:"files"`
+	BookmarkCount int      `json:"bookmarkCount"`
+}
+
+// hexColorRegex matches the category colours that may be embedded in CSS
+var hexColorRegex = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ExportSite renders bookmarks to a static HTML page and/or OPML file in
+// outDir. The output only depends on the stored bookmarks and the options,
+// so exporting the same data twice produces identical files.
+func (a *App) ExportSite(options SiteExportOptions, outDir string) (*SiteExportResult, error) {
+	if outDir == "" {
+		return nil, fmt.Errorf("output directory is required")
+	}
+
+	urls, err := a.GetURLs()
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := a.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []URLItem
+	for _, url := range urls {
+		if options.Category != "" && url.Category != options.Category {
+			continue
+		}
+		if options.Filter != nil && !a.matchesAdvancedCriteria(url, *options.Filter) {
+			continue
+		}
+		selected = append(selected, url)
+	}
+
+	formats := options.Formats
+	if len(formats) == 0 {
+		formats = []string{siteFormatHTML, siteFormatOPML}
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+
+	groups := groupURLsByCategory(selected, categories, false)
+	title := options.Title
+	if title == "" {
+		title = "URL Navigator"
+		if options.Category != "" {
+			title = options.Category
+		}
+	}
+
+	result := &SiteExportResult{BookmarkCount: len(selected)}
+	for _, format := range formats {
+		var data []byte
+		var name string
+
+		switch format {
+		case siteFormatHTML:
+			data, err = renderSiteHTML(title, options.Theme, groups)
+			name = siteHTMLFile
+		case siteFormatOPML:
+			data, err = renderSiteOPML(title, groups)
+			name = siteOPMLFile
+		default:
+			return nil, fmt.Errorf("unsupported export format: %s", format)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(outDir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, err
+		}
+		result.Files = append(result.Files, path)
+	}
+
+	return result, nil
+}
+
+// latestUpdate returns the newest UpdatedAt of the grouped bookmarks. It is
+// used instead of the current time to keep exports reproducible.
+func latestUpdate(groups []categoryGroup) time.Time {
+	var latest time.Time
+	for _, group := range groups {
+		for _, url := range group.URLs {
+			if url.UpdatedAt.After(latest) {
+				latest = url.UpdatedAt
+			}
+		}
+	}
+	return latest.UTC()
+}
+
+// siteCategory is a category as rendered by the HTML template
+type siteCategory struct {
+	Name        string
+	Description string
+	Color       template.CSS
+	Bookmarks   []siteBookmark
+}
+
+// siteBookmark is a bookmark as rendered by the HTML template
+type siteBookmark struct {
+	Title       string
+	URL         string
+	Host        string
+	Description string
+	Tags        []string
+	Search      string
+}
+
+// siteTemplate renders a self-contained page with inline styles and a
+// client-side search box
+var siteTemplate = template.Must(template.New("site").Parse(`<!DOCTYPE html>
+<html lang="zh-CN" data-theme="{{.Theme}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="URL Navigator">
+<title>{{.Title}}</title>
+<style>
+:root { --bg: #f8fafc; --card: #ffffff; --text: #0f172a; --muted: #64748b; --border: #e2e8f0; }
+[data-theme="dark"] { --bg: #0f172a; --card: #1e293b; --text: #f1f5f9; --muted: #94a3b8; --border: #334155; }
+@media (prefers-color-scheme: dark) {
+  [data-theme="auto"] { --bg: #0f172a; --card: #1e293b; --text: #f1f5f9; --muted: #94a3b8; --border: #334155; }
+}
+* { box-sizing: border-box; }
+body { margin: 0; font-family: -apple-system, "Segoe UI", "Microsoft YaHei", sans-serif; background: var(--bg); color: var(--text); }
+header { padding: 24px; border-bottom: 1px solid var(--border); }
+h1 { margin: 0 0 12px; font-size: 24px; }
+#search { width: 100%; max-width: 480px; padding: 8px 12px; border: 1px solid var(--border); border-radius: 6px; background: var(--card); color: var(--text); }
+main { padding: 24px; }
+section { margin-bottom: 32px; }
+h2 { font-size: 18px; margin: 0 0 4px; padding-left: 10px; border-left: 4px solid var(--cat-color, #6b7280); }
+.desc { color: var(--muted); font-size: 13px; margin: 0 0 12px 14px; }
+.grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(260px, 1fr)); gap: 12px; }
+.card { display: block; padding: 12px; background: var(--card); border: 1px solid var(--border); border-top: 3px solid var(--cat-color, #6b7280); border-radius: 8px; color: inherit; text-decoration: none; }
+.card:hover { border-color: var(--cat-color, #6b7280); }
+.title { font-weight: 600; overflow-wrap: anywhere; }
+.host { color: var(--muted); font-size: 12px; }
+.note { font-size: 13px; margin-top: 6px; color: var(--muted); }
+.tag { display: inline-block; font-size: 11px; padding: 1px 6px; margin: 6px 4px 0 0; border-radius: 999px; border: 1px solid var(--border); }
+.hidden { display: none; }
+footer { padding: 24px; color: var(--muted); font-size: 12px; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<input id="search" type="search" placeholder="搜索 {{.Count}} 个书签..." autocomplete="off">
+</header>
+<main>
+{{- range .Categories}}
+<section style="--cat-color: {{.Color}}">
+<h2>{{.Name}}</h2>
+{{- if .Description}}
+<p class="desc">{{.Description}}</p>
+{{- end}}
+<div class="grid">
+{{- range .Bookmarks}}
+<a class="card" href="{{.URL}}" target="_blank" rel="noopener noreferrer" data-search="{{.Search}}">
+<div class="title">{{.Title}}</div>
+<div class="host">{{.Host}}</div>
+{{- if .Description}}
+<div class="note">{{.Description}}</div>
+{{- end}}
+{{- range .Tags}}
+<span class="tag">{{.}}</span>
+{{- end}}
+</a>
+{{- end}}
+</div>
+</section>
+{{- end}}
+</main>
+<footer>{{if .Updated}}更新于 {{.Updated}} · {{end}}由 URL Navigator 生成</footer>
+<script>
+(function () {
+  var input = document.getElementById("search");
+  input.addEventListener("input", function () {
+    var terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    document.querySelectorAll("section").forEach(function (section) {
+      var visible = 0;
+      section.querySelectorAll(".card").forEach(function (card) {
+        var text = card.getAttribute("data-search");
+        var match = terms.every(function (term) { return text.indexOf(term) !== -1; });
+        card.classList.toggle("hidden", !match);
+        if (match) { visible++; }
+      });
+      section.classList.toggle("hidden", visible === 0);
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
+
+// renderSiteHTML renders grouped bookmarks to a static HTML page
+func renderSiteHTML(title, theme string, groups []categoryGroup) ([]byte, error) {
+	if theme != "light" && theme != "dark" {
+		theme = "auto"
+	}
+
+	count := 0
+	var categories []siteCategory
+	for _, group := range groups {
+		color := group.Category.Color
+		if !hexColorRegex.MatchString(color) {
+			color = importFolderCategoryHex
+		}
+
+		name := group.Name
+		if name == "" {
+			name = "未分类"
+		}
+
+		category := siteCategory{
+			Name:        name,
+			Description: group.Category.Description,
+			Color:       template.CSS(color),
+		}
+
+		for _, url := range group.URLs {
+			title := url.Title
+			if title == "" {
+				title = url.URL
+			}
+			search := strings.ToLower(strings.Join(append([]string{title, url.URL, url.Description}, url.Tags...), " "))
+
+			category.Bookmarks = append(category.Bookmarks, siteBookmark{
+				Title:       title,
+				URL:         url.URL,
+				Host:        urlHost(url.URL),
+				Description: url.Description,
+				Tags:        url.Tags,
+				Search:      search,
+			})
+			count++
+		}
+
+		categories = append(categories, category)
+	}
+
+	updated := ""
+	if latest := latestUpdate(groups); !latest.IsZero() {
+		updated = latest.Format("2006-01-02")
+	}
+
+	var buf bytes.Buffer
+	err := siteTemplate.Execute(&buf, map[string]interface{}{
+		"Title":      title,
+		"Theme":      theme,
+		"Count":      count,
+		"Categories": categories,
+		"Updated":    updated,
+	})
+	return buf.Bytes(), err
+}
+
+// urlHost returns the host part of a URL for display
+func urlHost(rawURL string) string {
+	host := rawURL
+	if index := strings.Index(host, "://"); index >= 0 {
+		host = host[index+3:]
+	}
+	if index := strings.IndexAny(host, "/?#"); index >= 0 {
+		host = host[:index]
+	}
+	return host
+}
+
+// opmlDocument is an OPML 2.0 document
+type opmlDocument struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Head    opmlHead      `xml:"head"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+// opmlHead is the head element of an OPML document
+type opmlHead struct {
+	Title        string `xml:"title"`
+	DateModified string `xml:"dateModified,omitempty"`
+}
+
+// opmlOutline is a category or bookmark outline element
+type opmlOutline struct {
+	Text        string        `xml:"text,attr"`
+	Title       string        `xml:"title,attr,omitempty"`
+	Type        string        `xml:"type,attr,omitempty"`
+	URL         string        `xml:"url,attr,omitempty"`
+	Description string        `xml:"description,attr,omitempty"`
+	Category    string        `xml:"category,attr,omitempty"`
+	Created     string        `xml:"created,attr,omitempty"`
+	Outlines    []opmlOutline `xml:"outline"`
+}
+
+// renderSiteOPML renders grouped bookmarks to an OPML 2.0 document with
+// one outline per category holding link outlines
+func renderSiteOPML(title string, groups []categoryGroup) ([]byte, error) {
+	doc := opmlDocument{
+		Version: "2.0",
+		Head:    opmlHead{Title: title},
+	}
+	if latest := latestUpdate(groups); !latest.IsZero() {
+		doc.Head.DateModified = latest.Format(time.RFC1123Z)
+	}
+
+	for _, group := range groups {
+		name := group.Name
+		if name == "" {
+			name = "未分类"
+		}
+
+		folder := opmlOutline{Text: name, Title: name}
+		for _, url := range group.URLs {
+			text := url.Title
+			if text == "" {
+				text = url.URL
+			}
+			link := opmlOutline{
+				Text:        text,
+				Type:        "link",
+				URL:         url.URL,
+				Description: url.Description,
+				Category:    strings.Join(url.Tags, ","),
+			}
+			if !url.CreatedAt.IsZero() {
+				link.Created = url.CreatedAt.UTC().Format(time.RFC1123Z)
+			}
+			folder.Outlines = append(folder.Outlines, link)
+		}
+		doc.Body = append(doc.Body, folder)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// runExportSiteCommand implements the "export-site" command line mode and
+// returns the process exit code
+func runExportSiteCommand(args []string) int {
+	flags := flag.NewFlagSet("export-site", flag.ContinueOnError)
+	outDir := flags.String("out", "site", "output directory")
+	title := flags.String("title", "", "page title")
+	category := flags.String("category", "", "only export this category")
+	query := flags.String("query", "", "only export bookmarks matching this search query")
+	tags := flags.String("tags", "", "only export bookmarks with one of these comma separated tags")
+	formats := flags.String("format", "html,opml", "comma separated formats: html, opml")
+	theme := flags.String("theme", "auto", "page theme: light, dark or auto")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	options := SiteExportOptions{
+		Title:    *title,
+		Category: *category,
+		Formats:  splitTags(*formats, ","),
+		Theme:    *theme,
+	}
+	if *query != "" || *tags != "" {
+		options.Filter = &AdvancedSearchOptions{
+			Query: *query,
+			Tags:  splitTags(*tags, ","),
+		}
+	}
+
+	result, err := NewApp().ExportSite(options, *outDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "导出失败: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("已导出 %d 个书签:\n", result.BookmarkCount)
+	for _, file := range result.Files {
+		fmt.Println("  " + file)
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportSiteIsReproducible(t *testing.T) {
+	setTestHome(t)
+	a := NewApp()
+
+	created := time.Date(2024, 3, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	newest := time.Date(2024, 5, 2, 9, 30, 0, 0, time.UTC)
+	urls := []URLItem{
+		{ID: "1", Title: "Go & <Rust>", URL: "https://go.dev/?a=1&b=2", Category: "Dev", Tags: []string{"lang", "go"}, Order: 1, CreatedAt: created, UpdatedAt: created},
+		{ID: "2", Title: "", URL: "https://example.org/", Description: `say "hi"`, Category: "Dev", Order: 0, CreatedAt: created, UpdatedAt: newest},
+		{ID: "3", Title: "Loose", URL: "https://loose.example/", Category: "Unlisted", Order: 0, UpdatedAt: created},
+	}
+	if err := a.SaveURLs(urls); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SaveCategories([]Category{{ID: "c1", Name: "Dev", Color: "#336699"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	export := func() map[string][]byte {
+		dir := t.TempDir()
+		result, err := a.ExportSite(SiteExportOptions{Title: "Links"}, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Files) != 2 || result.BookmarkCount != 3 {
+			t.Fatalf("result = %+v, want two files with 3 bookmarks", result)
+		}
+		files := make(map[string][]byte)
+		for _, name := range []string{siteHTMLFile, siteOPMLFile} {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[name] = data
+		}
+		return files
+	}
+
+	first := export()
+	time.Sleep(10 * time.Millisecond)
+	second := export()
+	for name, data := range first {
+		if !bytes.Equal(data, second[name]) {
+			t.Errorf("%s differs between two exports of the same data", name)
+		}
+	}
+
+	var doc opmlDocument
+	if err := xml.Unmarshal(first[siteOPMLFile], &doc); err != nil {
+		t.Fatalf("invalid OPML: %v", err)
+	}
+	if doc.Version != "2.0" || doc.Head.Title != "Links" {
+		t.Errorf("head = %q %+v, want version 2.0 titled Links", doc.Version, doc.Head)
+	}
+	if modified, err := time.Parse(time.RFC1123Z, doc.Head.DateModified); err != nil || !modified.Equal(newest) {
+		t.Errorf("dateModified = %q, want the newest bookmark update %v", doc.Head.DateModified, newest)
+	}
+	if len(doc.Body) != 2 || doc.Body[0].Text != "Dev" || doc.Body[1].Text != "Unlisted" {
+		t.Fatalf("body = %+v, want Dev then Unlisted", doc.Body)
+	}
+
+	dev := doc.Body[0].Outlines
+	want := []opmlOutline{
+		{Text: "https://example.org/", Type: "link", URL: "https://example.org/", Description: `say "hi"`, Created: created.UTC().Format(time.RFC1123Z)},
+		{Text: "Go & <Rust>", Type: "link", URL: "https://go.dev/?a=1&b=2", Category: "lang,go", Created: created.UTC().Format(time.RFC1123Z)},
+	}
+	if !reflect.DeepEqual(dev, want) {
+		t.Errorf("Dev outlines = %+v\nwant %+v", dev, want)
+	}
+}
@@ -0,0 +1,612 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// snapshotTimeout is the time allowed for archiving one page with its resources
+const snapshotTimeout = 2 * time.Minute
+
+// snapshotMaxResource limits the size of a single inlined resource
+const snapshotMaxResource = 5 << 20
+
+// snapshotMaxTotal limits the size of all inlined resources of a page
+const snapshotMaxTotal = 30 << 20
+
+// snapshotCSP keeps archived pages from running scripts or loading
+// anything from the network when they are viewed inside the app. The
+// sandbox gives them an opaque origin and keeps them from navigating the
+// app window.
+const snapshotCSP = "default-src 'none'; img-src data:; style-src 'unsafe-inline' data:; font-src data:; media-src data:; frame-src 'none'; form-action 'none'; base-uri 'none'; sandbox"
+
+// Snapshot is an archived copy of a bookmarked page
+type Snapshot struct {
+	ID        string    `json:"id"`
+	URLID     string    `json:"urlId"`
+	URL       string    `json:"url"` // Final URL after redirects
+	Title     string    `json:"title"`
+	Size      int64     `json:"size"`
+	Resources int       `json:"resources"` // Inlined stylesheets, images and fonts
+	Failed    int       `json:"failed"`    // Resources that could not be downloaded
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// cssURLRegex matches url(...) references in stylesheets
+var cssURLRegex = regexp.MustCompile(`url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"\s]*))\s*\)`)
+
+// cssImportRegex matches @import rules that do not use url(...)
+var cssImportRegex = regexp.MustCompile(`@import\s+(?:"([^"]*)"|'([^']*)')`)
+
+// snapshotMutex serializes changes to the snapshot indexes
+var snapshotMutex sync.Mutex
+
+// snapshotDir returns the directory holding the snapshots of a bookmark
+func (a *App) snapshotDir(urlID string) string {
+	return filepath.Join(a.GetDataDir(), "snapshots", filepath.Base(urlID))
+}
+
+// CreateSnapshot saves a self-contained copy of a bookmarked page with its
+// stylesheets, images and fonts inlined
+func (a *App) CreateSnapshot(urlID string) (*Snapshot, error) {
+	item, err := a.findURL(urlID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := a.httpClient(0)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+
+	archiver := &pageArchiver{client: client, cache: make(map[string]string)}
+	page, finalURL, err := archiver.archive(ctx, item.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := Snapshot{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		URLID:     urlID,
+		URL:       finalURL,
+		Title:     archiver.title,
+		Size:      int64(len(page)),
+		Resources: archiver.inlined,
+		Failed:    archiver.failed,
+		CreatedAt: time.Now(),
+	}
+	if snapshot.Title == "" {
+		snapshot.Title = item.Title
+	}
+
+	snapshotMutex.Lock()
+	defer snapshotMutex.Unlock()
+
+	dir := a.snapshotDir(urlID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, snapshot.ID+".html"), page, 0644); err != nil {
+		return nil, err
+	}
+
+	snapshots, err := a.readSnapshotIndex(urlID)
+	if err != nil {
+		return nil, err
+	}
+	snapshots = append(snapshots, snapshot)
+	if err := a.writeSnapshotIndex(urlID, snapshots); err != nil {
+		return nil, err
+	}
+
+	a.indexPageContent(urlID, archiver.text)
+
+	return &snapshot, nil
+}
+
+// ListSnapshots returns the snapshots of a bookmark, newest first
+func (a *App) ListSnapshots(urlID string) ([]Snapshot, error) {
+	snapshotMutex.Lock()
+	defer snapshotMutex.Unlock()
+
+	snapshots, err := a.readSnapshotIndex(urlID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt) })
+	return snapshots, nil
+}
+
+// DeleteSnapshot removes a snapshot of a bookmark
+func (a *App) DeleteSnapshot(urlID, snapshotID string) error {
+	snapshotMutex.Lock()
+	defer snapshotMutex.Unlock()
+
+	snapshots, err := a.readSnapshotIndex(urlID)
+	if err != nil {
+		return err
+	}
+
+	kept := snapshots[:0]
+	found := false
+	for _, snapshot := range snapshots {
+		if snapshot.ID == snapshotID {
+			found = true
+			continue
+		}
+		kept = append(kept, snapshot)
+	}
+	if !found {
+		return fmt.Errorf("snapshot %s not found", snapshotID)
+	}
+
+	err = os.Remove(filepath.Join(a.snapshotDir(urlID), filepath.Base(snapshotID)+".html"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return a.writeSnapshotIndex(urlID, kept)
+}
+
+// findURL returns the bookmark with the given ID
+func (a *App) findURL(id string) (*URLItem, error) {
+	urls, err := a.GetURLs()
+	if err != nil {
+		return nil, err
+	}
+	for i := range urls {
+		if urls[i].ID == id {
+			return &urls[i], nil
+		}
+	}
+	return nil, fmt.Errorf("URL with id %s not found", id)
+}
+
+// readSnapshotIndex reads index.json of a bookmark; callers must hold snapshotMutex
+func (a *App) readSnapshotIndex(urlID string) ([]Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(a.snapshotDir(urlID), "index.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Snapshot{}, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	err = json.Unmarshal(data, &snapshots)
+	return snapshots, err
+}
+
+// writeSnapshotIndex writes index.json of a bookmark; callers must hold snapshotMutex
+func (a *App) writeSnapshotIndex(urlID string, snapshots []Snapshot) error {
+	data, err := json.MarshalIndent(snapshots, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(a.snapshotDir(urlID), "index.json"), data, 0644)
+}
+
+// serveSnapshot serves /snapshot/{urlId}/{snapshotId} for offline viewing
+func (a *App) serveSnapshot(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/snapshot/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	path := filepath.Join(a.snapshotDir(parts[0]), filepath.Base(parts[1])+".html")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Security-Policy", snapshotCSP)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Snapshots never change once written
+	writeCachedAsset(w, r, data, "text/html; charset=utf-8", 365*24*time.Hour)
+}
+
+// pageArchiver turns a page into a single HTML file. Scripts are removed
+// and every resource the page needs for rendering is inlined as a data URI.
+type pageArchiver struct {
+	client *http.Client
+	cache  map[string]string // Resource URL to data URI, "" if it failed
+
+	title   string
+	text    string // Readable text for the content index
+	total   int
+	inlined int
+	failed  int
+}
+
+// archive downloads a page and returns the self-contained HTML and the
+// URL it was finally served from
+func (p *pageArchiver) archive(ctx context.Context, rawURL string) ([]byte, string, error) {
+	body, contentType, finalURL, err := p.get(ctx, rawURL, snapshotMaxResource)
+	if err != nil {
+		return nil, "", err
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "" &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, "", fmt.Errorf("not an HTML page: %s", mediaType)
+	}
+
+	text, err := decodeHTML(body, contentType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	doc, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return nil, "", err
+	}
+
+	p.text = readableText(doc)
+
+	base, _ := url.Parse(finalURL)
+	p.rewrite(ctx, doc, base)
+	p.addSnapshotHead(doc, finalURL)
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), finalURL, nil
+}
+
+// get downloads a URL with a size limit
+func (p *pageArchiver) get(ctx context.Context, rawURL string, limit int) ([]byte, string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", "", err
+	}
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", AppName, strings.TrimPrefix(Version, "v")))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return nil, "", "", err
+	}
+	if len(data) > limit {
+		return nil, "", "", fmt.Errorf("%s is larger than %d bytes", rawURL, limit)
+	}
+
+	return data, resp.Header.Get("Content-Type"), resp.Request.URL.String(), nil
+}
+
+// dataURI downloads a resource and returns it as a data URI. Failed
+// downloads return an empty string and are counted.
+func (p *pageArchiver) dataURI(ctx context.Context, base *url.URL, ref string) string {
+	resolved := resolveArchiveURL(base, ref)
+	if resolved == nil {
+		return ""
+	}
+	if resolved.Scheme == "data" {
+		return ref
+	}
+
+	key := resolved.String()
+	if uri, ok := p.cache[key]; ok {
+		return uri
+	}
+
+	uri := ""
+	data, contentType, _, err := p.get(ctx, key, snapshotMaxResource)
+	if err == nil && p.total+len(data) <= snapshotMaxTotal {
+		p.total += len(data)
+		p.inlined++
+		if contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+		uri = "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	} else {
+		p.failed++
+	}
+
+	p.cache[key] = uri
+	return uri
+}
+
+// stylesheet downloads a stylesheet and inlines what it references
+func (p *pageArchiver) stylesheet(ctx context.Context, base *url.URL, ref string, depth int) (string, bool) {
+	resolved := resolveArchiveURL(base, ref)
+	if resolved == nil || resolved.Scheme == "data" {
+		return "", false
+	}
+
+	data, contentType, finalURL, err := p.get(ctx, resolved.String(), snapshotMaxResource)
+	if err != nil || p.total+len(data) > snapshotMaxTotal {
+		p.failed++
+		return "", false
+	}
+	p.total += len(data)
+	p.inlined++
+
+	css, err := decodeHTML(data, contentType)
+	if err != nil {
+		css = string(data)
+	}
+	cssBase, _ := url.Parse(finalURL)
+	return p.inlineCSS(ctx, cssBase, css, depth), true
+}
+
+// inlineCSS replaces @import rules and url(...) references in a
+// stylesheet with their content
+func (p *pageArchiver) inlineCSS(ctx context.Context, base *url.URL, css string, depth int) string {
+	// Imported stylesheets are inlined in place, a few levels deep
+	css = cssImportRegex.ReplaceAllStringFunc(css, func(match string) string {
+		groups := cssImportRegex.FindStringSubmatch(match)
+		if depth >= 3 {
+			return match
+		}
+		if imported, ok := p.stylesheet(ctx, base, groups[1]+groups[2], depth+1); ok {
+			return "@import url(\"data:text/css;base64," + base64.StdEncoding.EncodeToString([]byte(imported)) + "\")"
+		}
+		return match
+	})
+
+	return cssURLRegex.ReplaceAllStringFunc(css, func(match string) string {
+		groups := cssURLRegex.FindStringSubmatch(match)
+		ref := groups[1] + groups[2] + groups[3]
+		if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "data:") {
+			return match
+		}
+
+		if strings.HasSuffix(strings.ToLower(strings.SplitN(ref, "?", 2)[0]), ".css") && depth < 3 {
+			if imported, ok := p.stylesheet(ctx, base, ref, depth+1); ok {
+				return "url(\"data:text/css;base64," + base64.StdEncoding.EncodeToString([]byte(imported)) + "\")"
+			}
+			return match
+		}
+
+		if uri := p.dataURI(ctx, base, ref); uri != "" {
+			return "url(\"" + uri + "\")"
+		}
+		return match
+	})
+}
+
+// rewrite walks the document, dropping active content and inlining
+// stylesheets, images and icons
+func (p *pageArchiver) rewrite(ctx context.Context, n *html.Node, base *url.URL) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		if c.Type == html.ElementNode {
+			switch c.DataAtom {
+			case atom.Script, atom.Noscript, atom.Iframe, atom.Frame, atom.Object, atom.Embed, atom.Applet, atom.Template:
+				n.RemoveChild(c)
+				c = next
+				continue
+			case atom.Base:
+				if href := getAttr(c, "href"); href != "" {
+					if resolved, err := base.Parse(href); err == nil {
+						base = resolved
+					}
+				}
+				n.RemoveChild(c)
+				c = next
+				continue
+			case atom.Meta:
+				// Refresh redirects and CSP meta tags break offline viewing
+				equiv := strings.ToLower(getAttr(c, "http-equiv"))
+				if equiv == "refresh" || equiv == "content-security-policy" || getAttr(c, "charset") != "" || equiv == "content-type" {
+					n.RemoveChild(c)
+					c = next
+					continue
+				}
+			case atom.Title:
+				if p.title == "" && c.FirstChild != nil {
+					p.title = cleanMetadataText(c.FirstChild.Data)
+				}
+			}
+
+			if replacement := p.rewriteElement(ctx, c, base); replacement != nil {
+				if replacement != removedNode {
+					n.InsertBefore(replacement, c)
+				}
+				n.RemoveChild(c)
+				c = next
+				continue
+			}
+		}
+
+		p.rewrite(ctx, c, base)
+		c = next
+	}
+}
+
+// removedNode is returned by rewriteElement for elements that are dropped
+var removedNode = &html.Node{Type: html.CommentNode}
+
+// rewriteElement updates the attributes of an element. It returns a node
+// that replaces the element, used for stylesheets turned into <style>, or
+// removedNode to drop it.
+func (p *pageArchiver) rewriteElement(ctx context.Context, n *html.Node, base *url.URL) *html.Node {
+	var attrs []html.Attribute
+	for _, attr := range n.Attr {
+		key := strings.ToLower(attr.Key)
+		// Event handlers could run script despite the CSP in older engines
+		if strings.HasPrefix(key, "on") {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(attr.Val)), "javascript:") {
+			continue
+		}
+		if key == "style" {
+			attr.Val = p.inlineCSS(ctx, base, attr.Val, 0)
+		}
+		if key == "integrity" || key == "crossorigin" || key == "srcset" || key == "loading" {
+			// Inlined content no longer matches hashes and source sets
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	n.Attr = attrs
+
+	switch n.DataAtom {
+	case atom.Link:
+		rel := strings.Fields(strings.ToLower(getAttr(n, "rel")))
+		href := getAttr(n, "href")
+		switch {
+		case indexOf(rel, "stylesheet") >= 0 && href != "":
+			css, ok := p.stylesheet(ctx, base, href, 0)
+			if !ok {
+				return &html.Node{Type: html.CommentNode, Data: " stylesheet unavailable: " + href + " "}
+			}
+			style := &html.Node{Type: html.ElementNode, Data: "style", DataAtom: atom.Style}
+			if media := getAttr(n, "media"); media != "" {
+				style.Attr = []html.Attribute{{Key: "media", Val: media}}
+			}
+			style.AppendChild(&html.Node{Type: html.TextNode, Data: css})
+			return style
+		case indexOf(rel, "icon") >= 0 || indexOf(rel, "apple-touch-icon") >= 0:
+			if uri := p.dataURI(ctx, base, href); uri != "" {
+				setAttr(n, "href", uri)
+			}
+		default:
+			// Preloads, manifests and the like are useless offline
+			return removedNode
+		}
+	case atom.Meta:
+		// A refresh would leave the snapshot for the live page
+		if strings.EqualFold(strings.TrimSpace(getAttr(n, "http-equiv")), "refresh") {
+			return removedNode
+		}
+	case atom.Style:
+		if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
+			n.FirstChild.Data = p.inlineCSS(ctx, base, n.FirstChild.Data, 0)
+		}
+	case atom.Img, atom.Source, atom.Input, atom.Video, atom.Audio, atom.Track:
+		src := getAttr(n, "src")
+		// Lazy-loading scripts are gone, so use their source attributes
+		for _, lazy := range []string{"data-src", "data-original", "data-lazy-src"} {
+			if value := getAttr(n, lazy); value != "" {
+				src = value
+				break
+			}
+		}
+		if src != "" && n.DataAtom != atom.Video && n.DataAtom != atom.Audio {
+			if uri := p.dataURI(ctx, base, src); uri != "" {
+				setAttr(n, "src", uri)
+			}
+		}
+		if poster := getAttr(n, "poster"); poster != "" {
+			if uri := p.dataURI(ctx, base, poster); uri != "" {
+				setAttr(n, "poster", uri)
+			}
+		}
+	case atom.A, atom.Area:
+		if href := getAttr(n, "href"); href != "" && !strings.HasPrefix(href, "#") {
+			if resolved := resolveArchiveURL(base, href); resolved != nil {
+				setAttr(n, "href", resolved.String())
+			}
+		}
+	case atom.Form:
+		if action := getAttr(n, "action"); action != "" {
+			if resolved := resolveArchiveURL(base, action); resolved != nil {
+				setAttr(n, "action", resolved.String())
+			}
+		}
+	}
+
+	return nil
+}
+
+// addSnapshotHead declares the encoding and records the source of the
+// snapshot at the start of <head>
+func (p *pageArchiver) addSnapshotHead(doc *html.Node, source string) {
+	head := findElement(doc, atom.Head)
+	if head == nil {
+		return
+	}
+
+	charset := &html.Node{Type: html.ElementNode, Data: "meta", DataAtom: atom.Meta,
+		Attr: []html.Attribute{{Key: "charset", Val: "utf-8"}}}
+	origin := &html.Node{Type: html.ElementNode, Data: "meta", DataAtom: atom.Meta,
+		Attr: []html.Attribute{{Key: "name", Val: "urlnavigator-snapshot-source"}, {Key: "content", Val: source}}}
+
+	head.InsertBefore(origin, head.FirstChild)
+	head.InsertBefore(charset, head.FirstChild)
+}
+
+// resolveArchiveURL resolves a reference against the page URL, accepting
+// only http, https and data URLs
+func resolveArchiveURL(base *url.URL, ref string) *url.URL {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil
+	}
+	resolved, err := base.Parse(ref)
+	if err != nil {
+		return nil
+	}
+	switch resolved.Scheme {
+	case "http", "https", "data":
+		return resolved
+	}
+	return nil
+}
+
+// findElement returns the first element of the given type
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// getAttr returns the value of an attribute, or an empty string
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// setAttr sets the value of an attribute, adding it if needed
+func setAttr(n *html.Node, key, value string) {
+	for i, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: value})
+}
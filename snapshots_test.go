@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateAndServeSnapshot(t *testing.T) {
+	setTestHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<!DOCTYPE html><html><head>
+				<title>Archived page</title>
+				<meta http-equiv="refresh" content="0; url=https://live.example/">
+				<link rel="stylesheet" href="/style.css">
+				<link rel="preload" href="/font.woff2">
+				<script src="/app.js"></script>
+				</head><body>
+				<p onclick="steal()">Hello snapshot</p>
+				<img src="img/dot.png" srcset="big.png 2x">
+				<img src="/missing.png">
+				<a href="/other">Other</a>
+				</body></html>`))
+		case "/style.css":
+			w.Header().Set("Content-Type", "text/css")
+			w.Write([]byte(`body { background: url("img/dot.png"); }`))
+		case "/img/dot.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(encodeTestPNG(t, 1, 1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	a := NewApp()
+	if err := a.SaveURLs([]URLItem{{ID: "1", Title: "Page", URL: server.URL + "/page"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := a.CreateSnapshot("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Title != "Archived page" || snapshot.Resources != 2 || snapshot.Failed != 1 {
+		t.Errorf("snapshot = %+v, want the page title, 2 inlined resources and 1 failure", snapshot)
+	}
+
+	recorder := httptest.NewRecorder()
+	a.serveSnapshot(recorder, httptest.NewRequest(http.MethodGet, "/snapshot/1/"+snapshot.ID, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d", recorder.Code)
+	}
+	if csp := recorder.Header().Get("Content-Security-Policy"); csp != snapshotCSP || !strings.HasSuffix(csp, "; sandbox") {
+		t.Errorf("Content-Security-Policy = %q", csp)
+	}
+	if recorder.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("nosniff header missing")
+	}
+
+	page := recorder.Body.String()
+	for _, want := range []string{
+		"Hello snapshot",
+		`<style>body { background: url("data:image/png;base64,`,
+		`<img src="data:image/png;base64,`,
+		`href="` + server.URL + `/other"`,
+		`name="urlnavigator-snapshot-source" content="` + server.URL + `/page"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("snapshot lacks %q:\n%s", want, page)
+		}
+	}
+	for _, unwanted := range []string{"<script", "onclick", "http-equiv", "srcset", "preload", "/style.css"} {
+		if strings.Contains(page, unwanted) {
+			t.Errorf("snapshot still contains %q:\n%s", unwanted, page)
+		}
+	}
+
+	recorder = httptest.NewRecorder()
+	a.serveSnapshot(recorder, httptest.NewRequest(http.MethodGet, "/snapshot/1/../../urls", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("path outside the snapshots: status = %d, want 404", recorder.Code)
+	}
+}
@@ -178,6 +178,13 @@ func buildMode() error {
 
 	// 构建带版本注入的ldflags
 	ldflags := fmt.Sprintf("-s -w -X main.Version=%s -X main.GitHubOwner=wangyaxings -X main.GitHubRepo=url-navigator", version)
+
+	// 注入更新签名公钥（minisign公钥），未设置时应用将拒绝安装更新
+	if publicKey := strings.TrimSpace(os.Getenv("UPDATE_PUBLIC_KEY")); publicKey != "" {
+		ldflags += " -X main.UpdatePublicKey=" + publicKey
+	} else {
+		writeWarning("未设置 UPDATE_PUBLIC_KEY，构建的应用无法验证和安装自动更新")
+	}
 	writeInfo(fmt.Sprintf("使用版本: %s", version))
 	writeInfo(fmt.Sprintf("ldflags: %s", ldflags))
 	cmd = exec.Command("wails", "build", "-ldflags", ldflags)
@@ -224,6 +231,32 @@ func runApp() error {
 	return cmd.Run()
 }
 
+// 导出静态站点（调用构建好的应用的 export-site 命令）
+func exportSiteMode(args []string) error {
+	writeHeader("🌐 URL Navigator Export Site")
+
+	projectRoot, err := getProjectRoot()
+	if err != nil {
+		return fmt.Errorf("无法获取项目根目录: %v", err)
+	}
+
+	exePath := filepath.Join(projectRoot, "build", "bin", "URLNavigator.exe")
+	if _, err := os.Stat(exePath); os.IsNotExist(err) {
+		writeError("应用文件不存在，正在构建...")
+		if err := buildMode(); err != nil {
+			return fmt.Errorf("构建失败: %v", err)
+		}
+	}
+
+	writeInfo("导出书签站点...")
+	cmd := exec.Command(exePath, append([]string{"export-site"}, args...)...)
+	cmd.Dir = projectRoot
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
 // 简化版发布功能
 func releaseMode(version string, skipBuild, skipRelease, force, debug bool) error {
 	writeHeader("📦 URL Navigator Release")
@@ -447,6 +480,7 @@ func showHelp() {
 	fmt.Println("  release  发布新版本")
 	fmt.Println()
 	fmt.Println("其他命令:")
+	fmt.Println("  export-site  导出书签为静态HTML页面和OPML")
 	fmt.Println("  help     显示此帮助信息")
 	fmt.Println()
 	fmt.Println("开发示例:")
@@ -458,6 +492,9 @@ func showHelp() {
 	fmt.Println("  go run tools/urlnav.go release v1.4.0")
 	fmt.Println("  go run tools/urlnav.go release v1.4.0 -skip-build")
 	fmt.Println()
+	fmt.Println("导出示例:")
+	fmt.Println("  go run tools/urlnav.go export-site -out site -category 工作 -theme dark")
+	fmt.Println()
 	fmt.Println("发布选项:")
 	fmt.Println("  -skip-build    跳过构建过程")
 	fmt.Println("  -skip-release  跳过发布过程")
@@ -509,6 +546,8 @@ func main() {
 		}
 
 		err = releaseMode(version, skipBuild, skipRelease, force, debug)
+	case "export-site":
+		err = exportSiteMode(os.Args[2:])
 	case "help", "-h", "--help":
 		showHelp()
 		return
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Packaging formats of release assets
+const (
+	assetFormatBinary   = "binary"   // Plain executable, e.g. URLNavigator.exe
+	assetFormatAppImage = "appimage" // Linux AppImage, itself an executable
+	assetFormatTarGz    = "tar.gz"
+	assetFormatZip      = "zip" // Includes zipped macOS .app bundles
+)
+
+// updateMaxBinarySize limits the size of an executable extracted from an archive
+const updateMaxBinarySize = 512 * 1024 * 1024
+
+// updatePlatform describes what a release asset has to run on
+type updatePlatform struct {
+	GOOS     string
+	GOARCH   string
+	AppImage bool // Running from an AppImage, which should stay one
+}
+
+// Names used for operating systems and architectures in asset file names
+var (
+	osAliases = map[string][]string{
+		"windows": {"windows", "win64", "win32", "win"},
+		"darwin":  {"darwin", "macos", "mac", "osx"},
+		"linux":   {"linux"},
+	}
+	archAliases = map[string][]string{
+		"amd64": {"amd64", "x64"},
+		"arm64": {"arm64", "aarch64"},
+		"386":   {"386", "i386", "i686", "x86"},
+	}
+)
+
+// x86_64Replacer names 64-bit x86 before asset names are split at "_" and "-"
+var x86_64Replacer = strings.NewReplacer("x86_64", "amd64", "x86-64", "amd64")
+
+// currentUpdatePlatform returns the platform of the running app
+func currentUpdatePlatform() updatePlatform {
+	return updatePlatform{
+		GOOS:     runtime.GOOS,
+		GOARCH:   runtime.GOARCH,
+		AppImage: runtime.GOOS == "linux" && os.Getenv("APPIMAGE") != "",
+	}
+}
+
+// selectReleaseAsset picks the asset that can update the app on a
+// platform, or nil when the release has none. Operating system and
+// architecture come from names such as URLNavigator-linux-x86_64.AppImage;
+// an .exe, .AppImage or .app.zip implies its operating system and assets
+// without an architecture are assumed to fit.
+func selectReleaseAsset(assets []releaseAsset, platform updatePlatform) *releaseAsset {
+	best, bestScore := -1, 0
+	for i, asset := range assets {
+		score := releaseAssetScore(asset.Name, platform)
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	return &assets[best]
+}
+
+// releaseAssetScore rates how well an asset fits a platform; zero means
+// it does not fit at all
+func releaseAssetScore(name string, platform updatePlatform) int {
+	lower := strings.ToLower(name)
+	for _, manifest := range checksumManifestNames {
+		if lower == manifest {
+			return 0
+		}
+	}
+
+	format := releaseAssetFormat(lower)
+	formatScore := assetFormatPreference(format, platform)
+	if formatScore == 0 {
+		return 0
+	}
+
+	// x86_64 would otherwise split into "x86", which means 386
+	tokens := make(map[string]bool)
+	for _, token := range strings.FieldsFunc(x86_64Replacer.Replace(lower), func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == ' ' || r == '+'
+	}) {
+		tokens[token] = true
+	}
+
+	score := formatScore * 10
+
+	// The operating system must be named or implied by the format
+	assetOS := ""
+	for goos, aliases := range osAliases {
+		if hasAnyToken(tokens, aliases) {
+			if assetOS != "" {
+				return 0 // Ambiguous, e.g. a bundle for several systems
+			}
+			assetOS = goos
+		}
+	}
+	switch {
+	case assetOS == platform.GOOS:
+		score += 4
+	case assetOS != "":
+		return 0
+	case impliedAssetOS(lower, format) == platform.GOOS:
+		score += 2
+	default:
+		return 0
+	}
+
+	// A named architecture must match; universal macOS builds fit all
+	assetArch := ""
+	for goarch, aliases := range archAliases {
+		if hasAnyToken(tokens, aliases) {
+			assetArch = goarch
+		}
+	}
+	switch {
+	case assetArch == platform.GOARCH:
+		score += 3
+	case assetArch != "":
+		return 0
+	case platform.GOOS == "darwin" && tokens["universal"]:
+		score += 2
+	default:
+		score++
+	}
+
+	if strings.Contains(lower, "urlnavigator") || strings.Contains(lower, "url-navigator") {
+		score++
+	}
+	return score
+}
+
+// releaseAssetFormat returns the packaging of an asset, or "" for files
+// that cannot update the app such as signatures, installers and packages
+func releaseAssetFormat(lowerName string) string {
+	switch {
+	case strings.HasSuffix(lowerName, ".appimage"):
+		return assetFormatAppImage
+	case strings.HasSuffix(lowerName, ".tar.gz"), strings.HasSuffix(lowerName, ".tgz"):
+		return assetFormatTarGz
+	case strings.HasSuffix(lowerName, ".zip"):
+		return assetFormatZip
+	case strings.HasSuffix(lowerName, ".exe"):
+		return assetFormatBinary
+	}
+
+	// Names like urlnavigator-v1.5.0-linux-amd64 have no real extension
+	ext := path.Ext(lowerName)
+	if ext == "" || strings.ContainsAny(ext, "-_") {
+		return assetFormatBinary
+	}
+	return ""
+}
+
+// assetFormatPreference ranks the formats usable on a platform
+func assetFormatPreference(format string, platform updatePlatform) int {
+	switch platform.GOOS {
+	case "windows":
+		switch format {
+		case assetFormatBinary:
+			return 3
+		case assetFormatZip:
+			return 2
+		}
+	case "darwin":
+		switch format {
+		case assetFormatZip:
+			return 3
+		case assetFormatTarGz:
+			return 2
+		case assetFormatBinary:
+			return 1
+		}
+	case "linux":
+		switch format {
+		case assetFormatAppImage:
+			if platform.AppImage {
+				return 4
+			}
+			return 1
+		case assetFormatTarGz:
+			return 3
+		case assetFormatBinary:
+			return 2
+		}
+	}
+	return 0
+}
+
+// impliedAssetOS returns the operating system a format only exists for
+func impliedAssetOS(lowerName, format string) string {
+	switch {
+	case strings.HasSuffix(lowerName, ".exe"):
+		return "windows"
+	case format == assetFormatAppImage:
+		return "linux"
+	case strings.HasSuffix(lowerName, ".app.zip"):
+		return "darwin"
+	}
+	return ""
+}
+
+// hasAnyToken reports whether one of the names is among the tokens
+func hasAnyToken(tokens map[string]bool, names []string) bool {
+	for _, name := range names {
+		if tokens[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// updateTargetPath returns the file an update replaces: the AppImage when
+// running from one, otherwise the executable
+func updateTargetPath() (string, error) {
+	if appImage := os.Getenv("APPIMAGE"); runtime.GOOS == "linux" && appImage != "" {
+		return appImage, nil
+	}
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+	return exePath, nil
+}
+
+// extractUpdateBinary returns the new executable from a downloaded asset,
+// unpacking archives. executableName is the file name to look for, e.g.
+// URLNavigator inside URLNavigator.app/Contents/MacOS.
+func extractUpdateBinary(data []byte, assetName, executableName string) ([]byte, error) {
+	switch releaseAssetFormat(strings.ToLower(assetName)) {
+	case assetFormatTarGz:
+		return extractTarGzBinary(data, executableName)
+	case assetFormatZip:
+		return extractZipBinary(data, executableName)
+	default:
+		return data, nil
+	}
+}
+
+// isUpdateArchive reports whether an asset has to be unpacked
+func isUpdateArchive(assetName string) bool {
+	format := releaseAssetFormat(strings.ToLower(assetName))
+	return format == assetFormatTarGz || format == assetFormatZip
+}
+
+// archiveEntry is a regular file found in an update archive
+type archiveEntry struct {
+	name       string
+	executable bool
+}
+
+// extractTarGzBinary returns the executable from a .tar.gz archive
+func extractTarGzBinary(data []byte, executableName string) ([]byte, error) {
+	open := func() (*tar.Reader, error) {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("解压更新失败: %v", err)
+		}
+		return tar.NewReader(gz), nil
+	}
+
+	reader, err := open()
+	if err != nil {
+		return nil, err
+	}
+	var entries []archiveEntry
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解压更新失败: %v", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			entries = append(entries, archiveEntry{name: header.Name, executable: header.Mode&0111 != 0})
+		}
+	}
+
+	wanted := pickExecutableEntry(entries, executableName)
+	if wanted == "" {
+		return nil, fmt.Errorf("压缩包中未找到可执行文件 %s", executableName)
+	}
+
+	// Read the archive again up to the chosen entry
+	reader, err = open()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		header, err := reader.Next()
+		if err != nil {
+			return nil, fmt.Errorf("解压更新失败: %v", err)
+		}
+		if header.Typeflag == tar.TypeReg && header.Name == wanted {
+			return readLimitedBinary(reader)
+		}
+	}
+}
+
+// extractZipBinary returns the executable from a .zip archive
+func extractZipBinary(data []byte, executableName string) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("解压更新失败: %v", err)
+	}
+
+	var entries []archiveEntry
+	for _, file := range archive.File {
+		if file.Mode().IsRegular() {
+			entries = append(entries, archiveEntry{name: file.Name, executable: file.Mode()&0111 != 0})
+		}
+	}
+
+	wanted := pickExecutableEntry(entries, executableName)
+	if wanted == "" {
+		return nil, fmt.Errorf("压缩包中未找到可执行文件 %s", executableName)
+	}
+	for _, file := range archive.File {
+		if file.Name != wanted {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("解压更新失败: %v", err)
+		}
+		defer reader.Close()
+		return readLimitedBinary(reader)
+	}
+	return nil, fmt.Errorf("压缩包中未找到可执行文件 %s", executableName)
+}
+
+// pickExecutableEntry chooses the archive entry holding the app: one named
+// like the running executable or the app, else the only executable file
+func pickExecutableEntry(entries []archiveEntry, executableName string) string {
+	names := []string{executableName, AppName, AppName + ".exe"}
+	for _, name := range names {
+		for _, entry := range entries {
+			if name != "" && strings.EqualFold(path.Base(entry.name), name) {
+				return entry.name
+			}
+		}
+	}
+
+	var candidates []string
+	for _, entry := range entries {
+		if entry.executable || strings.HasSuffix(strings.ToLower(entry.name), ".exe") {
+			candidates = append(candidates, entry.name)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return ""
+}
+
+// readLimitedBinary reads an extracted executable within updateMaxBinarySize
+func readLimitedBinary(reader io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(reader, updateMaxBinarySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("解压更新失败: %v", err)
+	}
+	if len(data) > updateMaxBinarySize {
+		return nil, fmt.Errorf("解压后的文件过大")
+	}
+	return data, nil
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// fakeGitHubAssets is the asset list of a GitHub release as returned by
+// the releases API
+const fakeGitHubAssets = `[
+  {"name": "URLNavigator-v1.5.0-windows-amd64.exe", "browser_download_url": "https://example.com/1", "size": 100},
+  {"name": "URLNavigator-v1.5.0-windows-amd64.zip", "browser_download_url": "https://example.com/2", "size": 90},
+  {"name": "URLNavigator-v1.5.0-windows-arm64.exe", "browser_download_url": "https://example.com/3", "size": 100},
+  {"name": "URLNavigator-v1.5.0-windows-amd64-setup.msi", "browser_download_url": "https://example.com/4", "size": 120},
+  {"name": "URLNavigator-v1.5.0-linux-amd64.tar.gz", "browser_download_url": "https://example.com/5", "size": 80},
+  {"name": "URLNavigator-v1.5.0-linux-amd64.tar.gz.sig", "browser_download_url": "https://example.com/6", "size": 1},
+  {"name": "URLNavigator-v1.5.0-linux-x86_64.AppImage", "browser_download_url": "https://example.com/7", "size": 110},
+  {"name": "URLNavigator-v1.5.0-linux-arm64.tar.gz", "browser_download_url": "https://example.com/8", "size": 80},
+  {"name": "url-navigator_1.5.0_amd64.deb", "browser_download_url": "https://example.com/9", "size": 80},
+  {"name": "URLNavigator-v1.5.0-darwin-universal.app.zip", "browser_download_url": "https://example.com/10", "size": 150},
+  {"name": "URLNavigator-v1.5.0-darwin-amd64.tar.gz", "browser_download_url": "https://example.com/11", "size": 80},
+  {"name": "SHA256SUMS", "browser_download_url": "https://example.com/12", "size": 1}
+]`
+
+// fakeAssets decodes an asset list and optionally drops assets by name
+func fakeAssets(t *testing.T, list string, without ...string) []releaseAsset {
+	var assets []releaseAsset
+	if err := json.Unmarshal([]byte(list), &assets); err != nil {
+		t.Fatal(err)
+	}
+	kept := assets[:0]
+	for _, asset := range assets {
+		if indexOf(without, asset.Name) < 0 {
+			kept = append(kept, asset)
+		}
+	}
+	return kept
+}
+
+func TestSelectReleaseAsset(t *testing.T) {
+	tests := []struct {
+		name     string
+		assets   string
+		without  []string
+		platform updatePlatform
+		want     string
+	}{
+		{
+			name:     "windows amd64 prefers the plain exe",
+			assets:   fakeGitHubAssets,
+			platform: updatePlatform{GOOS: "windows", GOARCH: "amd64"},
+			want:     "URLNavigator-v1.5.0-windows-amd64.exe",
+		},
+		{
+			name:     "windows amd64 falls back to the zip",
+			assets:   fakeGitHubAssets,
+			without:  []string{"URLNavigator-v1.5.0-windows-amd64.exe"},
+			platform: updatePlatform{GOOS: "windows", GOARCH: "amd64"},
+			want:     "URLNavigator-v1.5.0-windows-amd64.zip",
+		},
+		{
+			name:     "linux amd64 prefers the tarball",
+			assets:   fakeGitHubAssets,
+			platform: updatePlatform{GOOS: "linux", GOARCH: "amd64"},
+			want:     "URLNavigator-v1.5.0-linux-amd64.tar.gz",
+		},
+		{
+			name:     "linux amd64 running from an AppImage stays one",
+			assets:   fakeGitHubAssets,
+			platform: updatePlatform{GOOS: "linux", GOARCH: "amd64", AppImage: true},
+			want:     "URLNavigator-v1.5.0-linux-x86_64.AppImage",
+		},
+		{
+			name:     "linux amd64 without a tarball uses the AppImage",
+			assets:   fakeGitHubAssets,
+			without:  []string{"URLNavigator-v1.5.0-linux-amd64.tar.gz"},
+			platform: updatePlatform{GOOS: "linux", GOARCH: "amd64"},
+			want:     "URLNavigator-v1.5.0-linux-x86_64.AppImage",
+		},
+		{
+			name:     "darwin arm64 uses the universal build",
+			assets:   fakeGitHubAssets,
+			platform: updatePlatform{GOOS: "darwin", GOARCH: "arm64"},
+			want:     "URLNavigator-v1.5.0-darwin-universal.app.zip",
+		},
+		{
+			name: "darwin arm64 prefers a native build over the universal one",
+			assets: `[
+				{"name": "URLNavigator-darwin-universal.app.zip"},
+				{"name": "URLNavigator-macos-aarch64.zip"}
+			]`,
+			platform: updatePlatform{GOOS: "darwin", GOARCH: "arm64"},
+			want:     "URLNavigator-macos-aarch64.zip",
+		},
+		{
+			name:     "format implies the operating system",
+			assets:   `[{"name": "URLNavigator.exe"}, {"name": "URLNavigator.AppImage"}]`,
+			platform: updatePlatform{GOOS: "windows", GOARCH: "arm64"},
+			want:     "URLNavigator.exe",
+		},
+		{
+			name: "goreleaser default names",
+			assets: `[
+				{"name": "urlnav_1.2.3_linux_amd64.tar.gz"},
+				{"name": "urlnav_1.2.3_linux_arm64.tar.gz"},
+				{"name": "urlnav_1.2.3_windows_amd64.zip"},
+				{"name": "checksums.txt"}
+			]`,
+			platform: updatePlatform{GOOS: "linux", GOARCH: "amd64"},
+			want:     "urlnav_1.2.3_linux_amd64.tar.gz",
+		},
+		{
+			name: "goreleaser title case names",
+			assets: `[
+				{"name": "URLNavigator_Linux_i386.tar.gz"},
+				{"name": "URLNavigator_Linux_x86_64.tar.gz"},
+				{"name": "URLNavigator_Linux_arm64.tar.gz"}
+			]`,
+			platform: updatePlatform{GOOS: "linux", GOARCH: "amd64"},
+			want:     "URLNavigator_Linux_x86_64.tar.gz",
+		},
+		{
+			name:     "x86_64 is not 386",
+			assets:   `[{"name": "URLNavigator_Linux_x86_64.tar.gz"}, {"name": "URLNavigator-linux-x86-64.tar.gz"}]`,
+			platform: updatePlatform{GOOS: "linux", GOARCH: "386"},
+		},
+		{
+			name:     "no asset for the platform",
+			assets:   fakeGitHubAssets,
+			platform: updatePlatform{GOOS: "linux", GOARCH: "386"},
+		},
+		{
+			name:     "assets for several systems are ambiguous",
+			assets:   `[{"name": "URLNavigator-windows-linux-amd64.zip"}]`,
+			platform: updatePlatform{GOOS: "windows", GOARCH: "amd64"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := selectReleaseAsset(fakeAssets(t, test.assets, test.without...), test.platform)
+			switch {
+			case got == nil && test.want != "":
+				t.Errorf("selected nothing, want %s", test.want)
+			case got != nil && got.Name != test.want:
+				t.Errorf("selected %q, want %q", got.Name, test.want)
+			}
+		})
+	}
+}
+
+// archiveFile is a file written into a test archive
+type archiveFile struct {
+	name string
+	mode int64
+	body string
+}
+
+func tarGzArchive(t *testing.T, files []archiveFile) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		header := &tar.Header{Name: file.name, Mode: file.mode, Size: int64(len(file.body)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(file.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files []archiveFile) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		header := &zip.FileHeader{Name: file.name, Method: zip.Deflate}
+		header.SetMode(fs.FileMode(file.mode))
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(file.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractUpdateArchives(t *testing.T) {
+	tests := []struct {
+		name           string
+		files          []archiveFile
+		executableName string
+		want           string
+		wantErr        string
+	}{
+		{
+			name: "named like the running executable",
+			files: []archiveFile{
+				{name: "README.md", mode: 0644, body: "readme"},
+				{name: "bin/helper", mode: 0755, body: "helper"},
+				{name: "bin/urlnav-custom", mode: 0755, body: "app"},
+			},
+			executableName: "urlnav-custom",
+			want:           "app",
+		},
+		{
+			name: "macOS app bundle",
+			files: []archiveFile{
+				{name: "URLNavigator.app/Contents/Info.plist", mode: 0644, body: "plist"},
+				{name: "URLNavigator.app/Contents/MacOS/URLNavigator", mode: 0755, body: "app"},
+			},
+			executableName: "URLNavigator",
+			want:           "app",
+		},
+		{
+			name: "the only executable",
+			files: []archiveFile{
+				{name: "LICENSE", mode: 0644, body: "license"},
+				{name: "renamed", mode: 0755, body: "app"},
+			},
+			executableName: "URLNavigator",
+			want:           "app",
+		},
+		{
+			name: "several executables without a matching name",
+			files: []archiveFile{
+				{name: "one", mode: 0755, body: "1"},
+				{name: "two", mode: 0755, body: "2"},
+			},
+			executableName: "URLNavigator",
+			wantErr:        "未找到可执行文件",
+		},
+	}
+
+	formats := []struct {
+		name    string
+		archive func(*testing.T, []archiveFile) []byte
+		extract func([]byte, string) ([]byte, error)
+	}{
+		{name: "tar.gz", archive: tarGzArchive, extract: extractTarGzBinary},
+		{name: "zip", archive: zipArchive, extract: extractZipBinary},
+	}
+
+	for _, format := range formats {
+		for _, test := range tests {
+			t.Run(format.name+"/"+test.name, func(t *testing.T) {
+				got, err := format.extract(format.archive(t, test.files), test.executableName)
+				if test.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+						t.Fatalf("error = %v, want %q", err, test.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != test.want {
+					t.Errorf("extracted %q, want %q", got, test.want)
+				}
+			})
+		}
+	}
+
+	if _, err := extractTarGzBinary([]byte("not gzip"), AppName); err == nil {
+		t.Error("extracting a corrupt tar.gz succeeded")
+	}
+	if _, err := extractZipBinary([]byte("not zip"), AppName); err == nil {
+		t.Error("extracting a corrupt zip succeeded")
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Update channels of UpdateSettings.Channel, each including the ones before
+const (
+	updateChannelStable  = "stable"
+	updateChannelBeta    = "beta"    // alpha, beta and rc prereleases
+	updateChannelNightly = "nightly" // nightly, dev and snapshot builds
+)
+
+// updateChannelRank orders the channels from most to least stable
+var updateChannelRank = map[string]int{
+	updateChannelStable:  0,
+	updateChannelBeta:    1,
+	updateChannelNightly: 2,
+}
+
+// nightlyIdentifiers mark prerelease versions of the nightly channel
+var nightlyIdentifiers = []string{"nightly", "dev", "snapshot"}
+
+// releaseChannel returns the channel a release belongs to, from its
+// prerelease identifiers or, for plain versions, the source's flag
+func releaseChannel(release *sourceRelease) string {
+	parsed, ok := parseSemVer(release.Version)
+	if !ok || len(parsed.Prerelease) == 0 {
+		if release.Prerelease {
+			return updateChannelBeta
+		}
+		return updateChannelStable
+	}
+
+	for _, identifier := range parsed.Prerelease {
+		for _, nightly := range nightlyIdentifiers {
+			if strings.HasPrefix(strings.ToLower(identifier), nightly) {
+				return updateChannelNightly
+			}
+		}
+	}
+	return updateChannelBeta
+}
+
+// channelIncludes reports whether a channel offers releases of another one
+func channelIncludes(channel, releaseChannel string) bool {
+	rank, ok := updateChannelRank[channel]
+	if !ok {
+		rank = updateChannelRank[updateChannelStable]
+	}
+	return updateChannelRank[releaseChannel] <= rank
+}
+
+// sameVersion reports whether two version strings name the same version
+func sameVersion(a, b string) bool {
+	parsedA, okA := parseSemVer(a)
+	parsedB, okB := parseSemVer(b)
+	if okA && okB {
+		return compareSemVer(parsedA, parsedB) == 0
+	}
+	return strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(a), "v"), strings.TrimPrefix(strings.TrimSpace(b), "v"))
+}
+
+// latestChannelRelease returns the highest version of the channel, or nil
+func latestChannelRelease(releases []sourceRelease, channel string) *sourceRelease {
+	var latest *sourceRelease
+	for i := range releases {
+		release := &releases[i]
+		if !channelIncludes(channel, releaseChannel(release)) {
+			continue
+		}
+		if latest == nil || compareVersions(release.Version, latest.Version) > 0 {
+			latest = release
+		}
+	}
+	return latest
+}
+
+// selectUpdateRelease returns the release to offer, or nil when there is
+// none. A pinned version is offered whenever it differs from the running
+// one, regardless of channel; otherwise the highest version of the
+// channel above the running one that was not skipped.
+func selectUpdateRelease(releases []sourceRelease, settings UpdateSettings, currentVersion string) *sourceRelease {
+	if settings.PinnedVersion != "" {
+		if sameVersion(settings.PinnedVersion, currentVersion) {
+			return nil
+		}
+		for i := range releases {
+			if sameVersion(releases[i].Version, settings.PinnedVersion) {
+				return &releases[i]
+			}
+		}
+		return nil
+	}
+
+	var candidates []sourceRelease
+	for _, release := range releases {
+		if compareVersions(release.Version, currentVersion) <= 0 {
+			continue
+		}
+		skipped := false
+		for _, version := range settings.SkippedVersions {
+			if sameVersion(release.Version, version) {
+				skipped = true
+				break
+			}
+		}
+		if !skipped {
+			candidates = append(candidates, release)
+		}
+	}
+	return latestChannelRelease(candidates, settings.Channel)
+}
+
+// SetUpdateChannel switches between the stable, beta and nightly channels
+func (a *App) SetUpdateChannel(channel string) error {
+	if _, ok := updateChannelRank[channel]; !ok {
+		return fmt.Errorf("未知的更新通道: %s", channel)
+	}
+	return a.modifySettings(func(settings *Settings) error {
+		settings.Update.Channel = channel
+		return nil
+	})
+}
+
+// SkipUpdateVersion stops offering a version; later versions are offered
+// again. While the mock update server runs, the version is skipped for the
+// mock session only.
+func (a *App) SkipUpdateVersion(version string) error {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return fmt.Errorf("版本号不能为空")
+	}
+	if skipMockVersion(version) {
+		return nil
+	}
+	return a.modifySettings(func(settings *Settings) error {
+		for _, skipped := range settings.Update.SkippedVersions {
+			if sameVersion(skipped, version) {
+				return nil
+			}
+		}
+		settings.Update.SkippedVersions = append(settings.Update.SkippedVersions, version)
+		return nil
+	})
+}
+
+// PinUpdateVersion offers only the given version; an empty version unpins
+func (a *App) PinUpdateVersion(version string) error {
+	return a.modifySettings(func(settings *Settings) error {
+		settings.Update.PinnedVersion = strings.TrimSpace(version)
+		return nil
+	})
+}
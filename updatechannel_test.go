@@ -0,0 +1,105 @@
+package main
+
+import "testing"
+
+func TestSelectUpdateRelease(t *testing.T) {
+	releases := []sourceRelease{
+		{Version: "v1.4.0"},
+		{Version: "v1.5.0"},
+		{Version: "v1.5.1"},
+		{Version: "v1.6.0-beta.1"},
+		{Version: "v1.6.0-rc.1"},
+		{Version: "v1.7.0-nightly.20240101"},
+		{Version: "v1.5.2", Prerelease: true},
+	}
+
+	tests := []struct {
+		name     string
+		settings UpdateSettings
+		current  string
+		want     string
+	}{
+		{name: "stable", settings: UpdateSettings{Channel: updateChannelStable}, current: "1.5.0", want: "v1.5.1"},
+		{name: "unknown channel is stable", settings: UpdateSettings{Channel: "canary"}, current: "1.5.0", want: "v1.5.1"},
+		{name: "beta", settings: UpdateSettings{Channel: updateChannelBeta}, current: "1.5.0", want: "v1.6.0-rc.1"},
+		{name: "nightly", settings: UpdateSettings{Channel: updateChannelNightly}, current: "1.5.0", want: "v1.7.0-nightly.20240101"},
+		{name: "up to date", settings: UpdateSettings{Channel: updateChannelStable}, current: "1.5.1"},
+		{name: "newer than all releases", settings: UpdateSettings{Channel: updateChannelNightly}, current: "2.0.0"},
+		{name: "stable prerelease flag", settings: UpdateSettings{Channel: updateChannelStable}, current: "1.5.1"},
+		{name: "beta prerelease flag", settings: UpdateSettings{Channel: updateChannelBeta}, current: "1.6.0-rc.1"},
+		{name: "prerelease to its release", settings: UpdateSettings{Channel: updateChannelStable}, current: "1.5.1-rc.2", want: "v1.5.1"},
+		{
+			name:     "skipped version",
+			settings: UpdateSettings{Channel: updateChannelStable, SkippedVersions: []string{"1.5.1"}},
+			current:  "1.4.0",
+			want:     "v1.5.0",
+		},
+		{
+			name:     "skipping all newer versions",
+			settings: UpdateSettings{Channel: updateChannelBeta, SkippedVersions: []string{"v1.5.1", "1.5.2", "1.6.0-beta.1", "1.6.0-rc.1+build"}},
+			current:  "1.5.0",
+		},
+		{
+			name:     "pin to a newer version",
+			settings: UpdateSettings{Channel: updateChannelStable, PinnedVersion: "1.5.0"},
+			current:  "1.4.0",
+			want:     "v1.5.0",
+		},
+		{
+			name:     "pin downgrades",
+			settings: UpdateSettings{Channel: updateChannelStable, PinnedVersion: "v1.4.0"},
+			current:  "1.5.1",
+			want:     "v1.4.0",
+		},
+		{
+			name:     "pin ignores the channel and skip list",
+			settings: UpdateSettings{Channel: updateChannelStable, PinnedVersion: "1.7.0-nightly.20240101", SkippedVersions: []string{"1.7.0-nightly.20240101"}},
+			current:  "1.5.0",
+			want:     "v1.7.0-nightly.20240101",
+		},
+		{
+			name:     "pinned version is running",
+			settings: UpdateSettings{Channel: updateChannelStable, PinnedVersion: "1.4.0"},
+			current:  "v1.4.0+local",
+		},
+		{
+			name:     "pinned version not released",
+			settings: UpdateSettings{Channel: updateChannelStable, PinnedVersion: "1.9.0"},
+			current:  "1.4.0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := selectUpdateRelease(releases, test.settings, test.current)
+			switch {
+			case got == nil && test.want != "":
+				t.Errorf("selected nothing, want %s", test.want)
+			case got != nil && got.Version != test.want:
+				t.Errorf("selected %s, want %q", got.Version, test.want)
+			}
+		})
+	}
+}
+
+func TestReleaseChannel(t *testing.T) {
+	tests := []struct {
+		release sourceRelease
+		want    string
+	}{
+		{release: sourceRelease{Version: "1.0.0"}, want: updateChannelStable},
+		{release: sourceRelease{Version: "1.0.0", Prerelease: true}, want: updateChannelBeta},
+		{release: sourceRelease{Version: "1.0.0-alpha.1"}, want: updateChannelBeta},
+		{release: sourceRelease{Version: "1.0.0-rc.1"}, want: updateChannelBeta},
+		{release: sourceRelease{Version: "1.0.0-Nightly.5"}, want: updateChannelNightly},
+		{release: sourceRelease{Version: "1.0.0-beta.dev3"}, want: updateChannelNightly},
+		{release: sourceRelease{Version: "1.0.0-snapshot"}, want: updateChannelNightly},
+		{release: sourceRelease{Version: "latest"}, want: updateChannelStable},
+	}
+
+	for _, test := range tests {
+		if got := releaseChannel(&test.release); got != test.want {
+			t.Errorf("releaseChannel(%+v) = %q, want %q", test.release, got, test.want)
+		}
+	}
+}
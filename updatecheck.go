@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Timing of the background update check
+const (
+	defaultUpdateCheckHours = 24
+	updateCheckStartDelay   = 30 * time.Second
+	updateCheckMaxSleep     = time.Hour        // Settings changes apply within this time
+	updateCheckMinBackoff   = 15 * time.Minute // Wait after the first failed check
+)
+
+// updateCacheMaxSize limits the size of a cached release list
+const updateCacheMaxSize = 4 * 1024 * 1024
+
+// UpdateCheckStatus is the outcome of the last update check, kept across
+// restarts
+type UpdateCheckStatus struct {
+	LastCheckedAt   time.Time   `json:"lastCheckedAt"`
+	LastResult      *UpdateInfo `json:"lastResult,omitempty"` // Last successful check
+	LastError       string      `json:"lastError,omitempty"`
+	Failures        int         `json:"failures"`                  // Failed checks in a row
+	NextCheckAt     time.Time   `json:"nextCheckAt"`               // Earliest next background check
+	NotifiedVersion string      `json:"notifiedVersion,omitempty"` // Last version announced by update:available
+}
+
+// updateCheckState is stored in update-check.json
+type updateCheckState struct {
+	UpdateCheckStatus
+	Responses map[string]cachedReleaseResponse `json:"responses,omitempty"` // By request URL
+}
+
+// cachedReleaseResponse is a release list kept for conditional requests
+type cachedReleaseResponse struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Body         []byte `json:"body"`
+}
+
+// updateCheckMutex serializes reads and writes of update-check.json
+var updateCheckMutex sync.Mutex
+
+// updateCheckStatePath returns the file the last check is recorded in
+func (a *App) updateCheckStatePath() string {
+	return filepath.Join(a.GetDataDir(), "update-check.json")
+}
+
+// loadUpdateCheckState reads update-check.json; callers must hold
+// updateCheckMutex
+func (a *App) loadUpdateCheckState() updateCheckState {
+	var state updateCheckState
+	if data, err := os.ReadFile(a.updateCheckStatePath()); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// saveUpdateCheckState writes update-check.json; callers must hold
+// updateCheckMutex
+func (a *App) saveUpdateCheckState(state updateCheckState) error {
+	if err := a.EnsureDataDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(a.updateCheckStatePath(), data, 0644)
+}
+
+// GetUpdateCheckStatus returns the outcome of the last update check, or
+// of the last check against the mock update server while it runs
+func (a *App) GetUpdateCheckStatus() UpdateCheckStatus {
+	if mock := runningMockUpdate(); mock != nil {
+		return mock.lastCheckStatus()
+	}
+	updateCheckMutex.Lock()
+	defer updateCheckMutex.Unlock()
+	return a.loadUpdateCheckState().UpdateCheckStatus
+}
+
+// runUpdateCheck checks for updates and records the outcome. Background
+// checks announce a new version once with an update:available event.
+// updateCheckMutex is held only while update-check.json is read and
+// written, not during the requests. Checks against the mock update server
+// are recorded in memory and leave update-check.json alone.
+func (a *App) runUpdateCheck(background bool) UpdateInfo {
+	mock := runningMockUpdate()
+	var previous map[string]cachedReleaseResponse
+	if mock == nil {
+		updateCheckMutex.Lock()
+		previous = a.loadUpdateCheckState().Responses
+		updateCheckMutex.Unlock()
+	}
+
+	cache := &releaseCacheTransport{
+		previous: previous,
+		current:  make(map[string]cachedReleaseResponse),
+	}
+	info, err := a.checkForUpdates(cache)
+
+	interval := time.Duration(defaultUpdateCheckHours) * time.Hour
+	if settings, settingsErr := a.GetSettings(); settingsErr == nil {
+		interval = updateCheckInterval(settings.Update)
+	}
+
+	var notify bool
+	if mock == nil {
+		mock = runningMockUpdate()
+	}
+	if mock != nil {
+		mock.updateCheckStatus(func(status *UpdateCheckStatus) {
+			notify = recordUpdateCheck(status, info, err, interval, background)
+		})
+	} else {
+		// Load the state again, other checks may have saved it meanwhile
+		updateCheckMutex.Lock()
+		state := a.loadUpdateCheckState()
+		notify = recordUpdateCheck(&state.UpdateCheckStatus, info, err, interval, background)
+		if err == nil {
+			state.Responses = cache.current
+		}
+		a.saveUpdateCheckState(state)
+		updateCheckMutex.Unlock()
+	}
+
+	if notify {
+		a.emitEvent(eventUpdateAvailable, info)
+	}
+	return info
+}
+
+// recordUpdateCheck updates the status with the outcome of a check and
+// reports whether a background check should announce the new version
+func recordUpdateCheck(status *UpdateCheckStatus, info UpdateInfo, err error, interval time.Duration, background bool) bool {
+	now := time.Now()
+	status.LastCheckedAt = now
+	if err != nil {
+		status.Failures++
+		status.LastError = err.Error()
+		status.NextCheckAt = now.Add(updateCheckBackoff(status.Failures, interval, err))
+	} else {
+		status.Failures = 0
+		status.LastError = ""
+		status.LastResult = &info
+		status.NextCheckAt = now.Add(interval)
+	}
+
+	notify := background && info.HasUpdate && info.UpdateURL != "" && info.LatestVersion != status.NotifiedVersion
+	if notify {
+		status.NotifiedVersion = info.LatestVersion
+	}
+	return notify
+}
+
+// updateCheckInterval returns the time between background checks
+func updateCheckInterval(settings UpdateSettings) time.Duration {
+	hours := settings.CheckIntervalHours
+	if hours <= 0 {
+		hours = defaultUpdateCheckHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// updateCheckBackoff doubles the wait after each failed check up to the
+// check interval, and waits at least until a rate limit resets
+func updateCheckBackoff(failures int, interval time.Duration, err error) time.Duration {
+	delay := interval
+	if failures < 10 {
+		if backoff := updateCheckMinBackoff << (failures - 1); backoff < interval {
+			delay = backoff
+		}
+	}
+
+	var limited *updateRateLimitError
+	if errors.As(err, &limited) && !limited.RetryAt.IsZero() {
+		if wait := time.Until(limited.RetryAt); wait > delay {
+			delay = wait
+		}
+	}
+	return delay
+}
+
+// startUpdateCheckScheduler checks for updates in the background while
+// automatic checks are enabled
+func (a *App) startUpdateCheckScheduler(ctx context.Context) {
+	go func() {
+		// Give the app time to finish starting before the first check
+		timer := time.NewTimer(updateCheckStartDelay)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			timer.Reset(a.runScheduledUpdateCheck())
+		}
+	}()
+}
+
+// runScheduledUpdateCheck checks for updates when a check is due and
+// returns how long to sleep before looking again
+func (a *App) runScheduledUpdateCheck() time.Duration {
+	settings, err := a.GetSettings()
+	if err != nil || !settings.Update.AutoCheck {
+		return updateCheckMaxSleep
+	}
+
+	status := a.GetUpdateCheckStatus()
+	due := status.NextCheckAt
+	if due.IsZero() && !status.LastCheckedAt.IsZero() {
+		due = status.LastCheckedAt.Add(updateCheckInterval(settings.Update))
+	}
+	if wait := time.Until(due); wait > 0 {
+		return min(wait, updateCheckMaxSleep)
+	}
+
+	a.runUpdateCheck(true)
+	return min(time.Until(a.GetUpdateCheckStatus().NextCheckAt), updateCheckMaxSleep)
+}
+
+// releaseCacheTransport makes conditional requests with the ETag and
+// Last-Modified of earlier responses and answers 304 Not Modified from the
+// cache, so that unchanged release lists do not count against API rate
+// limits
+type releaseCacheTransport struct {
+	base     http.RoundTripper
+	previous map[string]cachedReleaseResponse // Loaded from update-check.json
+
+	mutex   sync.Mutex
+	current map[string]cachedReleaseResponse // Responses of this check
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *releaseCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet {
+		return base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	cached, ok := t.previous[key]
+	if ok {
+		// A RoundTripper must not modify the caller's request
+		req = req.Clone(req.Context())
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		resp.Body.Close()
+		t.store(key, cached)
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.Body = io.NopCloser(bytes.NewReader(cached.Body))
+		resp.ContentLength = int64(len(cached.Body))
+
+	case resp.StatusCode == http.StatusOK:
+		entry := cachedReleaseResponse{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		if entry.ETag == "" && entry.LastModified == "" {
+			break
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, updateCacheMaxSize+1))
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if len(body) <= updateCacheMaxSize {
+			entry.Body = body
+			t.store(key, entry)
+		}
+	}
+	return resp, nil
+}
+
+// store records a response of this check
+func (t *releaseCacheTransport) store(key string, entry cachedReleaseResponse) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.current[key] = entry
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateCheckBackoff(t *testing.T) {
+	interval := 24 * time.Hour
+	tests := []struct {
+		name     string
+		failures int
+		interval time.Duration
+		err      error
+		want     time.Duration
+	}{
+		{name: "first failure", failures: 1, interval: interval, want: updateCheckMinBackoff},
+		{name: "second failure", failures: 2, interval: interval, want: 2 * updateCheckMinBackoff},
+		{name: "fifth failure", failures: 5, interval: interval, want: 16 * updateCheckMinBackoff},
+		{name: "seventh failure", failures: 7, interval: interval, want: 64 * updateCheckMinBackoff},
+		{name: "capped at the interval", failures: 8, interval: interval, want: interval},
+		{name: "many failures", failures: 200, interval: interval, want: interval},
+		{name: "short interval", failures: 1, interval: 10 * time.Minute, want: 10 * time.Minute},
+		{
+			name: "rate limit resets later", failures: 1, interval: interval,
+			err:  &updateRateLimitError{RetryAt: time.Now().Add(3 * time.Hour)},
+			want: 3 * time.Hour,
+		},
+		{
+			name: "rate limit beyond the interval", failures: 1, interval: interval,
+			err:  &updateRateLimitError{RetryAt: time.Now().Add(30 * time.Hour)},
+			want: 30 * time.Hour,
+		},
+		{
+			name: "rate limit already reset", failures: 2, interval: interval,
+			err:  &updateRateLimitError{RetryAt: time.Now().Add(-time.Hour)},
+			want: 2 * updateCheckMinBackoff,
+		},
+		{
+			name: "rate limit without a reset time", failures: 1, interval: interval,
+			err:  &updateRateLimitError{},
+			want: updateCheckMinBackoff,
+		},
+		{name: "other error", failures: 3, interval: interval, err: errors.New("offline"), want: 4 * updateCheckMinBackoff},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := updateCheckBackoff(test.failures, test.interval, test.err)
+			// Waits until a reset time are computed from the current time
+			if got > test.want || got < test.want-5*time.Second {
+				t.Errorf("updateCheckBackoff(%d, %v) = %v, want %v", test.failures, test.interval, got, test.want)
+			}
+		})
+	}
+}
+
+// releaseListServer serves a release list that can change, answering
+// conditional requests with 304 Not Modified
+type releaseListServer struct {
+	*httptest.Server
+
+	mutex        sync.Mutex
+	body         string
+	etag         string
+	lastModified string
+	conditional  []string // Validators received with each request
+}
+
+func newReleaseListServer(t *testing.T) *releaseListServer {
+	s := &releaseListServer{body: `[{"tag_name": "v1.0.0"}]`, etag: `"r1"`}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.conditional = append(s.conditional, r.Header.Get("If-None-Match")+"|"+r.Header.Get("If-Modified-Since"))
+		if s.etag != "" {
+			w.Header().Set("ETag", s.etag)
+		}
+		if s.lastModified != "" {
+			w.Header().Set("Last-Modified", s.lastModified)
+		}
+		if (s.etag != "" && r.Header.Get("If-None-Match") == s.etag) ||
+			(s.etag == "" && s.lastModified != "" && r.Header.Get("If-Modified-Since") == s.lastModified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		io.WriteString(w, s.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// get requests the release list through a cache of the previous responses
+// and returns the body and the responses to keep
+func (s *releaseListServer) get(t *testing.T, previous map[string]cachedReleaseResponse) (string, map[string]cachedReleaseResponse) {
+	transport := &releaseCacheTransport{
+		base:     s.Client().Transport,
+		previous: previous,
+		current:  make(map[string]cachedReleaseResponse),
+	}
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/releases", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if req.Header.Get("If-None-Match") != "" {
+		t.Error("the caller's request was modified")
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ContentLength >= 0 && resp.ContentLength != int64(len(body)) {
+		t.Errorf("content length = %d, body has %d bytes", resp.ContentLength, len(body))
+	}
+	return string(body), transport.current
+}
+
+// lastConditional returns the validators of the latest request
+func (s *releaseListServer) lastConditional() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.conditional[len(s.conditional)-1]
+}
+
+func TestReleaseCacheTransport(t *testing.T) {
+	server := newReleaseListServer(t)
+	key := server.URL + "/releases"
+
+	// The first check stores the list with its ETag
+	body, cache := server.get(t, nil)
+	if body != server.body || server.lastConditional() != "|" {
+		t.Fatalf("first check: body %q, validators %q", body, server.lastConditional())
+	}
+	if entry := cache[key]; entry.ETag != `"r1"` || string(entry.Body) != server.body {
+		t.Fatalf("cached %+v", entry)
+	}
+
+	// An unchanged list is answered with 304 and replayed from the cache
+	body, cache = server.get(t, cache)
+	if server.lastConditional() != `"r1"|` {
+		t.Errorf("validators = %q, want the ETag", server.lastConditional())
+	}
+	if body != `[{"tag_name": "v1.0.0"}]` {
+		t.Errorf("replayed body = %q", body)
+	}
+	if _, ok := cache[key]; !ok {
+		t.Fatal("replayed response dropped from the cache")
+	}
+
+	// A changed list replaces the cached one
+	server.mutex.Lock()
+	server.body, server.etag = `[{"tag_name": "v1.1.0"}]`, `"r2"`
+	server.mutex.Unlock()
+	body, cache = server.get(t, cache)
+	if !strings.Contains(body, "v1.1.0") || cache[key].ETag != `"r2"` || string(cache[key].Body) != body {
+		t.Errorf("changed list: body %q, cached %+v", body, cache[key])
+	}
+
+	// Last-Modified alone is used as a validator as well
+	server.mutex.Lock()
+	server.etag, server.lastModified = "", "Mon, 01 Jan 2024 00:00:00 GMT"
+	server.mutex.Unlock()
+	_, cache = server.get(t, cache)
+	body, cache = server.get(t, cache)
+	if server.lastConditional() != "|Mon, 01 Jan 2024 00:00:00 GMT" || !strings.Contains(body, "v1.1.0") {
+		t.Errorf("validators = %q, body %q", server.lastConditional(), body)
+	}
+
+	// Responses without validators are not cached
+	server.mutex.Lock()
+	server.lastModified = ""
+	server.mutex.Unlock()
+	if _, cache = server.get(t, cache); len(cache) != 0 {
+		t.Errorf("cached %+v, want nothing", cache)
+	}
+
+	// A 304 for a URL that is not cached is passed on unchanged
+	transport := &releaseCacheTransport{base: http.DefaultTransport, current: make(map[string]cachedReleaseResponse)}
+	notModified := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer notModified.Close()
+	resp, err := (&http.Client{Transport: transport}).Get(notModified.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("status = %d, want 304", resp.StatusCode)
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// updateDownloadAttempts is how often a dropped download is resumed
+// before giving up
+const updateDownloadAttempts = 5
+
+var (
+	// State of the running update, shared with CancelUpdate
+	updateRunMutex   sync.Mutex
+	updateCancel     context.CancelFunc
+	updateInstalling bool
+)
+
+// errUpdateRunning is returned when an update is started twice
+var errUpdateRunning = errors.New("更新正在进行中")
+
+// stagedDownload is stored next to a partial download so that it can be
+// resumed only while the file on the server is unchanged
+type stagedDownload struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Total        int64  `json:"total"` // Zero while unknown
+}
+
+// updateStagingDir returns the directory update downloads are staged in
+func (a *App) updateStagingDir() string {
+	return filepath.Join(a.GetDataDir(), "updates")
+}
+
+// CancelUpdate stops the running update download. The partial file is
+// kept and resumed by the next download of the same update.
+func (a *App) CancelUpdate() error {
+	updateRunMutex.Lock()
+	defer updateRunMutex.Unlock()
+
+	if updateCancel == nil {
+		return fmt.Errorf("没有正在进行的更新")
+	}
+	if updateInstalling {
+		return fmt.Errorf("正在安装更新，无法取消")
+	}
+	updateCancel()
+	return nil
+}
+
+// beginUpdate registers a new update run and returns its context
+func beginUpdate() (context.Context, error) {
+	updateRunMutex.Lock()
+	defer updateRunMutex.Unlock()
+
+	if updateCancel != nil {
+		return nil, errUpdateRunning
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	updateCancel = cancel
+	updateInstalling = false
+	return ctx, nil
+}
+
+// endUpdate releases the update run registered by beginUpdate
+func endUpdate() {
+	updateRunMutex.Lock()
+	defer updateRunMutex.Unlock()
+
+	if updateCancel != nil {
+		updateCancel()
+	}
+	updateCancel = nil
+	updateInstalling = false
+}
+
+// startUpdateInstall marks the point after which the update can no longer
+// be cancelled; it fails if it was cancelled before
+func startUpdateInstall(ctx context.Context) error {
+	updateRunMutex.Lock()
+	defer updateRunMutex.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	updateInstalling = true
+	return nil
+}
+
+// stagedDownloadPaths returns the partial file and its metadata for a URL
+func stagedDownloadPaths(dir, fileURL string) (string, string) {
+	sum := sha1.Sum([]byte(fileURL))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(dir, name+".part"), filepath.Join(dir, name+".json")
+}
+
+// removeStagedDownload deletes the staged files of a URL
+func removeStagedDownload(dir, fileURL string) {
+	partPath, metaPath := stagedDownloadPaths(dir, fileURL)
+	os.Remove(partPath)
+	os.Remove(metaPath)
+}
+
+// pruneStagedDownloads deletes staged downloads of other URLs, e.g. of an
+// update that has since been superseded
+func pruneStagedDownloads(dir, keepURL string) {
+	keepPart, keepMeta := stagedDownloadPaths(dir, keepURL)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || path == keepPart || path == keepMeta {
+			continue
+		}
+		if strings.HasSuffix(path, ".part") || strings.HasSuffix(path, ".json") {
+			os.Remove(path)
+		}
+	}
+}
+
+// readStagedDownload loads the metadata of a partial download
+func readStagedDownload(metaPath string) stagedDownload {
+	var meta stagedDownload
+	if data, err := os.ReadFile(metaPath); err == nil {
+		json.Unmarshal(data, &meta)
+	}
+	return meta
+}
+
+// writeStagedDownload saves the metadata of a partial download
+func writeStagedDownload(metaPath string, meta stagedDownload) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(metaPath, data, 0644)
+}
+
+// downloadUpdateFile downloads fileURL into dir and returns the path of the
+// complete file. A partial file left by an earlier run is continued with
+// an HTTP Range request, and dropped connections are resumed the same way.
+// onProgress receives the bytes on disk and the total, zero if unknown.
+func downloadUpdateFile(ctx context.Context, client *http.Client, fileURL, dir string, onProgress func(downloaded, total int64)) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("无法创建下载目录: %v", err)
+	}
+	pruneStagedDownloads(dir, fileURL)
+	partPath, metaPath := stagedDownloadPaths(dir, fileURL)
+
+	var lastErr error
+	for attempt := 0; attempt < updateDownloadAttempts; attempt++ {
+		if attempt > 0 {
+			// Back off 2s, 4s, 8s... before resuming
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(time.Duration(1<<attempt) * time.Second):
+			}
+		}
+
+		retry, err := downloadUpdateAttempt(ctx, client, fileURL, partPath, metaPath, onProgress)
+		if err == nil {
+			return partPath, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if !retry {
+			return "", err
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// downloadUpdateAttempt makes one request for the rest of the file and
+// reports whether a failure is worth retrying
+func downloadUpdateAttempt(ctx context.Context, client *http.Client, fileURL, partPath, metaPath string, onProgress func(downloaded, total int64)) (bool, error) {
+	meta := readStagedDownload(metaPath)
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil && meta.URL == fileURL {
+		offset = info.Size()
+	}
+	if meta.URL != fileURL {
+		meta = stagedDownload{URL: fileURL}
+	}
+
+	// Without a validator the server cannot tell whether the file changed
+	validator := firstNonEmpty(meta.ETag, meta.LastModified)
+	if validator == "" || (meta.Total > 0 && offset > meta.Total) {
+		offset = 0
+	}
+	if meta.Total > 0 && offset == meta.Total {
+		onProgress(offset, meta.Total)
+		return false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("无效的更新URL: %v", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("网络连接失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var file *os.File
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			os.Remove(partPath)
+			return true, fmt.Errorf("服务器返回的续传范围无效")
+		}
+		if total > 0 {
+			meta.Total = total
+		}
+		file, err = os.OpenFile(partPath, os.O_WRONLY|os.O_APPEND, 0644)
+
+	case resp.StatusCode == http.StatusOK:
+		// A full response: the server ignored the range or the file changed
+		offset = 0
+		meta = stagedDownload{
+			URL:          fileURL,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		if resp.ContentLength > 0 {
+			meta.Total = resp.ContentLength
+		}
+		file, err = os.Create(partPath)
+
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		os.Remove(partPath)
+		os.Remove(metaPath)
+		return true, fmt.Errorf("续传失败，将重新下载")
+
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("HTTP状态码: %d", resp.StatusCode)
+
+	default:
+		return false, fmt.Errorf("HTTP状态码: %d", resp.StatusCode)
+	}
+	if err != nil {
+		return false, fmt.Errorf("无法写入下载文件: %v", err)
+	}
+	defer file.Close()
+
+	if err := writeStagedDownload(metaPath, meta); err != nil {
+		return false, fmt.Errorf("无法写入下载文件: %v", err)
+	}
+
+	onProgress(offset, meta.Total)
+	progressReader := &ProgressReader{
+		reader:     resp.Body,
+		total:      meta.Total,
+		downloaded: offset,
+		onProgress: onProgress,
+	}
+	written, err := io.Copy(file, progressReader)
+	if err != nil {
+		return true, fmt.Errorf("下载中断: %v", err)
+	}
+
+	size := offset + written
+	if meta.Total > 0 && size != meta.Total {
+		return true, fmt.Errorf("下载不完整: %s / %s", formatBytes(size), formatBytes(meta.Total))
+	}
+	if meta.Total == 0 {
+		// Remember the size so that a finished file is not fetched again
+		meta.Total = size
+		writeStagedDownload(metaPath, meta)
+	}
+	return false, nil
+}
+
+// parseContentRange parses "bytes <start>-<end>/<total>"; total is zero
+// when the server sends "*"
+func parseContentRange(value string) (int64, int64, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(value), "bytes ")
+	if !ok {
+		return 0, 0, false
+	}
+	span, size, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, 0, false
+	}
+	first, _, ok := strings.Cut(span, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if size == "*" {
+		return start, 0, true
+	}
+	total, err := strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
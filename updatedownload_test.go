@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// updateFileServer serves content with an ETag through http.ServeContent,
+// which answers Range and If-Range requests. The handler can be replaced
+// to simulate misbehaving servers; requests records the Range headers.
+type updateFileServer struct {
+	*httptest.Server
+	content []byte
+	etag    string
+
+	mu       sync.Mutex
+	handler  http.HandlerFunc
+	requests []http.Header
+}
+
+func newUpdateFileServer(t *testing.T, content []byte) *updateFileServer {
+	s := &updateFileServer{content: content, etag: `"v1"`}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Header.Clone())
+		handler := s.handler
+		s.mu.Unlock()
+
+		if handler != nil {
+			handler(w, r)
+			return
+		}
+		w.Header().Set("ETag", s.etag)
+		http.ServeContent(w, r, "update.bin", time.Time{}, bytes.NewReader(s.content))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// setHandler replaces the handler and forgets earlier requests
+func (s *updateFileServer) setHandler(handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = handler
+	s.requests = nil
+}
+
+// received returns the headers of the requests so far
+func (s *updateFileServer) received() []http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]http.Header(nil), s.requests...)
+}
+
+// stagePartialDownload writes a partial file and its metadata as left by
+// an interrupted download
+func stagePartialDownload(t *testing.T, dir, fileURL string, data []byte, meta stagedDownload) (string, string) {
+	partPath, metaPath := stagedDownloadPaths(dir, fileURL)
+	if err := os.WriteFile(partPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeStagedDownload(metaPath, meta); err != nil {
+		t.Fatal(err)
+	}
+	return partPath, metaPath
+}
+
+func TestDownloadUpdateAttempt(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	changed := []byte(strings.Repeat("abcdefghij", 120))
+
+	tests := []struct {
+		name      string
+		staged    []byte // Partial file left by an earlier run
+		meta      stagedDownload
+		serve     func(s *updateFileServer) http.HandlerFunc
+		serverNew bool // The file on the server has changed
+		wantRange string
+		wantRetry bool
+		wantErr   string
+		want      []byte
+		wantTotal int64
+		wantGone  bool // The partial file was discarded
+		noLength  bool // Progress is reported without a total
+	}{
+		{
+			name:      "fresh download",
+			want:      content,
+			wantTotal: int64(len(content)),
+		},
+		{
+			name:      "resume with If-Range",
+			staged:    content[:300],
+			meta:      stagedDownload{ETag: `"v1"`, Total: int64(len(content))},
+			wantRange: "bytes=300-",
+			want:      content,
+			wantTotal: int64(len(content)),
+		},
+		{
+			name:      "resume by Last-Modified",
+			staged:    content[:300],
+			meta:      stagedDownload{LastModified: "Mon, 01 Jan 2024 00:00:00 GMT"},
+			wantRange: "bytes=300-",
+			// The validator does not match, so the whole file is sent again
+			want:      content,
+			wantTotal: int64(len(content)),
+		},
+		{
+			name:      "file changed since the partial download",
+			staged:    content[:300],
+			meta:      stagedDownload{ETag: `"v1"`, Total: int64(len(content))},
+			serverNew: true,
+			wantRange: "bytes=300-",
+			want:      changed,
+			wantTotal: int64(len(changed)),
+		},
+		{
+			name:   "no validator starts over",
+			staged: content[:300],
+			meta:   stagedDownload{Total: int64(len(content))},
+			want:   content, wantTotal: int64(len(content)),
+		},
+		{
+			name:      "partial file larger than the total starts over",
+			staged:    append(append([]byte{}, content...), 'x'),
+			meta:      stagedDownload{ETag: `"v1"`, Total: int64(len(content))},
+			want:      content,
+			wantTotal: int64(len(content)),
+		},
+		{
+			name:      "already complete",
+			staged:    content,
+			meta:      stagedDownload{ETag: `"v1"`, Total: int64(len(content))},
+			want:      content,
+			wantTotal: int64(len(content)),
+		},
+		{
+			name:   "range ignored by the server",
+			staged: content[:300],
+			meta:   stagedDownload{ETag: `"v1"`, Total: int64(len(content))},
+			serve: func(s *updateFileServer) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("ETag", s.etag)
+					w.Write(s.content)
+				}
+			},
+			wantRange: "bytes=300-",
+			want:      content,
+			wantTotal: int64(len(content)),
+		},
+		{
+			name:   "unknown length",
+			staged: nil,
+			serve: func(s *updateFileServer) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("ETag", s.etag)
+					w.Write(s.content[:500])
+					w.(http.Flusher).Flush()
+					w.Write(s.content[500:])
+				}
+			},
+			want:      content,
+			wantTotal: int64(len(content)),
+			noLength:  true,
+		},
+		{
+			name:   "wrong Content-Range offset",
+			staged: content[:300],
+			meta:   stagedDownload{ETag: `"v1"`, Total: int64(len(content))},
+			serve: func(s *updateFileServer) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Range", "bytes 200-999/1000")
+					w.WriteHeader(http.StatusPartialContent)
+					w.Write(s.content[200:])
+				}
+			},
+			wantRange: "bytes=300-",
+			wantRetry: true,
+			wantErr:   "续传范围无效",
+			wantGone:  true,
+		},
+		{
+			name:   "range not satisfiable",
+			staged: content[:300],
+			meta:   stagedDownload{ETag: `"v1"`, Total: int64(len(content))},
+			serve: func(s *updateFileServer) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				}
+			},
+			wantRange: "bytes=300-",
+			wantRetry: true,
+			wantErr:   "将重新下载",
+			wantGone:  true,
+		},
+		{
+			name: "connection dropped",
+			serve: func(s *updateFileServer) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("ETag", s.etag)
+					w.Header().Set("Content-Length", "1000")
+					w.Write(s.content[:400])
+				}
+			},
+			wantRetry: true,
+			wantErr:   "下载",
+			want:      content[:400],
+			wantTotal: int64(len(content)),
+		},
+		{
+			name: "server error",
+			serve: func(s *updateFileServer) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			},
+			wantRetry: true,
+			wantErr:   "503",
+		},
+		{
+			name: "not found",
+			serve: func(s *updateFileServer) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					http.NotFound(w, r)
+				}
+			},
+			wantErr: "404",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newUpdateFileServer(t, content)
+			if test.serverNew {
+				server.content, server.etag = changed, `"v2"`
+			}
+			if test.serve != nil {
+				server.setHandler(test.serve(server))
+			}
+
+			dir := t.TempDir()
+			fileURL := server.URL + "/update.bin"
+			partPath, metaPath := stagedDownloadPaths(dir, fileURL)
+			if test.staged != nil {
+				test.meta.URL = fileURL
+				stagePartialDownload(t, dir, fileURL, test.staged, test.meta)
+			}
+
+			var lastDownloaded, lastTotal int64
+			retry, err := downloadUpdateAttempt(context.Background(), server.Client(), fileURL, partPath, metaPath, func(downloaded, total int64) {
+				lastDownloaded, lastTotal = downloaded, total
+			})
+			if retry != test.wantRetry {
+				t.Errorf("retry = %v, want %v", retry, test.wantRetry)
+			}
+			if test.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+				t.Fatalf("error = %v, want %q", err, test.wantErr)
+			}
+
+			requests := server.received()
+			if test.staged != nil && test.wantRange != "" {
+				if len(requests) == 0 {
+					t.Fatal("no request made")
+				}
+				header := requests[0]
+				if header.Get("Range") != test.wantRange || header.Get("If-Range") == "" {
+					t.Errorf("Range = %q, If-Range = %q, want %q with a validator", header.Get("Range"), header.Get("If-Range"), test.wantRange)
+				}
+			}
+			if test.wantRange == "" {
+				for _, header := range requests {
+					if header.Get("Range") != "" {
+						t.Errorf("unexpected Range %q", header.Get("Range"))
+					}
+				}
+			}
+
+			data, readErr := os.ReadFile(partPath)
+			if test.wantGone {
+				if !os.IsNotExist(readErr) {
+					t.Errorf("partial file kept (%v)", readErr)
+				}
+				return
+			}
+			if test.want == nil {
+				return
+			}
+			if !bytes.Equal(data, test.want) {
+				t.Errorf("file has %d bytes, want %d", len(data), len(test.want))
+			}
+			meta := readStagedDownload(metaPath)
+			if meta.URL != fileURL || meta.Total != test.wantTotal {
+				t.Errorf("metadata = %+v, want total %d", meta, test.wantTotal)
+			}
+			wantProgressTotal := test.wantTotal
+			if test.noLength {
+				wantProgressTotal = 0
+			}
+			if err == nil && (lastDownloaded != int64(len(test.want)) || lastTotal != wantProgressTotal) {
+				t.Errorf("last progress = %d / %d, want %d / %d", lastDownloaded, lastTotal, len(test.want), wantProgressTotal)
+			}
+		})
+	}
+}
+
+func TestDownloadUpdateFileCancelKeepsPartialFile(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+	server := newUpdateFileServer(t, content)
+
+	// The first response stalls half way; the download is cancelled once
+	// part of it is on disk
+	ctx, cancel := context.WithCancel(context.Background())
+	server.setHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", server.etag)
+		w.Header().Set("Content-Length", "10000")
+		w.Write(content[:4000])
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	dir := t.TempDir()
+	fileURL := server.URL + "/update.bin"
+	_, err := downloadUpdateFile(ctx, server.Client(), fileURL, dir, func(downloaded, total int64) {
+		if downloaded > 0 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+
+	partPath, metaPath := stagedDownloadPaths(dir, fileURL)
+	info, err := os.Stat(partPath)
+	if err != nil {
+		t.Fatalf("partial file removed: %v", err)
+	}
+	if info.Size() == 0 || info.Size() > 4000 {
+		t.Fatalf("partial file has %d bytes, want up to 4000", info.Size())
+	}
+
+	// The next download continues where the cancelled one stopped
+	server.setHandler(nil)
+	path, err := downloadUpdateFile(context.Background(), server.Client(), fileURL, dir, func(downloaded, total int64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != partPath {
+		t.Errorf("path = %q, want %q", path, partPath)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, content) {
+		t.Errorf("resumed file has %d bytes, want %d", len(data), len(content))
+	}
+	if got := server.received()[0].Get("Range"); got != "bytes="+strconv.FormatInt(info.Size(), 10)+"-" {
+		t.Errorf("Range = %q, want to resume at %d", got, info.Size())
+	}
+	if _, err := os.Stat(metaPath); err != nil {
+		t.Errorf("metadata missing: %v", err)
+	}
+
+	// Staged downloads of other URLs are pruned
+	otherPart, _ := stagedDownloadPaths(dir, server.URL+"/old.bin")
+	if err := os.WriteFile(otherPart, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := downloadUpdateFile(context.Background(), server.Client(), fileURL, dir, func(downloaded, total int64) {}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(otherPart); !os.IsNotExist(err) {
+		t.Errorf("staged file of another URL kept (%v)", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("staging directory has %d files, want 2", len(entries))
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value       string
+		start, size int64
+		ok          bool
+	}{
+		{value: "bytes 100-199/1000", start: 100, size: 1000, ok: true},
+		{value: " bytes 0-0/*", start: 0, size: 0, ok: true},
+		{value: "bytes */1000"},
+		{value: "items 0-1/2"},
+		{value: "bytes 1-2"},
+		{value: "bytes x-2/3"},
+		{value: "bytes 1-2/y"},
+	}
+
+	for _, test := range tests {
+		start, size, ok := parseContentRange(test.value)
+		if start != test.start || size != test.size || ok != test.ok {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v, want %d, %d, %v", test.value, start, size, ok, test.start, test.size, test.ok)
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Failures the mock update server can simulate
+const (
+	mockFailureNone       = ""
+	mockFailureCheck      = "check_error"    // The manifest request fails with 500
+	mockFailureRateLimit  = "rate_limit"     // The manifest request is rate limited
+	mockFailureDownload   = "download_error" // Every download fails with 500
+	mockFailureDisconnect = "disconnect"     // The first download breaks off halfway
+	mockFailureChecksum   = "checksum"       // The manifest lists a wrong SHA-256
+	mockFailureSignature  = "signature"      // The signature does not match
+)
+
+// mockUpdatesEnv starts the mock update server at launch in dev and debug
+// builds. Its value is "1" or one of the failure modes.
+const mockUpdatesEnv = "URLNAVIGATOR_MOCK_UPDATES"
+
+// Download speed of the mock update server, slow enough to watch the
+// progress of a typical executable
+const (
+	defaultMockBytesPerSecond = 2 * 1024 * 1024
+	mockChunkSize             = 32 * 1024
+)
+
+// MockUpdateOptions configure the mock update server
+type MockUpdateOptions struct {
+	HasUpdate         bool   `json:"hasUpdate"`         // Offer a newer version; otherwise only the current one is listed
+	Version           string `json:"version"`           // Offered version, defaults to the next minor version
+	LatencyMs         int    `json:"latencyMs"`         // Delay before every response
+	BytesPerSecond    int64  `json:"bytesPerSecond"`    // Download speed; 0 for the default, negative for unlimited
+	OmitContentLength bool   `json:"omitContentLength"` // Send downloads chunked, without size or resume support
+	Failure           string `json:"failure"`           // One of the simulated failures, empty for none
+}
+
+// MockUpdateServerStatus describes the running mock update server
+type MockUpdateServerStatus struct {
+	Available bool              `json:"available"` // Dev or debug build
+	Running   bool              `json:"running"`
+	URL       string            `json:"url,omitempty"` // Manifest URL
+	Options   MockUpdateOptions `json:"options"`
+}
+
+// mockUpdateServer serves a synthetic release on localhost. The offered
+// "update" is a copy of the running executable, signed with a key created
+// for the session, so that download, verification, installation and
+// restart all run for real.
+type mockUpdateServer struct {
+	options    MockUpdateOptions
+	server     *http.Server
+	baseURL    string
+	publicKey  string
+	privateKey ed25519.PrivateKey
+	binary     []byte
+	assetName  string
+	etag       string
+
+	// disconnected is set once the disconnect failure was simulated, so
+	// that the resumed download succeeds
+	disconnected atomic.Bool
+
+	// Versions skipped while the server runs; they are kept here rather
+	// than in settings.json, where they would hide real releases
+	skippedMutex sync.Mutex
+	skipped      []string
+
+	// Outcome of update checks while the server runs, likewise kept out
+	// of update-check.json
+	checkMutex  sync.Mutex
+	checkStatus UpdateCheckStatus
+}
+
+var (
+	// The running mock update server, nil when updates come from the
+	// configured source
+	mockUpdateMutex sync.Mutex
+	mockUpdate      *mockUpdateServer
+)
+
+// mockUpdatesAllowed reports whether the mock update server may run, which
+// is only in dev and debug builds
+func (a *App) mockUpdatesAllowed() bool {
+	return mockUpdatesBuild
+}
+
+// startMockUpdateServerFromEnv starts the mock update server at launch
+// when it is enabled through the environment
+func (a *App) startMockUpdateServerFromEnv() {
+	value := strings.TrimSpace(os.Getenv(mockUpdatesEnv))
+	if value == "" || !a.mockUpdatesAllowed() {
+		return
+	}
+	options := MockUpdateOptions{HasUpdate: true}
+	if value != "1" && !strings.EqualFold(value, "true") {
+		options.Failure = value
+	}
+	if status, err := a.StartMockUpdateServer(options); err != nil {
+		fmt.Printf("警告: 模拟更新服务器启动失败: %v\n", err)
+	} else {
+		fmt.Printf("模拟更新服务器: %s\n", status.URL)
+	}
+}
+
+// StartMockUpdateServer serves a synthetic release on localhost and checks
+// for updates there instead of the configured source until
+// StopMockUpdateServer is called
+func (a *App) StartMockUpdateServer(options MockUpdateOptions) (MockUpdateServerStatus, error) {
+	if !a.mockUpdatesAllowed() {
+		return MockUpdateServerStatus{}, fmt.Errorf("模拟更新服务器仅在调试模式下可用")
+	}
+	switch options.Failure {
+	case mockFailureNone, mockFailureCheck, mockFailureRateLimit, mockFailureDownload,
+		mockFailureDisconnect, mockFailureChecksum, mockFailureSignature:
+	default:
+		return MockUpdateServerStatus{}, fmt.Errorf("未知的模拟失败类型: %s", options.Failure)
+	}
+	if options.BytesPerSecond == 0 {
+		options.BytesPerSecond = defaultMockBytesPerSecond
+	}
+	if options.Version == "" {
+		options.Version = nextMockVersion(a.GetCurrentVersion())
+	}
+	options.Version = strings.TrimPrefix(options.Version, "v")
+	if _, ok := parseSemVer(options.Version); !ok {
+		return MockUpdateServerStatus{}, fmt.Errorf("无效的版本号: %s", options.Version)
+	}
+
+	// The update is the running executable, so installing it is harmless
+	targetPath, err := updateTargetPath()
+	if err != nil {
+		return MockUpdateServerStatus{}, fmt.Errorf("无法获取可执行文件路径: %v", err)
+	}
+	binary, err := os.ReadFile(targetPath)
+	if err != nil {
+		return MockUpdateServerStatus{}, fmt.Errorf("无法读取可执行文件: %v", err)
+	}
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return MockUpdateServerStatus{}, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return MockUpdateServerStatus{}, fmt.Errorf("无法启动模拟更新服务器: %v", err)
+	}
+
+	sum := sha256.Sum256(binary)
+	mock := &mockUpdateServer{
+		options:    options,
+		baseURL:    "http://" + listener.Addr().String(),
+		publicKey:  base64.StdEncoding.EncodeToString(publicKey),
+		privateKey: privateKey,
+		binary:     binary,
+		assetName:  filepath.Base(targetPath),
+		etag:       `"` + hex.EncodeToString(sum[:8]) + `"`,
+	}
+	mock.server = &http.Server{Handler: mock.handler(a.GetCurrentVersion())}
+
+	a.StopMockUpdateServer()
+	mockUpdateMutex.Lock()
+	mockUpdate = mock
+	mockUpdateMutex.Unlock()
+
+	go mock.server.Serve(listener)
+	return mock.status(), nil
+}
+
+// StopMockUpdateServer stops the mock update server; later checks use the
+// configured source again
+func (a *App) StopMockUpdateServer() error {
+	mockUpdateMutex.Lock()
+	mock := mockUpdate
+	mockUpdate = nil
+	mockUpdateMutex.Unlock()
+	if mock == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return mock.server.Shutdown(ctx)
+}
+
+// GetMockUpdateServer returns the state of the mock update server
+func (a *App) GetMockUpdateServer() MockUpdateServerStatus {
+	mockUpdateMutex.Lock()
+	defer mockUpdateMutex.Unlock()
+	if mockUpdate == nil {
+		return MockUpdateServerStatus{Available: a.mockUpdatesAllowed()}
+	}
+	return mockUpdate.status()
+}
+
+// runningMockUpdate returns the mock update server, or nil when it is not
+// running
+func runningMockUpdate() *mockUpdateServer {
+	if !mockUpdatesBuild {
+		return nil
+	}
+	mockUpdateMutex.Lock()
+	defer mockUpdateMutex.Unlock()
+	return mockUpdate
+}
+
+// activeUpdateSource returns the mock update server while it runs, and
+// the configured source otherwise. While the mock server runs, the
+// versions skipped during the session replace the saved ones.
+func activeUpdateSource(settings *UpdateSettings) (UpdateSource, error) {
+	if mock := runningMockUpdate(); mock != nil {
+		settings.SkippedVersions = mock.skippedVersions()
+		return &manifestSource{manifestURL: mock.baseURL + "/manifest.json"}, nil
+	}
+	return newUpdateSource(*settings)
+}
+
+// isMockUpdateURL reports whether a download is served by the mock update
+// server. Such updates are not recorded in the update history and what's
+// new.
+func isMockUpdateURL(updateURL string) bool {
+	mock := runningMockUpdate()
+	return mock != nil && strings.HasPrefix(updateURL, mock.baseURL+"/")
+}
+
+// mockUpdatePublicKey returns the key of the mock update server when a
+// signature is served by it. Production builds always use UpdatePublicKey.
+func mockUpdatePublicKey(signatureURL string) (string, bool) {
+	mock := runningMockUpdate()
+	if mock == nil || !strings.HasPrefix(signatureURL, mock.baseURL+"/") {
+		return "", false
+	}
+	return mock.publicKey, true
+}
+
+// skipMockVersion skips a version for the rest of the mock session. It
+// reports false when the mock update server is not running.
+func skipMockVersion(version string) bool {
+	mock := runningMockUpdate()
+	if mock == nil {
+		return false
+	}
+	mock.skippedMutex.Lock()
+	defer mock.skippedMutex.Unlock()
+	for _, skipped := range mock.skipped {
+		if sameVersion(skipped, version) {
+			return true
+		}
+	}
+	mock.skipped = append(mock.skipped, version)
+	return true
+}
+
+// lastCheckStatus returns the outcome of the checks of the mock session
+func (s *mockUpdateServer) lastCheckStatus() UpdateCheckStatus {
+	s.checkMutex.Lock()
+	defer s.checkMutex.Unlock()
+	return s.checkStatus
+}
+
+// updateCheckStatus changes the check status of the mock session
+func (s *mockUpdateServer) updateCheckStatus(update func(status *UpdateCheckStatus)) {
+	s.checkMutex.Lock()
+	defer s.checkMutex.Unlock()
+	update(&s.checkStatus)
+}
+
+// skippedVersions returns the versions skipped during the mock session
+func (s *mockUpdateServer) skippedVersions() []string {
+	s.skippedMutex.Lock()
+	defer s.skippedMutex.Unlock()
+	return append([]string(nil), s.skipped...)
+}
+
+// nextMockVersion returns the next minor version after the current one
+func nextMockVersion(currentVersion string) string {
+	version, ok := parseSemVer(currentVersion)
+	if !ok {
+		return "999.0.0"
+	}
+	return fmt.Sprintf("%d.%d.0", version.Major, version.Minor+1)
+}
+
+// status describes the server
+func (s *mockUpdateServer) status() MockUpdateServerStatus {
+	return MockUpdateServerStatus{Available: true, Running: true, URL: s.baseURL + "/manifest.json", Options: s.options}
+}
+
+// handler serves the manifest, the executable and its signature
+func (s *mockUpdateServer) handler(currentVersion string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		s.serveManifest(w, r, currentVersion)
+	})
+	mux.HandleFunc("/"+s.assetName, s.serveBinary)
+	mux.HandleFunc("/"+s.assetName+".minisig", s.serveSignature)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.options.LatencyMs > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Duration(s.options.LatencyMs) * time.Millisecond):
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// serveManifest lists the offered release and, for a minor update, a
+// patch release in between, so that notes across versions can be seen
+func (s *mockUpdateServer) serveManifest(w http.ResponseWriter, r *http.Request, currentVersion string) {
+	switch s.options.Failure {
+	case mockFailureCheck:
+		http.Error(w, "mock failure", http.StatusInternalServerError)
+		return
+	case mockFailureRateLimit:
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "mock rate limit", http.StatusTooManyRequests)
+		return
+	}
+
+	checksum := sha256.Sum256(s.binary)
+	if s.options.Failure == mockFailureChecksum {
+		checksum = sha256.Sum256(nil)
+	}
+	platforms := map[string]manifestPlatform{
+		platformKeys(currentUpdatePlatform())[0]: {URL: s.assetName, SHA256: hex.EncodeToString(checksum[:])},
+	}
+
+	now := time.Now()
+	var manifest updateManifest
+	if !s.options.HasUpdate {
+		manifest.Releases = []manifestRelease{{
+			Version:     strings.TrimPrefix(currentVersion, "v"),
+			Name:        "模拟版本（当前）",
+			Notes:       "## Features\n- 当前版本\n",
+			PublishedAt: now.Add(-24 * time.Hour),
+			Platforms:   platforms,
+		}}
+	} else {
+		manifest.Releases = []manifestRelease{{
+			Version:     s.options.Version,
+			Name:        "模拟更新 " + s.options.Version,
+			Notes:       "## Breaking Changes\n- 模拟的不兼容变更\n\n## Features\n- feat(update): 模拟的新功能\n- 模拟的改进\n\n## Bug Fixes\n- fix: 模拟的问题修复\n",
+			PublishedAt: now,
+			Platforms:   platforms,
+		}}
+		if current, ok := parseSemVer(currentVersion); ok {
+			patch := fmt.Sprintf("%d.%d.%d", current.Major, current.Minor, current.Patch+1)
+			if compareVersions(patch, s.options.Version) < 0 {
+				manifest.Releases = append(manifest.Releases, manifestRelease{
+					Version:     patch,
+					Name:        "模拟补丁 " + patch,
+					Notes:       "- fix: 中间版本的问题修复\n- feat: 中间版本的新功能\n",
+					PublishedAt: now.Add(-time.Hour),
+					Platforms:   platforms,
+				})
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(manifest)
+}
+
+// serveSignature serves a raw ed25519 signature of the executable
+func (s *mockUpdateServer) serveSignature(w http.ResponseWriter, r *http.Request) {
+	message := s.binary
+	if s.options.Failure == mockFailureSignature {
+		message = []byte("not the update")
+	}
+	w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, message))))
+}
+
+// serveBinary serves the executable at the configured speed, with Range
+// support unless the size is withheld
+func (s *mockUpdateServer) serveBinary(w http.ResponseWriter, r *http.Request) {
+	if s.options.Failure == mockFailureDownload {
+		http.Error(w, "mock failure", http.StatusInternalServerError)
+		return
+	}
+
+	data := s.binary
+	if s.options.OmitContentLength {
+		// Without Content-Length the response is sent chunked
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.Header().Set("ETag", s.etag)
+		w.Header().Set("Accept-Ranges", "bytes")
+
+		status := http.StatusOK
+		if start, ok := parseMockRange(r.Header.Get("Range")); ok && (r.Header.Get("If-Range") == "" || r.Header.Get("If-Range") == s.etag) {
+			if start >= int64(len(data)) {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(data)))
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(data)-1, len(data)))
+			data = data[start:]
+			status = http.StatusPartialContent
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(status)
+	}
+
+	// The first download breaks off halfway; the retry resumes it
+	if s.options.Failure == mockFailureDisconnect && r.Header.Get("Range") == "" && s.disconnected.CompareAndSwap(false, true) {
+		s.writeThrottled(w, r, data[:len(data)/2])
+		panic(http.ErrAbortHandler)
+	}
+	s.writeThrottled(w, r, data)
+}
+
+// writeThrottled writes data in chunks at the configured speed
+func (s *mockUpdateServer) writeThrottled(w http.ResponseWriter, r *http.Request, data []byte) {
+	flusher, _ := w.(http.Flusher)
+	for len(data) > 0 {
+		chunk := data[:min(mockChunkSize, len(data))]
+		data = data[len(chunk):]
+		if _, err := w.Write(chunk); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if s.options.BytesPerSecond > 0 {
+			delay := time.Duration(int64(len(chunk)) * int64(time.Second) / s.options.BytesPerSecond)
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+	}
+}
+
+// parseMockRange reads the start of an open "bytes=N-" range, the only
+// form the updater sends
+func parseMockRange(header string) (int64, bool) {
+	value, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, false
+	}
+	value, ok = strings.CutSuffix(value, "-")
+	if !ok {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(value, 10, 64)
+	return start, err == nil && start >= 0
+}
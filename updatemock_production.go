@@ -0,0 +1,7 @@
+//go:build production && !debug
+
+package main
+
+// mockUpdatesBuild enables the mock update server; it is compiled out of
+// production builds
+const mockUpdatesBuild = false
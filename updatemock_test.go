@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMockUpdatesAreNotPersisted(t *testing.T) {
+	saved := RuntimeVersion
+	t.Cleanup(func() { RuntimeVersion = saved })
+	RuntimeVersion = &VersionInfo{Version: "1.4.0"}
+
+	setTestHome(t)
+	a := NewApp()
+
+	// Saved state of the real source, which the mock session must not touch
+	seeded := updateCheckState{
+		UpdateCheckStatus: UpdateCheckStatus{
+			LastCheckedAt: time.Now().Add(-time.Hour).UTC(),
+			LastResult:    &UpdateInfo{CurrentVersion: "1.4.0", LatestVersion: "1.4.0"},
+			NextCheckAt:   time.Now().Add(time.Hour).UTC(),
+		},
+		Responses: map[string]cachedReleaseResponse{"https://example.com/releases": {ETag: `"real"`, Body: []byte("[]")}},
+	}
+	if err := a.saveUpdateCheckState(seeded); err != nil {
+		t.Fatal(err)
+	}
+	savedState, err := os.ReadFile(a.updateCheckStatePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := a.StartMockUpdateServer(MockUpdateOptions{HasUpdate: true, Version: "9.0.0", BytesPerSecond: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.StopMockUpdateServer() })
+
+	// Skipping a mock version lasts for the mock session only
+	if err := a.SkipUpdateVersion("9.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := a.GetSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settings.Update.SkippedVersions) != 0 {
+		t.Errorf("skipped versions saved: %v", settings.Update.SkippedVersions)
+	}
+	info := a.CheckForUpdates()
+	if info.LatestVersion != "1.4.1" {
+		t.Errorf("offered %q after skipping 9.0.0, want the mock patch release", info.LatestVersion)
+	}
+	if !isMockUpdateURL(info.UpdateURL) || !strings.HasPrefix(status.URL, "http://127.0.0.1:") {
+		t.Errorf("update URL %q is not served by the mock server at %s", info.UpdateURL, status.URL)
+	}
+	if last := a.GetUpdateCheckStatus().LastResult; last == nil || last.UpdateURL != info.UpdateURL {
+		t.Errorf("last result = %+v, want the mock release", last)
+	}
+
+	// Installing a mock update keeps no history, health marker or backup
+	target := filepath.Join(t.TempDir(), "URLNavigator")
+	if err := os.WriteFile(target, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.installUpdateBinary(target, strings.NewReader("new"), nil, "1.4.0", "9.0.0", true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "new" {
+		t.Errorf("target = %q, want the update", data)
+	}
+	for _, path := range []string{a.updateHistoryPath(), a.updateHealthPath(), previousBinaryPath(target)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s written by a mock update", filepath.Base(path))
+		}
+	}
+
+	// Once the server stops, skipping is saved again
+	a.StopMockUpdateServer()
+	if isMockUpdateURL(info.UpdateURL) {
+		t.Error("update URL still treated as mock after the server stopped")
+	}
+	if err := a.SkipUpdateVersion("9.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if settings, _ := a.GetSettings(); len(settings.Update.SkippedVersions) != 1 {
+		t.Errorf("skipped versions = %v, want 9.0.0", settings.Update.SkippedVersions)
+	}
+
+	// Failing mock checks leave no error or backoff behind either
+	for _, failure := range []string{mockFailureCheck, mockFailureRateLimit} {
+		if _, err := a.StartMockUpdateServer(MockUpdateOptions{HasUpdate: true, Failure: failure}); err != nil {
+			t.Fatal(err)
+		}
+		a.runUpdateCheck(true)
+		if status := a.GetUpdateCheckStatus(); status.Failures != 1 || status.LastError == "" {
+			t.Errorf("%s: status = %+v, want the mock failure", failure, status)
+		}
+		a.StopMockUpdateServer()
+	}
+
+	if data, _ := os.ReadFile(a.updateCheckStatePath()); string(data) != string(savedState) {
+		t.Errorf("update-check.json changed by the mock session:\n%s\nwant\n%s", data, savedState)
+	}
+	if status := a.GetUpdateCheckStatus(); status.LastResult == nil || status.LastResult.UpdateURL != "" || status.Failures != 0 {
+		t.Errorf("status after the mock session = %+v, want the saved one", status)
+	}
+}
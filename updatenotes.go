@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Kinds of release note sections, in display order
+const (
+	noteKindBreaking = "breaking"
+	noteKindFeatures = "features"
+	noteKindFixes    = "fixes"
+	noteKindOther    = "other"
+)
+
+// noteKindOrder sorts sections so that breaking changes come first
+var noteKindOrder = map[string]int{
+	noteKindBreaking: 0,
+	noteKindFeatures: 1,
+	noteKindFixes:    2,
+	noteKindOther:    3,
+}
+
+// noteKindKeywords classify section headings, checked in this order.
+// English keywords match whole words, so that "Debugging" is not a bug
+// fix; Chinese ones match anywhere in the heading.
+var noteKindKeywords = []struct {
+	kind     string
+	keywords []string
+}{
+	{noteKindBreaking, []string{"breaking", "incompatible", "incompatibilities", "破坏性", "不兼容", "重大变更"}},
+	{noteKindFixes, []string{"fix", "fixes", "fixed", "bug", "bugs", "bugfix", "bugfixes", "hotfix", "hotfixes", "修复", "问题"}},
+	{noteKindFeatures, []string{
+		"feature", "features", "new", "added", "enhancement", "enhancements", "improvement", "improvements", "improved",
+		"新功能", "新增", "功能", "改进", "优化",
+	}},
+}
+
+var (
+	// A Markdown heading, or a line that is entirely bold, used as a heading
+	noteHeadingPattern = regexp.MustCompile(`^(?:#{1,6}\s+(.+?)\s*#*|\*\*(.+?)\*\*:?|__(.+?)__:?)$`)
+	// A bullet or numbered list item
+	noteItemPattern = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+(.*)$`)
+	// A conventional commit subject such as "feat(ui)!: ..."
+	conventionalPattern = regexp.MustCompile(`^(?i)(feat|fix|perf|refactor|docs|chore|build|ci|style|test|revert)(\([^)]*\))?(!)?:\s*(.+)$`)
+)
+
+// ReleaseNoteSection is one group of changes of a release
+type ReleaseNoteSection struct {
+	Kind  string   `json:"kind"`  // breaking, features, fixes or other
+	Title string   `json:"title"` // First heading as written, empty for ungrouped items
+	Items []string `json:"items"`
+}
+
+// ReleaseNotes are the parsed notes of one release
+type ReleaseNotes struct {
+	Version     string               `json:"version"`
+	Name        string               `json:"name,omitempty"`
+	PublishedAt time.Time            `json:"publishedAt,omitempty"`
+	Prerelease  bool                 `json:"prerelease"`
+	Sections    []ReleaseNoteSection `json:"sections"`
+	Body        string               `json:"body"` // Raw Markdown
+}
+
+// WhatsNew is shown once after an update was installed
+type WhatsNew struct {
+	FromVersion string         `json:"fromVersion"`
+	Version     string         `json:"version"`
+	Notes       []ReleaseNotes `json:"notes"` // Newest first
+}
+
+// releaseNotesBetween returns the notes of the releases after the current
+// version up to the target, newest first. Releases outside the channel
+// are left out unless they are the target; a downgrade only shows the
+// target's notes.
+func releaseNotesBetween(releases []sourceRelease, currentVersion string, target *sourceRelease, channel string) []ReleaseNotes {
+	var included []sourceRelease
+	for _, release := range releases {
+		isTarget := sameVersion(release.Version, target.Version)
+		switch {
+		case isTarget:
+		case compareVersions(target.Version, currentVersion) <= 0:
+			continue
+		case compareVersions(release.Version, currentVersion) <= 0,
+			compareVersions(release.Version, target.Version) > 0,
+			!channelIncludes(channel, releaseChannel(&release)):
+			continue
+		}
+		included = append(included, release)
+	}
+	sort.SliceStable(included, func(i, j int) bool {
+		return compareVersions(included[i].Version, included[j].Version) > 0
+	})
+
+	notes := make([]ReleaseNotes, 0, len(included))
+	for i := range included {
+		release := &included[i]
+		notes = append(notes, ReleaseNotes{
+			Version:     strings.TrimPrefix(release.Version, "v"),
+			Name:        release.Name,
+			PublishedAt: release.PublishedAt,
+			Prerelease:  releaseChannel(release) != updateChannelStable,
+			Sections:    parseReleaseNotes(release.Notes),
+			Body:        release.Notes,
+		})
+	}
+	return notes
+}
+
+// parseReleaseNotes groups the list items of Markdown release notes into
+// one section per kind, classified by their headings. Items outside a
+// recognised section are classified by a conventional commit prefix such
+// as "feat:" or "fix(ui):".
+func parseReleaseNotes(body string) []ReleaseNoteSection {
+	sections := []ReleaseNoteSection{}
+	index := make(map[string]int) // Section position by kind
+
+	add := func(kind, title, item string) {
+		i, ok := index[kind]
+		if !ok {
+			i = len(sections)
+			index[kind] = i
+			sections = append(sections, ReleaseNoteSection{Kind: kind})
+		}
+		if sections[i].Title == "" {
+			sections[i].Title = title
+		}
+		sections[i].Items = append(sections[i].Items, item)
+	}
+
+	kind, title := "", ""
+	lastKind := ""
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "<!--") {
+			continue
+		}
+
+		if match := noteHeadingPattern.FindStringSubmatch(trimmed); match != nil {
+			title = strings.TrimSpace(firstNonEmpty(match[1], match[2], match[3]))
+			kind = noteSectionKind(title)
+			lastKind = ""
+			continue
+		}
+
+		match := noteItemPattern.FindStringSubmatch(trimmed)
+		if match == nil {
+			// Indented text continues the previous item
+			if lastKind != "" && line != trimmed {
+				items := sections[index[lastKind]].Items
+				items[len(items)-1] += " " + trimmed
+			}
+			continue
+		}
+
+		item := strings.TrimSpace(match[1])
+		itemKind, itemTitle := kind, title
+		if commitKind, text, ok := conventionalItemKind(item); ok && (kind == "" || kind == noteKindOther) {
+			itemKind, itemTitle, item = commitKind, "", text
+		}
+		if itemKind == "" {
+			itemKind = noteKindOther
+		}
+		if item == "" {
+			continue
+		}
+		add(itemKind, itemTitle, item)
+		lastKind = itemKind
+	}
+
+	sort.SliceStable(sections, func(i, j int) bool {
+		return noteKindOrder[sections[i].Kind] < noteKindOrder[sections[j].Kind]
+	})
+	return sections
+}
+
+// noteSectionKind classifies a section heading
+func noteSectionKind(title string) string {
+	lower := strings.ToLower(title)
+	// English words end at anything but an ASCII letter or digit
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(lower, func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	}) {
+		words[word] = true
+	}
+
+	for _, group := range noteKindKeywords {
+		for _, keyword := range group.keywords {
+			if isASCIIWord(keyword) && words[keyword] || !isASCIIWord(keyword) && strings.Contains(lower, keyword) {
+				return group.kind
+			}
+		}
+	}
+	return noteKindOther
+}
+
+// isASCIIWord reports whether a keyword is written in ASCII
+func isASCIIWord(keyword string) bool {
+	for i := 0; i < len(keyword); i++ {
+		if keyword[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// conventionalItemKind classifies an item written as a conventional commit
+// subject and returns its text without the prefix
+func conventionalItemKind(item string) (string, string, bool) {
+	if text, ok := strings.CutPrefix(item, "BREAKING CHANGE:"); ok {
+		return noteKindBreaking, strings.TrimSpace(text), true
+	}
+	match := conventionalPattern.FindStringSubmatch(item)
+	if match == nil {
+		return "", "", false
+	}
+	switch {
+	case match[3] == "!":
+		return noteKindBreaking, match[4], true
+	case strings.EqualFold(match[1], "feat"):
+		return noteKindFeatures, match[4], true
+	case strings.EqualFold(match[1], "fix"):
+		return noteKindFixes, match[4], true
+	}
+	return noteKindOther, match[4], true
+}
+
+// whatsNewPath returns the file the notes of an installed update are kept
+// in until they were shown
+func (a *App) whatsNewPath() string {
+	return filepath.Join(a.GetDataDir(), "whats-new.json")
+}
+
+// saveWhatsNew keeps the notes of an update that is being installed
+func (a *App) saveWhatsNew(whatsNew WhatsNew) error {
+	if err := a.EnsureDataDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(whatsNew, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(a.whatsNewPath(), data, 0644)
+}
+
+// GetWhatsNew returns the notes of the update the app is now running, or
+// nil when there is nothing new to show
+func (a *App) GetWhatsNew() *WhatsNew {
+	data, err := os.ReadFile(a.whatsNewPath())
+	if err != nil {
+		return nil
+	}
+	var whatsNew WhatsNew
+	if err := json.Unmarshal(data, &whatsNew); err != nil {
+		os.Remove(a.whatsNewPath())
+		return nil
+	}
+
+	// Notes of an update that was staged but not installed, or rolled
+	// back, wait until that version runs
+	if whatsNew.Version == "" || !sameVersion(whatsNew.Version, a.GetCurrentVersion()) {
+		return nil
+	}
+	return &whatsNew
+}
+
+// DismissWhatsNew marks the notes of the installed update as shown
+func (a *App) DismissWhatsNew() error {
+	if err := os.Remove(a.whatsNewPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
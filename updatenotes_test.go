@@ -0,0 +1,163 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNoteSectionKind(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{title: "Breaking Changes", want: noteKindBreaking},
+		{title: "⚠️ BREAKING", want: noteKindBreaking},
+		{title: "Bug Fixes", want: noteKindFixes},
+		{title: "Fixed", want: noteKindFixes},
+		{title: "Bugfixes & improvements", want: noteKindFixes},
+		{title: "Hotfix", want: noteKindFixes},
+		{title: "New Features", want: noteKindFeatures},
+		{title: "What's New", want: noteKindFeatures},
+		{title: "Added", want: noteKindFeatures},
+		{title: "Debugging improvements", want: noteKindFeatures},
+		{title: "Debugging", want: noteKindOther},
+		{title: "Renewed documentation", want: noteKindOther},
+		{title: "Prefix handling", want: noteKindOther},
+		{title: "Newsletter", want: noteKindOther},
+		{title: "Featured contributors", want: noteKindOther},
+		{title: "不兼容变更", want: noteKindBreaking},
+		{title: "问题修复", want: noteKindFixes},
+		{title: "Bug修复", want: noteKindFixes},
+		{title: "新功能", want: noteKindFeatures},
+		{title: "性能优化", want: noteKindFeatures},
+		{title: "Dependencies", want: noteKindOther},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			if got := noteSectionKind(test.title); got != test.want {
+				t.Errorf("noteSectionKind(%q) = %q, want %q", test.title, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseReleaseNotes(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []ReleaseNoteSection
+	}{
+		{name: "empty", body: "", want: []ReleaseNoteSection{}},
+		{
+			name: "headed sections sorted by kind",
+			body: "## Bug Fixes\r\n- Fixed a crash\r\n\r\n## Features\r\n* Dark mode\r\n1. Export to OPML\r\n\r\n### Breaking changes ###\r\n- Dropped Windows 7\r\n",
+			want: []ReleaseNoteSection{
+				{Kind: noteKindBreaking, Title: "Breaking changes", Items: []string{"Dropped Windows 7"}},
+				{Kind: noteKindFeatures, Title: "Features", Items: []string{"Dark mode", "Export to OPML"}},
+				{Kind: noteKindFixes, Title: "Bug Fixes", Items: []string{"Fixed a crash"}},
+			},
+		},
+		{
+			name: "bold headings and continuation lines",
+			body: "**New:**\n- Link checker\n  with history\n\n__Fixes__\n- Sync\nNot part of the item\n",
+			want: []ReleaseNoteSection{
+				{Kind: noteKindFeatures, Title: "New:", Items: []string{"Link checker with history"}},
+				{Kind: noteKindFixes, Title: "Fixes", Items: []string{"Sync"}},
+			},
+		},
+		{
+			name: "conventional commits without sections",
+			body: "- feat(ui): tag cloud\n- fix: import of empty files\n- feat!: new data format\n- BREAKING CHANGE: settings moved\n- chore: bump deps\n- plain item\n",
+			want: []ReleaseNoteSection{
+				{Kind: noteKindBreaking, Items: []string{"new data format", "settings moved"}},
+				{Kind: noteKindFeatures, Items: []string{"tag cloud"}},
+				{Kind: noteKindFixes, Items: []string{"import of empty files"}},
+				{Kind: noteKindOther, Items: []string{"bump deps", "plain item"}},
+			},
+		},
+		{
+			name: "section heading wins over a commit prefix",
+			body: "## Bug fixes\n- feat: mislabelled fix\n\n## Debugging improvements\n- Better logs\n<!-- generated -->\n",
+			want: []ReleaseNoteSection{
+				{Kind: noteKindFeatures, Title: "Debugging improvements", Items: []string{"Better logs"}},
+				{Kind: noteKindFixes, Title: "Bug fixes", Items: []string{"feat: mislabelled fix"}},
+			},
+		},
+		{
+			name: "sections of the same kind merge",
+			body: "## 新功能\n- 书签监控\n## 功能改进\n- 更快的搜索\n## 其他\n- fix: 修正拼写\n",
+			want: []ReleaseNoteSection{
+				{Kind: noteKindFeatures, Title: "新功能", Items: []string{"书签监控", "更快的搜索"}},
+				{Kind: noteKindFixes, Items: []string{"修正拼写"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parseReleaseNotes(test.body); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseReleaseNotes() =\n%+v\nwant\n%+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReleaseNotesBetween(t *testing.T) {
+	releases := []sourceRelease{
+		{Version: "v1.4.0", Notes: "- old"},
+		{Version: "v1.5.0", Name: "Five", Notes: "## Features\n- five"},
+		{Version: "v1.5.1", Notes: "## Fixes\n- five one"},
+		{Version: "v1.6.0-beta.1", Notes: "- beta"},
+		{Version: "v1.6.0", Notes: "- six"},
+		{Version: "v1.7.0-nightly.1", Notes: "- nightly"},
+		{Version: "v1.7.0"},
+	}
+	find := func(version string) *sourceRelease {
+		for i := range releases {
+			if sameVersion(releases[i].Version, version) {
+				return &releases[i]
+			}
+		}
+		t.Fatalf("no release %s", version)
+		return nil
+	}
+
+	tests := []struct {
+		name    string
+		current string
+		target  string
+		channel string
+		want    []string
+	}{
+		{name: "stable update", current: "1.4.0", target: "1.6.0", channel: updateChannelStable, want: []string{"1.6.0", "1.5.1", "1.5.0"}},
+		{name: "beta includes prereleases", current: "1.5.1", target: "1.6.0", channel: updateChannelBeta, want: []string{"1.6.0", "1.6.0-beta.1"}},
+		{name: "target outside the channel", current: "1.6.0", target: "1.7.0-nightly.1", channel: updateChannelStable, want: []string{"1.7.0-nightly.1"}},
+		{name: "nightly channel", current: "1.6.0", target: "1.7.0", channel: updateChannelNightly, want: []string{"1.7.0", "1.7.0-nightly.1"}},
+		{name: "downgrade shows only the target", current: "1.6.0", target: "1.4.0", channel: updateChannelStable, want: []string{"1.4.0"}},
+		{name: "reinstall of the running version", current: "1.5.0", target: "1.5.0", channel: updateChannelStable, want: []string{"1.5.0"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notes := releaseNotesBetween(releases, test.current, find(test.target), test.channel)
+			var got []string
+			for _, note := range notes {
+				got = append(got, note.Version)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("versions = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	notes := releaseNotesBetween(releases, "1.4.0", find("1.6.0-beta.1"), updateChannelBeta)
+	if len(notes) != 3 || !notes[0].Prerelease || notes[1].Prerelease {
+		t.Fatalf("notes = %+v, want the beta flagged as a prerelease", notes)
+	}
+	five := notes[2]
+	if five.Name != "Five" || five.Body != "## Features\n- five" ||
+		!reflect.DeepEqual(five.Sections, []ReleaseNoteSection{{Kind: noteKindFeatures, Title: "Features", Items: []string{"five"}}}) {
+		t.Errorf("notes of 1.5.0 = %+v", five)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/minio/selfupdate"
+)
+
+// PendingUpdate is a downloaded and verified update waiting to be
+// installed when the app quits or starts the next time
+type PendingUpdate struct {
+	Version     string    `json:"version"`
+	FromVersion string    `json:"fromVersion"`
+	TargetPath  string    `json:"targetPath"` // Executable the update replaces
+	BinaryPath  string    `json:"binaryPath"` // Staged new executable
+	SHA256      string    `json:"sha256"`     // Of the staged executable
+	StagedAt    time.Time `json:"stagedAt"`
+	Mock        bool      `json:"mock,omitempty"` // Served by the mock update server
+}
+
+// pendingUpdatePath returns the marker of a staged update
+func (a *App) pendingUpdatePath() string {
+	return filepath.Join(a.GetDataDir(), "update-pending.json")
+}
+
+// loadPendingUpdate reads the marker of a staged update
+func (a *App) loadPendingUpdate() (*PendingUpdate, error) {
+	data, err := os.ReadFile(a.pendingUpdatePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var pending PendingUpdate
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, err
+	}
+	return &pending, nil
+}
+
+// discardPendingUpdate deletes a staged update and its marker
+func (a *App) discardPendingUpdate(pending *PendingUpdate) {
+	if pending != nil && pending.BinaryPath != "" {
+		os.Remove(pending.BinaryPath)
+	}
+	os.Remove(a.pendingUpdatePath())
+}
+
+// stagePendingUpdate saves a verified executable to be installed later,
+// replacing any update staged before
+func (a *App) stagePendingUpdate(targetPath string, binary []byte, fromVersion, toVersion string, mock bool) error {
+	if previous, _ := a.loadPendingUpdate(); previous != nil {
+		a.discardPendingUpdate(previous)
+	}
+
+	dir := a.updateStagingDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	binaryPath := filepath.Join(dir, "pending-"+filepath.Base(targetPath))
+	if err := os.WriteFile(binaryPath, binary, 0755); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(binary)
+	data, err := json.MarshalIndent(PendingUpdate{
+		Version:     toVersion,
+		FromVersion: fromVersion,
+		TargetPath:  targetPath,
+		BinaryPath:  binaryPath,
+		SHA256:      hex.EncodeToString(sum[:]),
+		StagedAt:    time.Now(),
+		Mock:        mock,
+	}, "", "  ")
+	if err != nil {
+		os.Remove(binaryPath)
+		return err
+	}
+	return os.WriteFile(a.pendingUpdatePath(), data, 0644)
+}
+
+// installUpdateBinary replaces the executable, keeping the old one for
+// RollbackUpdate, and records the update. A non-nil checksum is checked
+// once more right before the executable is replaced. An update from the
+// mock update server is neither kept for rollback nor recorded, since it
+// is a copy of the running executable.
+func (a *App) installUpdateBinary(targetPath string, binary io.Reader, checksum []byte, fromVersion, toVersion string, mock bool) error {
+	options := selfupdate.Options{
+		TargetPath: targetPath,
+		Checksum:   checksum,
+	}
+	if !mock {
+		options.OldSavePath = previousBinaryPath(targetPath)
+	}
+	if err := selfupdate.Apply(binary, options); err != nil {
+		// 尝试回滚失败的更新
+		if rollbackErr := selfupdate.RollbackError(err); rollbackErr != nil {
+			return fmt.Errorf("更新失败且回滚失败: %v, 回滚错误: %v", err, rollbackErr)
+		}
+		return fmt.Errorf("更新失败: %v", err)
+	}
+
+	if !mock {
+		a.recordUpdateInstalled(fromVersion, toVersion)
+	}
+	return nil
+}
+
+// applyPendingUpdate installs a staged update. It reports whether one was
+// installed; a staged update that cannot be installed is discarded. It
+// registers as the running update, so it fails with errUpdateRunning while
+// a download or another install is in progress.
+func (a *App) applyPendingUpdate() (bool, error) {
+	ctx, err := beginUpdate()
+	if err != nil {
+		return false, err
+	}
+	defer endUpdate()
+	if err := startUpdateInstall(ctx); err != nil {
+		return false, err
+	}
+
+	pending, err := a.loadPendingUpdate()
+	if err != nil {
+		os.Remove(a.pendingUpdatePath())
+		return false, err
+	}
+	if pending == nil {
+		return false, nil
+	}
+	defer a.discardPendingUpdate(pending)
+
+	// The app may have been moved or replaced since the update was staged
+	targetPath, err := updateTargetPath()
+	if err != nil {
+		return false, err
+	}
+	if targetPath != pending.TargetPath {
+		return false, fmt.Errorf("应用位置已改变，已放弃待安装的更新")
+	}
+
+	checksum, err := hex.DecodeString(pending.SHA256)
+	if err != nil || len(checksum) != sha256.Size {
+		return false, fmt.Errorf("待安装的更新校验和无效")
+	}
+	binary, err := os.ReadFile(pending.BinaryPath)
+	if err != nil {
+		return false, fmt.Errorf("无法读取待安装的更新: %v", err)
+	}
+
+	if err := a.installUpdateBinary(targetPath, bytes.NewReader(binary), checksum, pending.FromVersion, pending.Version, pending.Mock); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetPendingUpdate returns the update waiting to be installed, or nil
+func (a *App) GetPendingUpdate() *PendingUpdate {
+	pending, err := a.loadPendingUpdate()
+	if err != nil {
+		return nil
+	}
+	return pending
+}
+
+// InstallPendingUpdate installs the staged update now and restarts
+func (a *App) InstallPendingUpdate() error {
+	installed, err := a.applyPendingUpdate()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return fmt.Errorf("没有待安装的更新")
+	}
+
+	// 延迟重启，给前端时间显示结果
+	go func() {
+		time.Sleep(time.Second)
+		a.RestartApplication()
+	}()
+	return nil
+}
+
+// ApplyPendingUpdateOnExit installs a staged update while the app quits,
+// so that the next launch starts the new version
+func (a *App) ApplyPendingUpdateOnExit() error {
+	_, err := a.applyPendingUpdate()
+	return err
+}
+
+// applyPendingUpdateOnLaunch runs before the window opens and installs an
+// update staged by a session that did not quit cleanly. It reports whether
+// the app is restarting into the new version.
+func (a *App) applyPendingUpdateOnLaunch() bool {
+	installed, err := a.applyPendingUpdate()
+	if err != nil {
+		fmt.Printf("警告: 安装待更新版本失败: %v\n", err)
+	}
+	if !installed {
+		return false
+	}
+	return a.RestartApplication() == nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInstallPendingUpdateWhileUpdating(t *testing.T) {
+	setTestHome(t)
+	a := NewApp()
+
+	if _, err := beginUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	defer endUpdate()
+
+	if err := a.InstallPendingUpdate(); !errors.Is(err, errUpdateRunning) {
+		t.Errorf("InstallPendingUpdate() = %v, want %v", err, errUpdateRunning)
+	}
+	if err := a.ApplyPendingUpdateOnExit(); !errors.Is(err, errUpdateRunning) {
+		t.Errorf("ApplyPendingUpdateOnExit() = %v, want %v", err, errUpdateRunning)
+	}
+}
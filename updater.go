@@ -1,20 +1,16 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
-
-	"github.com/minio/selfupdate"
 )
 
 // UpdateInfo represents update information
@@ -24,12 +20,16 @@ type UpdateInfo struct {
 	LatestVersion  string `json:"latestVersion"`
 	UpdateURL      string `json:"updateUrl"`
 	ReleaseNotes   string `json:"releaseNotes"`
+	Channel        string `json:"channel"`    // 最新版本所属的更新通道
+	Prerelease     bool   `json:"prerelease"` // 最新版本是否为预发布版本
 	ErrorMessage   string `json:"errorMessage,omitempty"`
+	// 当前版本之后到最新版本的结构化更新说明，从新到旧
+	Notes []ReleaseNotes `json:"notes,omitempty"`
 }
 
 // UpdateProgress represents download progress information
 type UpdateProgress struct {
-	Phase          string `json:"phase"`          // "downloading", "installing", "completed", "error"
+	Phase          string `json:"phase"`          // "downloading", "verifying", "installing", "staged", "completed", "error", "verification_failed", "cancelled"
 	Progress       int    `json:"progress"`       // 0-100
 	Speed          string `json:"speed"`          // Download speed (e.g. "1.2 MB/s")
 	ETA            string `json:"eta"`            // Estimated time (e.g. "2m 30s")
@@ -39,8 +39,21 @@ type UpdateProgress struct {
 	Error          string `json:"error,omitempty"` // Error message if any
 }
 
-// CheckForUpdates 检查是否有新版本可用
+// releaseAsset 发布版本中的文件
+type releaseAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Size               int    `json:"size"`
+}
+
+// CheckForUpdates 检查是否有新版本可用，并记录检查结果
 func (a *App) CheckForUpdates() UpdateInfo {
+	return a.runUpdateCheck(false)
+}
+
+// checkForUpdates 查询更新源；返回的错误用于计算后台检查的退避时间。
+// cache 为更新源请求提供 ETag/If-Modified-Since 条件请求
+func (a *App) checkForUpdates(cache *releaseCacheTransport) (UpdateInfo, error) {
 	// 确保版本信息已初始化
 	if RuntimeVersion == nil {
 		return UpdateInfo{
@@ -50,177 +63,114 @@ func (a *App) CheckForUpdates() UpdateInfo {
 			UpdateURL:      "",
 			ReleaseNotes:   "",
 			ErrorMessage:   "版本信息未初始化，请检查配置",
-		}
+		}, fmt.Errorf("版本信息未初始化")
 	}
 
 	currentVersion := RuntimeVersion.Version
-
-	// 仅在Windows上支持自动更新
-	if runtime.GOOS != "windows" {
+	failed := func(err error) (UpdateInfo, error) {
 		return UpdateInfo{
 			HasUpdate:      false,
 			CurrentVersion: currentVersion,
 			LatestVersion:  currentVersion,
 			UpdateURL:      "",
-			ReleaseNotes:   "自动更新功能仅支持Windows版本",
-		}
+			ReleaseNotes:   "",
+			ErrorMessage:   err.Error(),
+		}, err
 	}
 
-	// 检查GitHub仓库信息是否配置
-	if RuntimeVersion.GitHubOwner == "" || RuntimeVersion.GitHubRepo == "" {
-		return UpdateInfo{
-			HasUpdate:      false,
-			CurrentVersion: currentVersion,
-			LatestVersion:  currentVersion,
-			UpdateURL:      "",
-			ReleaseNotes:   "",
-			ErrorMessage:   "GitHub仓库信息未配置，无法检查更新。请配置GitHub用户名和仓库名。",
-		}
+	// 按配置选择更新源（GitHub、GitLab、Gitea 或自建更新清单）
+	settings, err := a.GetSettings()
+	if err != nil {
+		return failed(fmt.Errorf("读取设置失败: %v", err))
 	}
 
-	// 构建GitHub API URL
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest",
-		RuntimeVersion.GitHubOwner, RuntimeVersion.GitHubRepo)
+	source, err := activeUpdateSource(&settings.Update)
+	if err != nil {
+		return failed(err)
+	}
 
-	// 创建HTTP客户端，设置超时
-	client := &http.Client{
-		Timeout: 15 * time.Second,
+	// 创建HTTP客户端（使用网络设置中的代理、证书和超时）
+	client, err := newHTTPClient(settings.Network, a.requestTimeout())
+	if err != nil {
+		return failed(fmt.Errorf("网络设置无效: %v", err))
+	}
+	if cache != nil {
+		cache.base = client.Transport
+		client.Transport = cache
 	}
 
-	// 发送请求
-	resp, err := client.Get(apiURL)
+	// 获取版本列表
+	releases, err := source.Releases(context.Background(), client)
 	if err != nil {
-		return UpdateInfo{
-			HasUpdate:      false,
-			CurrentVersion: currentVersion,
-			LatestVersion:  currentVersion,
-			UpdateURL:      "",
-			ReleaseNotes:   "",
-			ErrorMessage:   fmt.Sprintf("网络连接失败: %v", err),
-		}
+		return failed(err)
 	}
-	defer resp.Body.Close()
-
-	// 检查响应状态
-	if resp.StatusCode != http.StatusOK {
-		errorMsg := ""
-		switch resp.StatusCode {
-		case 404:
-			errorMsg = fmt.Sprintf("仓库 %s/%s 未找到或没有发布版本",
-				RuntimeVersion.GitHubOwner, RuntimeVersion.GitHubRepo)
-		case 403:
-			errorMsg = "GitHub API访问限制，请稍后重试"
-		case 429:
-			errorMsg = "请求过于频繁，请稍后重试"
-		default:
-			errorMsg = fmt.Sprintf("GitHub API请求失败，状态码: %d", resp.StatusCode)
-		}
 
-		return UpdateInfo{
+	// 按更新通道、固定版本和跳过的版本选择要提供的版本（SemVer 2.0 优先级）
+	release := selectUpdateRelease(releases, settings.Update, currentVersion)
+	if release == nil {
+		info := UpdateInfo{
 			HasUpdate:      false,
 			CurrentVersion: currentVersion,
-			LatestVersion:  currentVersion,
+			LatestVersion:  strings.TrimPrefix(currentVersion, "v"),
 			UpdateURL:      "",
 			ReleaseNotes:   "",
-			ErrorMessage:   errorMsg,
 		}
+		if latest := latestChannelRelease(releases, settings.Update.Channel); latest != nil {
+			info.LatestVersion = strings.TrimPrefix(latest.Version, "v")
+			info.ReleaseNotes = latest.Notes
+			info.Channel = releaseChannel(latest)
+			info.Prerelease = info.Channel != updateChannelStable
+			info.Notes = releaseNotesBetween(releases, currentVersion, latest, settings.Update.Channel)
+		}
+		return info, nil
 	}
 
-	// 解析GitHub API响应
-	var release struct {
-		TagName string `json:"tag_name"`
-		Name    string `json:"name"`
-		Body    string `json:"body"`
-		Assets  []struct {
-			Name               string `json:"name"`
-			BrowserDownloadURL string `json:"browser_download_url"`
-			Size              int    `json:"size"`
-		} `json:"assets"`
-	}
+	// 处理版本号（移除v前缀）
+	latestVersion := strings.TrimPrefix(release.Version, "v")
+	channel := releaseChannel(release)
+	notes := releaseNotesBetween(releases, currentVersion, release, settings.Update.Channel)
 
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+	// 按当前系统和架构查找安装包
+	download := release.downloadFor(currentUpdatePlatform())
+	if download == nil {
 		return UpdateInfo{
-			HasUpdate:      false,
+			HasUpdate:      true,
 			CurrentVersion: currentVersion,
-			LatestVersion:  currentVersion,
+			LatestVersion:  latestVersion,
 			UpdateURL:      "",
-			ReleaseNotes:   "",
-			ErrorMessage:   fmt.Sprintf("解析GitHub响应失败: %v", err),
-		}
+			ReleaseNotes:   release.Notes,
+			Channel:        channel,
+			Prerelease:     channel != updateChannelStable,
+			Notes:          notes,
+			ErrorMessage:   fmt.Sprintf("新版本可用，但未找到适用于 %s/%s 的安装包", runtime.GOOS, runtime.GOARCH),
+		}, nil
 	}
 
-	// 处理版本号（移除v前缀进行比较）
-	latestVersion := strings.TrimPrefix(release.TagName, "v")
-	currentVersionForComparison := strings.TrimPrefix(currentVersion, "v")
-
-	// 比较版本号
-	hasUpdate := compareVersions(currentVersionForComparison, latestVersion) < 0
-
-	var updateURL string
-	if hasUpdate {
-		// 查找Windows可执行文件
-		updateURL = findWindowsExecutable(release.Assets)
-
-		if updateURL == "" {
-			return UpdateInfo{
-				HasUpdate:      true,
-				CurrentVersion: currentVersion,
-				LatestVersion:  latestVersion,
-				UpdateURL:      "",
-				ReleaseNotes:   release.Body,
-				ErrorMessage:   "新版本可用，但未找到Windows安装包",
-			}
-		}
-	}
+	// 记录签名和校验文件，下载时用于验证
+	rememberUpdateVerification(download.URL, download.Verification)
 
 	return UpdateInfo{
-		HasUpdate:      hasUpdate,
+		HasUpdate:      true,
 		CurrentVersion: currentVersion,
 		LatestVersion:  latestVersion,
-		UpdateURL:      updateURL,
-		ReleaseNotes:   release.Body,
-	}
-}
-
-// findWindowsExecutable 查找Windows可执行文件
-func findWindowsExecutable(assets []struct {
-	Name               string `json:"name"`
-	BrowserDownloadURL string `json:"browser_download_url"`
-	Size              int    `json:"size"`
-}) string {
-	// 优先查找确切的文件名
-	for _, asset := range assets {
-		if asset.Name == "URLNavigator.exe" {
-			return asset.BrowserDownloadURL
-		}
-	}
-
-	// 查找包含应用名称的.exe文件
-	for _, asset := range assets {
-		name := strings.ToLower(asset.Name)
-		if strings.HasSuffix(name, ".exe") &&
-		   (strings.Contains(name, "urlnavigator") || strings.Contains(name, "url-navigator")) {
-			return asset.BrowserDownloadURL
-		}
-	}
-
-	// 查找任何.exe文件
-	for _, asset := range assets {
-		if strings.HasSuffix(strings.ToLower(asset.Name), ".exe") {
-			return asset.BrowserDownloadURL
-		}
-	}
-
-	return ""
+		UpdateURL:      download.URL,
+		ReleaseNotes:   release.Notes,
+		Channel:        channel,
+		Prerelease:     channel != updateChannelStable,
+		Notes:          notes,
+	}, nil
 }
 
 var (
 	// 全局更新进度状态
 	updateProgressMutex sync.RWMutex
 	currentUpdateProgress *UpdateProgress
+	// 推送进度事件，下载进度最多每秒10次
+	updateProgressEvents *progressEmitter
 )
 
-// GetUpdateProgress 获取当前更新进度
+// GetUpdateProgress 获取当前更新进度；进度变化通过 update:progress 事件推送，
+// 此方法用于界面打开时读取初始状态
 func (a *App) GetUpdateProgress() *UpdateProgress {
 	updateProgressMutex.RLock()
 	defer updateProgressMutex.RUnlock()
@@ -238,11 +188,24 @@ func (a *App) GetUpdateProgress() *UpdateProgress {
 	return &progress
 }
 
-// setUpdateProgress 设置更新进度
-func setUpdateProgress(progress *UpdateProgress) {
+// setUpdateProgress 设置更新进度并推送给前端；事件在释放锁之后发送，
+// 以免前端回调 GetUpdateProgress 时阻塞
+func (a *App) setUpdateProgress(progress *UpdateProgress) {
 	updateProgressMutex.Lock()
-	defer updateProgressMutex.Unlock()
 	currentUpdateProgress = progress
+	if updateProgressEvents == nil {
+		updateProgressEvents = a.newProgressEmitter(eventUpdateProgress)
+	}
+	events := updateProgressEvents
+	snapshot := *progress
+	updateProgressMutex.Unlock()
+
+	// 下载进度节流发送，其他阶段立即发送
+	if snapshot.Phase == "downloading" {
+		events.Emit(snapshot)
+	} else {
+		events.Final(snapshot)
+	}
 }
 
 // ProgressReader wraps an io.Reader and provides progress tracking
@@ -262,133 +225,244 @@ func (pr *ProgressReader) Read(p []byte) (int, error) {
 	return n, err
 }
 
-// DownloadAndApplyUpdate 下载并应用更新
+// DownloadAndApplyUpdate 下载并应用更新，完成后重启应用
 func (a *App) DownloadAndApplyUpdate(updateURL string) error {
+	return a.downloadUpdate(updateURL, false)
+}
+
+// StageUpdate 下载并验证更新，在应用退出或下次启动时安装，不打断当前使用
+func (a *App) StageUpdate(updateURL string) error {
+	return a.downloadUpdate(updateURL, true)
+}
+
+// downloadUpdate 下载、验证更新后立即安装，或暂存到退出/下次启动时安装
+func (a *App) downloadUpdate(updateURL string, installLater bool) error {
 	if updateURL == "" {
 		return fmt.Errorf("无效的更新URL")
 	}
 
-	// 仅在Windows上支持自动更新
-	if runtime.GOOS != "windows" {
-		return fmt.Errorf("自动更新功能仅支持Windows版本")
+	// 同一时间只允许一个更新，CancelUpdate 通过 ctx 取消下载
+	ctx, err := beginUpdate()
+	if err != nil {
+		return err
 	}
+	defer endUpdate()
 
 	// 初始化进度
-	setUpdateProgress(&UpdateProgress{
+	a.setUpdateProgress(&UpdateProgress{
 		Phase:    "downloading",
 		Progress: 0,
 		Message:  "正在准备下载...",
 	})
 
-	// 创建HTTP客户端
-	client := &http.Client{
-		Timeout: 30 * time.Minute, // 30分钟超时，用于大文件下载
+	// 创建HTTP客户端（下载超时可在网络设置中配置）
+	client, err := a.httpClient(a.downloadTimeout())
+	if err != nil {
+		a.setUpdateProgress(&UpdateProgress{
+			Phase:   "error",
+			Message: "下载失败",
+			Error:   fmt.Sprintf("网络设置无效: %v", err),
+		})
+		return fmt.Errorf("网络设置无效: %v", err)
 	}
 
-	// 发起下载请求
-	resp, err := client.Get(updateURL)
+	// 下载到临时文件，中断后通过 HTTP Range 续传
+	var startTime time.Time
+	var startOffset, lastDownloaded int64
+	onProgress := func(downloaded, total int64) {
+		// 速度按本次会话下载的字节计算，不包括续传前已有的部分
+		if startTime.IsZero() || downloaded < lastDownloaded {
+			startTime, startOffset = time.Now(), downloaded
+		}
+		lastDownloaded = downloaded
+		elapsed := time.Since(startTime)
+		session := downloaded - startOffset
+
+		// 计算下载速度
+		speed := ""
+		bytesPerSecond := 0.0
+		if elapsed.Seconds() > 0 {
+			bytesPerSecond = float64(session) / elapsed.Seconds()
+			speed = formatBytes(int64(bytesPerSecond)) + "/s"
+		}
+
+		// 服务器未提供文件大小时只显示已下载的字节数
+		if total <= 0 {
+			a.setUpdateProgress(&UpdateProgress{
+				Phase:      "downloading",
+				Speed:      speed,
+				Downloaded: downloaded,
+				Message:    fmt.Sprintf("已下载 %s", formatBytes(downloaded)),
+			})
+			return
+		}
+
+		// 计算进度百分比
+		progress := int((downloaded * 100) / total)
+		if progress > 100 {
+			progress = 100
+		}
+
+		// 计算剩余时间
+		eta := ""
+		if session > 0 && elapsed.Seconds() > 1 && bytesPerSecond > 0 {
+			remainingSeconds := float64(total-downloaded) / bytesPerSecond
+			eta = formatDuration(time.Duration(remainingSeconds) * time.Second)
+		}
+
+		a.setUpdateProgress(&UpdateProgress{
+			Phase:      "downloading",
+			Progress:   progress,
+			Speed:      speed,
+			ETA:        eta,
+			Downloaded: downloaded,
+			Total:      total,
+			Message:    fmt.Sprintf("已下载 %s / %s", formatBytes(downloaded), formatBytes(total)),
+		})
+	}
+
+	stagingDir := a.updateStagingDir()
+	stagedPath, err := downloadUpdateFile(ctx, client, updateURL, stagingDir, onProgress)
 	if err != nil {
-		setUpdateProgress(&UpdateProgress{
+		if ctx.Err() != nil {
+			a.setUpdateProgress(&UpdateProgress{
+				Phase:      "cancelled",
+				Downloaded: lastDownloaded,
+				Message:    "更新已取消，下次将继续下载",
+			})
+			return fmt.Errorf("更新已取消")
+		}
+		a.setUpdateProgress(&UpdateProgress{
 			Phase:   "error",
 			Message: "下载失败",
-			Error:   fmt.Sprintf("网络连接失败: %v", err),
+			Error:   err.Error(),
 		})
 		return fmt.Errorf("下载更新失败: %v", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		setUpdateProgress(&UpdateProgress{
+	data, err := os.ReadFile(stagedPath)
+	if err != nil {
+		a.setUpdateProgress(&UpdateProgress{
 			Phase:   "error",
 			Message: "下载失败",
-			Error:   fmt.Sprintf("HTTP状态码: %d", resp.StatusCode),
+			Error:   fmt.Sprintf("无法读取下载文件: %v", err),
 		})
-		return fmt.Errorf("下载失败，状态码: %d", resp.StatusCode)
+		return fmt.Errorf("无法读取下载文件: %v", err)
 	}
 
-	// 获取文件大小
-	contentLength := resp.ContentLength
-	if contentLength <= 0 {
-		contentLength = 10 * 1024 * 1024 // 默认10MB，如果服务器没有提供文件大小
+	// 验证签名和SHA-256校验和，通过后才安装
+	a.setUpdateProgress(&UpdateProgress{
+		Phase:      "verifying",
+		Progress:   100,
+		Downloaded: int64(len(data)),
+		Total:      int64(len(data)),
+		Message:    "正在验证更新签名...",
+	})
+
+	verification := lookupUpdateVerification(updateURL)
+	checksum, err := verifyUpdate(client, verification, data)
+	if err != nil {
+		// 验证失败的文件不再续传
+		removeStagedDownload(stagingDir, updateURL)
+		a.setUpdateProgress(&UpdateProgress{
+			Phase:   "verification_failed",
+			Message: "更新验证失败，已取消安装",
+			Error:   err.Error(),
+		})
+		return fmt.Errorf("更新验证失败: %v", err)
 	}
 
-	setUpdateProgress(&UpdateProgress{
-		Phase:      "downloading",
-		Progress:   0,
-		Downloaded: 0,
-		Total:      contentLength,
-		Message:    "正在下载更新...",
-	})
+	targetPath, err := updateTargetPath()
+	if err != nil {
+		a.setUpdateProgress(&UpdateProgress{
+			Phase:   "error",
+			Message: "安装失败",
+			Error:   fmt.Sprintf("无法获取可执行文件路径: %v", err),
+		})
+		return fmt.Errorf("无法获取可执行文件路径: %v", err)
+	}
 
-	// 创建进度追踪器
-	startTime := time.Now()
-	progressReader := &ProgressReader{
-		reader: resp.Body,
-		total:  contentLength,
-		onProgress: func(downloaded, total int64) {
-			elapsed := time.Since(startTime)
-
-			// 计算进度百分比
-			progress := int((downloaded * 100) / total)
-			if progress > 100 {
-				progress = 100
-			}
-
-			// 计算下载速度
-			speed := ""
-			if elapsed.Seconds() > 0 {
-				bytesPerSecond := float64(downloaded) / elapsed.Seconds()
-				speed = formatBytes(int64(bytesPerSecond)) + "/s"
-			}
-
-			// 计算剩余时间
-			eta := ""
-			if downloaded > 0 && elapsed.Seconds() > 1 {
-				remainingBytes := total - downloaded
-				bytesPerSecond := float64(downloaded) / elapsed.Seconds()
-				if bytesPerSecond > 0 {
-					remainingSeconds := float64(remainingBytes) / bytesPerSecond
-					eta = formatDuration(time.Duration(remainingSeconds) * time.Second)
-				}
-			}
-
-			setUpdateProgress(&UpdateProgress{
-				Phase:      "downloading",
-				Progress:   progress,
-				Speed:      speed,
-				ETA:        eta,
-				Downloaded: downloaded,
-				Total:      total,
-				Message:    fmt.Sprintf("已下载 %s / %s", formatBytes(downloaded), formatBytes(total)),
+	// 压缩包（tar.gz、zip、.app.zip）先解压出可执行文件
+	binary, err := extractUpdateBinary(data, verification.AssetName, filepath.Base(targetPath))
+	if err != nil {
+		a.setUpdateProgress(&UpdateProgress{
+			Phase:   "error",
+			Message: "安装失败",
+			Error:   err.Error(),
+		})
+		return fmt.Errorf("更新失败: %v", err)
+	}
+
+	// 此后不能再取消
+	if err := startUpdateInstall(ctx); err != nil {
+		a.setUpdateProgress(&UpdateProgress{
+			Phase:      "cancelled",
+			Downloaded: int64(len(data)),
+			Message:    "更新已取消，下次将继续下载",
+		})
+		return fmt.Errorf("更新已取消")
+	}
+
+	// 更新历史中记录的版本；模拟更新服务器的版本不记录
+	fromVersion := strings.TrimPrefix(a.GetCurrentVersion(), "v")
+	toVersion := ""
+	mock := isMockUpdateURL(updateURL)
+	if last := a.GetUpdateCheckStatus().LastResult; last != nil && last.UpdateURL == updateURL {
+		toVersion = last.LatestVersion
+
+		// 新版本首次启动时展示的更新内容
+		if !mock {
+			a.saveWhatsNew(WhatsNew{FromVersion: fromVersion, Version: toVersion, Notes: last.Notes})
+		}
+	}
+
+	// 稍后安装：暂存已验证的可执行文件，退出或下次启动时安装
+	if installLater {
+		if err := a.stagePendingUpdate(targetPath, binary, fromVersion, toVersion, mock); err != nil {
+			a.setUpdateProgress(&UpdateProgress{
+				Phase:   "error",
+				Message: "保存更新失败",
+				Error:   err.Error(),
 			})
-		},
+			return fmt.Errorf("保存更新失败: %v", err)
+		}
+		removeStagedDownload(stagingDir, updateURL)
+
+		a.setUpdateProgress(&UpdateProgress{
+			Phase:    "staged",
+			Progress: 100,
+			Message:  "更新已下载，将在退出或下次启动时安装",
+		})
+		return nil
 	}
 
 	// 开始安装阶段
-	setUpdateProgress(&UpdateProgress{
+	a.setUpdateProgress(&UpdateProgress{
 		Phase:    "installing",
-		Progress: 90,
+		Progress: 100,
 		Message:  "正在安装更新...",
 	})
 
-	// 使用selfupdate进行更新
-	err = selfupdate.Apply(progressReader, selfupdate.Options{})
-	if err != nil {
-		setUpdateProgress(&UpdateProgress{
+	// 直接安装下载的文件时，安装前再次核对校验和
+	var installChecksum []byte
+	if !isUpdateArchive(verification.AssetName) {
+		installChecksum = checksum
+	}
+	if err := a.installUpdateBinary(targetPath, bytes.NewReader(binary), installChecksum, fromVersion, toVersion, mock); err != nil {
+		a.setUpdateProgress(&UpdateProgress{
 			Phase:   "error",
 			Message: "安装失败",
-			Error:   fmt.Sprintf("更新失败: %v", err),
+			Error:   err.Error(),
 		})
-
-		// 尝试回滚失败的更新
-		if rollbackErr := selfupdate.RollbackError(err); rollbackErr != nil {
-			return fmt.Errorf("更新失败且回滚失败: %v, 回滚错误: %v", err, rollbackErr)
-		}
-		return fmt.Errorf("更新失败: %v", err)
+		return err
+	}
+	removeStagedDownload(stagingDir, updateURL)
+	if !mock {
+		a.discardPendingUpdate(a.GetPendingUpdate())
 	}
 
 	// 更新完成
-	setUpdateProgress(&UpdateProgress{
+	a.setUpdateProgress(&UpdateProgress{
 		Phase:    "completed",
 		Progress: 100,
 		Message:  "更新完成，准备重启应用...",
@@ -405,34 +479,28 @@ func (a *App) DownloadAndApplyUpdate(updateURL string) error {
 
 // RestartApplication 重启应用程序
 func (a *App) RestartApplication() error {
-	// 获取当前可执行文件路径
-	exePath, err := os.Executable()
+	// 获取当前可执行文件路径（AppImage 运行时为 AppImage 文件）
+	exePath, err := updateTargetPath()
 	if err != nil {
 		return fmt.Errorf("无法获取可执行文件路径: %v", err)
 	}
 
-	// 在Windows上重启应用
-	if runtime.GOOS == "windows" {
-		// 使用 cmd /c start 来启动新实例并退出当前实例
-		cmd := exec.Command("cmd", "/c", "start", "/b", exePath)
-		cmd.Dir = filepath.Dir(exePath)
-
-		// 启动新实例
-		err := cmd.Start()
-		if err != nil {
-			return fmt.Errorf("重启失败: %v", err)
-		}
-
-		// 退出当前实例
-		go func() {
-			time.Sleep(500 * time.Millisecond) // 给新实例启动时间
-			os.Exit(0)
-		}()
-
-		return nil
+	// 以原来的命令行参数重新启动；成功时不会返回
+	if err := restartExecutable(exePath, os.Args[1:]); err != nil {
+		return fmt.Errorf("重启失败: %v", err)
 	}
+	return nil
+}
 
-	return fmt.Errorf("当前平台不支持自动重启")
+// macAppBundle 返回可执行文件所在的 .app 应用包路径
+func macAppBundle(exePath string) string {
+	macOSDir := filepath.Dir(exePath)
+	contentsDir := filepath.Dir(macOSDir)
+	bundle := filepath.Dir(contentsDir)
+	if filepath.Base(macOSDir) == "MacOS" && filepath.Base(contentsDir) == "Contents" && strings.HasSuffix(bundle, ".app") {
+		return bundle
+	}
+	return ""
 }
 
 // formatBytes 格式化字节数为人类可读格式
@@ -462,75 +530,20 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
 }
 
-// compareVersions 比较两个版本号
+// compareVersions 按 SemVer 2.0 优先级比较两个版本号（1.5.0-beta.2 < 1.5.0）
 // 返回: -1 if v1 < v2, 0 if v1 == v2, 1 if v1 > v2
+// 无法解析的版本号低于任何有效版本
 func compareVersions(v1, v2 string) int {
-	// 清理版本字符串
-	v1 = strings.TrimPrefix(strings.TrimSpace(v1), "v")
-	v2 = strings.TrimPrefix(strings.TrimSpace(v2), "v")
-
-	if v1 == v2 {
-		return 0
-	}
-
-	parts1 := strings.Split(v1, ".")
-	parts2 := strings.Split(v2, ".")
-
-	// 确保两个版本都有三个部分
-	for len(parts1) < 3 {
-		parts1 = append(parts1, "0")
-	}
-	for len(parts2) < 3 {
-		parts2 = append(parts2, "0")
-	}
-
-	for i := 0; i < 3; i++ {
-		// 提取数字部分（忽略预发布标识符）
-		num1Str := strings.Split(parts1[i], "-")[0]
-		num2Str := strings.Split(parts2[i], "-")[0]
-
-		num1, err1 := strconv.Atoi(num1Str)
-		num2, err2 := strconv.Atoi(num2Str)
-
-		if err1 != nil || err2 != nil {
-			// 如果无法解析为数字，进行字符串比较
-			if parts1[i] < parts2[i] {
-				return -1
-			} else if parts1[i] > parts2[i] {
-				return 1
-			}
-			continue
-		}
-
-		if num1 < num2 {
-			return -1
-		} else if num1 > num2 {
-			return 1
-		}
-	}
-
-	return 0
-}
-
-// TestUpdateAvailable 模拟有更新可用（用于测试）
-func (a *App) TestUpdateAvailable() UpdateInfo {
-	return UpdateInfo{
-		HasUpdate:      true,
-		CurrentVersion: a.GetCurrentVersion(),
-		LatestVersion:  "999.999.999",
-		UpdateURL:      "https://example.com/test-update.exe",
-		ReleaseNotes:   "这是一个测试更新\n\n新功能:\n- 测试功能1\n- 测试功能2\n\n修复:\n- 修复了测试问题",
-	}
-}
-
-// TestNoUpdate 模拟没有更新可用（用于测试）
-func (a *App) TestNoUpdate() UpdateInfo {
-	currentVersion := a.GetCurrentVersion()
-	return UpdateInfo{
-		HasUpdate:      false,
-		CurrentVersion: currentVersion,
-		LatestVersion:  currentVersion,
-		UpdateURL:      "",
-		ReleaseNotes:   "当前已是最新版本",
-	}
+	parsed1, ok1 := parseSemVer(v1)
+	parsed2, ok2 := parseSemVer(v2)
+
+	switch {
+	case ok1 && ok2:
+		return compareSemVer(parsed1, parsed2)
+	case ok1:
+		return 1
+	case ok2:
+		return -1
+	}
+	return strings.Compare(strings.TrimSpace(v1), strings.TrimSpace(v2))
 }
\ No newline at end of file
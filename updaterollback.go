@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/minio/selfupdate"
+)
+
+// Statuses of an update history entry
+const (
+	updateStatusInstalled  = "installed"   // Not yet confirmed to start
+	updateStatusConfirmed  = "confirmed"   // Started and ran without crashing
+	updateStatusRolledBack = "rolled_back" // Replaced by the previous version again
+)
+
+// updateHistoryLength is the number of history entries kept
+const updateHistoryLength = 20
+
+// A freshly installed update counts as healthy once it has run for
+// updateHealthyAfter or quit cleanly. Launches that end otherwise count as
+// failed; after updateHealthMaxLaunches of them in a row the previous
+// version is restored. More than one is required so that a single kill,
+// logoff or power cut does not undo an update.
+const (
+	updateHealthyAfter      = 30 * time.Second
+	updateHealthMaxLaunches = 3
+)
+
+// UpdateHistoryEntry records one installed update
+type UpdateHistoryEntry struct {
+	FromVersion  string    `json:"fromVersion"`
+	ToVersion    string    `json:"toVersion"`
+	InstalledAt  time.Time `json:"installedAt"`
+	Status       string    `json:"status"` // installed, confirmed or rolled_back
+	RolledBackAt time.Time `json:"rolledBackAt,omitempty"`
+	Automatic    bool      `json:"automatic,omitempty"` // Rolled back after failed launches
+}
+
+// UpdateHistory lists past updates and whether one can be undone
+type UpdateHistory struct {
+	Entries           []UpdateHistoryEntry `json:"entries"` // Newest first
+	RollbackAvailable bool                 `json:"rollbackAvailable"`
+	PreviousVersion   string               `json:"previousVersion,omitempty"`
+}
+
+// updateHealthMarker is written after an update is installed and removed
+// once the new version proved to start
+type updateHealthMarker struct {
+	FromVersion string    `json:"fromVersion"`
+	ToVersion   string    `json:"toVersion"`
+	InstalledAt time.Time `json:"installedAt"`
+	Launches    int       `json:"launches"` // Unconfirmed launches so far
+}
+
+var (
+	// updateHistoryMutex serializes access to the history and health marker
+	updateHistoryMutex sync.Mutex
+	// updateInstalledThisRun is set once this process installed an update;
+	// it must not confirm the new version it is not running
+	updateInstalledThisRun bool
+)
+
+// updateHistoryPath returns the file the update history is stored in
+func (a *App) updateHistoryPath() string {
+	return filepath.Join(a.GetDataDir(), "update-history.json")
+}
+
+// updateHealthPath returns the health marker of a fresh update
+func (a *App) updateHealthPath() string {
+	return filepath.Join(a.GetDataDir(), "update-health.json")
+}
+
+// previousBinaryPath returns where the replaced executable is kept. It
+// stays next to the target so that keeping it is a rename on the same
+// volume.
+func previousBinaryPath(targetPath string) string {
+	return filepath.Join(filepath.Dir(targetPath), "."+filepath.Base(targetPath)+".previous")
+}
+
+// loadUpdateHistory reads the history; callers must hold updateHistoryMutex
+func (a *App) loadUpdateHistory() []UpdateHistoryEntry {
+	var entries []UpdateHistoryEntry
+	if data, err := os.ReadFile(a.updateHistoryPath()); err == nil {
+		json.Unmarshal(data, &entries)
+	}
+	return entries
+}
+
+// saveUpdateHistory writes the history; callers must hold updateHistoryMutex
+func (a *App) saveUpdateHistory(entries []UpdateHistoryEntry) error {
+	if len(entries) > updateHistoryLength {
+		entries = entries[:updateHistoryLength]
+	}
+	if err := a.EnsureDataDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(a.updateHistoryPath(), data, 0644)
+}
+
+// loadUpdateHealth reads the health marker; callers must hold
+// updateHistoryMutex
+func (a *App) loadUpdateHealth() (updateHealthMarker, bool) {
+	var marker updateHealthMarker
+	data, err := os.ReadFile(a.updateHealthPath())
+	if err != nil || json.Unmarshal(data, &marker) != nil {
+		return marker, false
+	}
+	return marker, true
+}
+
+// saveUpdateHealth writes the health marker; callers must hold
+// updateHistoryMutex
+func (a *App) saveUpdateHealth(marker updateHealthMarker) error {
+	if err := a.EnsureDataDir(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(a.updateHealthPath(), data, 0644)
+}
+
+// recordUpdateInstalled adds an installed update to the history and
+// starts watching whether the new version starts
+func (a *App) recordUpdateInstalled(fromVersion, toVersion string) {
+	updateHistoryMutex.Lock()
+	defer updateHistoryMutex.Unlock()
+
+	updateInstalledThisRun = true
+	now := time.Now()
+	entry := UpdateHistoryEntry{
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		InstalledAt: now,
+		Status:      updateStatusInstalled,
+	}
+	a.saveUpdateHistory(append([]UpdateHistoryEntry{entry}, a.loadUpdateHistory()...))
+	a.saveUpdateHealth(updateHealthMarker{FromVersion: fromVersion, ToVersion: toVersion, InstalledAt: now})
+}
+
+// checkUpdateHealth runs before the window opens. It counts the launch of
+// an unconfirmed update and restores the previous version when earlier
+// launches failed. It reports whether the app is restarting.
+func (a *App) checkUpdateHealth() bool {
+	updateHistoryMutex.Lock()
+	marker, ok := a.loadUpdateHealth()
+	if !ok {
+		updateHistoryMutex.Unlock()
+		return false
+	}
+	if !sameVersion(a.GetCurrentVersion(), marker.ToVersion) {
+		// The update is not the version running, so the marker is stale
+		os.Remove(a.updateHealthPath())
+		updateHistoryMutex.Unlock()
+		return false
+	}
+	if marker.Launches < updateHealthMaxLaunches {
+		marker.Launches++
+		a.saveUpdateHealth(marker)
+		updateHistoryMutex.Unlock()
+		return false
+	}
+	updateHistoryMutex.Unlock()
+
+	settings, err := a.GetSettings()
+	if err == nil && settings.Update.AutoRollback {
+		if err := a.rollbackUpdate(true); err != nil {
+			fmt.Printf("警告: 自动回滚失败: %v\n", err)
+		} else if err := a.RestartApplication(); err == nil {
+			return true
+		}
+	}
+
+	// Stop watching a version that cannot be rolled back
+	os.Remove(a.updateHealthPath())
+	return false
+}
+
+// confirmUpdateHealth marks a fresh update as working. Only the updated
+// version itself can confirm it, not the process that installed it.
+func (a *App) confirmUpdateHealth() {
+	updateHistoryMutex.Lock()
+	defer updateHistoryMutex.Unlock()
+
+	marker, ok := a.loadUpdateHealth()
+	if !ok || updateInstalledThisRun || !sameVersion(a.GetCurrentVersion(), marker.ToVersion) {
+		return
+	}
+	os.Remove(a.updateHealthPath())
+
+	entries := a.loadUpdateHistory()
+	for i := range entries {
+		if entries[i].ToVersion == marker.ToVersion && entries[i].Status == updateStatusInstalled {
+			entries[i].Status = updateStatusConfirmed
+			a.saveUpdateHistory(entries)
+			return
+		}
+	}
+}
+
+// GetUpdateHistory returns past updates, newest first
+func (a *App) GetUpdateHistory() UpdateHistory {
+	updateHistoryMutex.Lock()
+	defer updateHistoryMutex.Unlock()
+
+	history := UpdateHistory{Entries: a.loadUpdateHistory()}
+	if history.Entries == nil {
+		history.Entries = []UpdateHistoryEntry{}
+	}
+	if target, err := updateTargetPath(); err == nil {
+		if _, err := os.Stat(previousBinaryPath(target)); err == nil {
+			history.RollbackAvailable = true
+		}
+	}
+	if history.RollbackAvailable && len(history.Entries) > 0 && history.Entries[0].Status != updateStatusRolledBack {
+		history.PreviousVersion = history.Entries[0].FromVersion
+	}
+	return history
+}
+
+// RollbackUpdate restores the version replaced by the last update and
+// restarts the app
+func (a *App) RollbackUpdate() error {
+	updateRunMutex.Lock()
+	running := updateCancel != nil
+	updateRunMutex.Unlock()
+	if running {
+		return errUpdateRunning
+	}
+
+	if err := a.rollbackUpdate(false); err != nil {
+		return err
+	}
+
+	// 延迟重启，给前端时间显示结果
+	go func() {
+		time.Sleep(time.Second)
+		a.RestartApplication()
+	}()
+	return nil
+}
+
+// rollbackUpdate puts the previous executable back in place and records it.
+// After a manual rollback the version rolled back from is skipped so that
+// it is not offered again. An automatic rollback may follow launches that
+// were merely interrupted, so the version is still offered after it.
+func (a *App) rollbackUpdate(automatic bool) error {
+	targetPath, err := updateTargetPath()
+	if err != nil {
+		return fmt.Errorf("无法获取可执行文件路径: %v", err)
+	}
+	previousPath := previousBinaryPath(targetPath)
+
+	file, err := os.Open(previousPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("没有可回滚的版本")
+		}
+		return fmt.Errorf("无法读取上一版本: %v", err)
+	}
+	err = selfupdate.Apply(file, selfupdate.Options{TargetPath: targetPath})
+	file.Close()
+	if err != nil {
+		if rollbackErr := selfupdate.RollbackError(err); rollbackErr != nil {
+			return fmt.Errorf("回滚失败且无法恢复: %v, 恢复错误: %v", err, rollbackErr)
+		}
+		return fmt.Errorf("回滚失败: %v", err)
+	}
+	os.Remove(previousPath)
+	a.DismissWhatsNew()
+
+	updateHistoryMutex.Lock()
+	os.Remove(a.updateHealthPath())
+	entries := a.loadUpdateHistory()
+	badVersion := ""
+	if len(entries) > 0 && entries[0].Status != updateStatusRolledBack {
+		entries[0].Status = updateStatusRolledBack
+		entries[0].RolledBackAt = time.Now()
+		entries[0].Automatic = automatic
+		badVersion = entries[0].ToVersion
+		a.saveUpdateHistory(entries)
+	}
+	updateHistoryMutex.Unlock()
+
+	if badVersion != "" && !automatic {
+		a.SkipUpdateVersion(badVersion)
+	}
+	return nil
+}
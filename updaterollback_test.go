@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestUpdateHealth(t *testing.T) {
+	saved, savedInstalled := RuntimeVersion, updateInstalledThisRun
+	t.Cleanup(func() { RuntimeVersion, updateInstalledThisRun = saved, savedInstalled })
+
+	tests := []struct {
+		name          string
+		running       string
+		installedHere bool
+		wantConfirmed bool
+	}{
+		{name: "updated version", running: "1.5.0", wantConfirmed: true},
+		{name: "updated version with prefix", running: "v1.5.0", wantConfirmed: true},
+		{name: "installing process", running: "1.5.0", installedHere: true},
+		{name: "previous version", running: "1.4.0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestHome(t)
+			a := NewApp()
+			updateInstalledThisRun = false
+			a.recordUpdateInstalled("1.4.0", "1.5.0")
+
+			RuntimeVersion = &VersionInfo{Version: test.running}
+			updateInstalledThisRun = test.installedHere
+			a.confirmUpdateHealth()
+
+			_, pending := a.loadUpdateHealth()
+			status := a.GetUpdateHistory().Entries[0].Status
+			if confirmed := status == updateStatusConfirmed; confirmed != test.wantConfirmed || pending == confirmed {
+				t.Errorf("status = %q, marker kept = %v, want confirmed %v", status, pending, test.wantConfirmed)
+			}
+		})
+	}
+}
+
+func TestCheckUpdateHealthCountsLaunches(t *testing.T) {
+	saved, savedInstalled := RuntimeVersion, updateInstalledThisRun
+	t.Cleanup(func() { RuntimeVersion, updateInstalledThisRun = saved, savedInstalled })
+
+	setTestHome(t)
+	a := NewApp()
+	a.recordUpdateInstalled("1.4.0", "1.5.0")
+	RuntimeVersion = &VersionInfo{Version: "1.5.0"}
+	if err := a.modifySettings(func(settings *Settings) error {
+		settings.Update.AutoRollback = false
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupted launches are counted without giving up on the update
+	for launch := 1; launch <= updateHealthMaxLaunches; launch++ {
+		if a.checkUpdateHealth() {
+			t.Fatalf("launch %d restarted the app", launch)
+		}
+		marker, ok := a.loadUpdateHealth()
+		if !ok || marker.Launches != launch {
+			t.Fatalf("launch %d: marker = %+v, %v", launch, marker, ok)
+		}
+	}
+
+	// Without automatic rollback the update is no longer watched
+	a.checkUpdateHealth()
+	if _, err := os.Stat(a.updateHealthPath()); !os.IsNotExist(err) {
+		t.Errorf("health marker kept after %d failed launches", updateHealthMaxLaunches+1)
+	}
+	if status := a.GetUpdateHistory().Entries[0].Status; status != updateStatusInstalled {
+		t.Errorf("status = %q, want %q", status, updateStatusInstalled)
+	}
+}
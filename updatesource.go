@@ -0,0 +1,522 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Update sources of UpdateSettings.Source
+const (
+	updateSourceGitHub   = "github"
+	updateSourceGitLab   = "gitlab"
+	updateSourceGitea    = "gitea"
+	updateSourceManifest = "manifest" // Self-hosted JSON manifest
+)
+
+// Default API servers of the hosted update sources
+const (
+	defaultGitHubAPI = "https://api.github.com"
+	defaultGitLabURL = "https://gitlab.com"
+	defaultGiteaURL  = "https://gitea.com"
+)
+
+// updateReleaseLimit is the number of recent releases fetched from a source
+const updateReleaseLimit = 30
+
+// UpdateSettings selects where updates come from and which are offered
+type UpdateSettings struct {
+	Source          string   `json:"source"`  // github, gitlab, gitea or manifest
+	BaseURL         string   `json:"baseUrl"` // API server, or the manifest URL for manifest sources
+	Owner           string   `json:"owner"`   // Defaults to the repository the app was built from
+	Repo            string   `json:"repo"`
+	Channel         string   `json:"channel"`         // stable, beta or nightly
+	PinnedVersion   string   `json:"pinnedVersion"`   // Only this version is offered when set
+	SkippedVersions []string `json:"skippedVersions"` // Versions the user chose to skip
+
+	AutoCheck          bool   `json:"autoCheck"`          // Check in the background
+	CheckIntervalHours int    `json:"checkIntervalHours"` // Time between background checks
+	Token              string `json:"token,omitempty"`    // API token, e.g. to raise GitHub's rate limit
+	AutoRollback       bool   `json:"autoRollback"`       // Restore the previous version if an update fails to start
+}
+
+// defaultUpdateSettings returns the update settings used when none are saved
+func defaultUpdateSettings() UpdateSettings {
+	return UpdateSettings{
+		Source:             updateSourceGitHub,
+		Channel:            updateChannelStable,
+		AutoCheck:          true,
+		CheckIntervalHours: defaultUpdateCheckHours,
+		AutoRollback:       true,
+	}
+}
+
+// UpdateSource lists the releases of the app
+type UpdateSource interface {
+	// Name is shown in error messages, e.g. "GitHub"
+	Name() string
+	// Releases returns the recent published releases, including prereleases
+	Releases(ctx context.Context, client *http.Client) ([]sourceRelease, error)
+}
+
+// sourceRelease is a release as reported by an update source
+type sourceRelease struct {
+	Version     string
+	Name        string
+	Notes       string
+	Prerelease  bool
+	PublishedAt time.Time
+	Assets      []releaseAsset
+
+	// Downloads maps platform keys such as "linux-amd64" to their files,
+	// for sources that list them explicitly instead of as named assets
+	Downloads map[string]updateDownload
+}
+
+// updateDownload is the file that updates the app on one platform
+type updateDownload struct {
+	URL          string
+	Verification updateVerification
+}
+
+// downloadFor returns the download for a platform, or nil when the
+// release has none
+func (r *sourceRelease) downloadFor(platform updatePlatform) *updateDownload {
+	if r.Downloads != nil {
+		for _, key := range platformKeys(platform) {
+			if download, ok := r.Downloads[key]; ok {
+				return &download
+			}
+		}
+		return nil
+	}
+
+	asset := selectReleaseAsset(r.Assets, platform)
+	if asset == nil {
+		return nil
+	}
+	return &updateDownload{
+		URL:          asset.BrowserDownloadURL,
+		Verification: findUpdateVerification(r.Assets, asset.Name),
+	}
+}
+
+// platformKeys returns the manifest keys that fit a platform, most
+// specific first
+func platformKeys(platform updatePlatform) []string {
+	keys := []string{
+		platform.GOOS + "-" + platform.GOARCH,
+		platform.GOOS + "/" + platform.GOARCH,
+		platform.GOOS + "_" + platform.GOARCH,
+	}
+	if platform.GOOS == "darwin" {
+		keys = append(keys, "darwin-universal")
+	}
+	return append(keys, platform.GOOS)
+}
+
+// newUpdateSource creates the configured update source. Owner and
+// repository default to the ones the app was built from.
+func newUpdateSource(settings UpdateSettings) (UpdateSource, error) {
+	owner, repo := settings.Owner, settings.Repo
+	if owner == "" && repo == "" && RuntimeVersion != nil {
+		owner, repo = RuntimeVersion.GitHubOwner, RuntimeVersion.GitHubRepo
+	}
+	baseURL := strings.TrimSuffix(strings.TrimSpace(settings.BaseURL), "/")
+	token := strings.TrimSpace(settings.Token)
+
+	switch settings.Source {
+	case "", updateSourceGitHub:
+		if owner == "" || repo == "" {
+			return nil, fmt.Errorf("GitHub仓库信息未配置，无法检查更新。请配置GitHub用户名和仓库名。")
+		}
+		return &githubSource{baseURL: firstNonEmpty(baseURL, defaultGitHubAPI), owner: owner, repo: repo, token: token}, nil
+	case updateSourceGitLab:
+		if owner == "" || repo == "" {
+			return nil, fmt.Errorf("GitLab项目信息未配置，无法检查更新")
+		}
+		return &gitlabSource{baseURL: firstNonEmpty(baseURL, defaultGitLabURL), project: owner + "/" + repo, token: token}, nil
+	case updateSourceGitea:
+		if owner == "" || repo == "" {
+			return nil, fmt.Errorf("Gitea仓库信息未配置，无法检查更新")
+		}
+		return &giteaSource{baseURL: firstNonEmpty(baseURL, defaultGiteaURL), owner: owner, repo: repo, token: token}, nil
+	case updateSourceManifest:
+		if baseURL == "" {
+			return nil, fmt.Errorf("更新清单地址未配置，无法检查更新")
+		}
+		return &manifestSource{manifestURL: baseURL, token: token}, nil
+	default:
+		return nil, fmt.Errorf("未知的更新源: %s", settings.Source)
+	}
+}
+
+// authorizedSource is implemented by sources that send an API token
+type authorizedSource interface {
+	authorize(req *http.Request)
+}
+
+// githubSource reads releases of a GitHub repository
+type githubSource struct {
+	baseURL string
+	owner   string
+	repo    string
+	token   string
+}
+
+// Name implements UpdateSource
+func (s *githubSource) Name() string { return "GitHub" }
+
+// authorize implements authorizedSource
+func (s *githubSource) authorize(req *http.Request) {
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+}
+
+// Releases implements UpdateSource
+func (s *githubSource) Releases(ctx context.Context, client *http.Client) ([]sourceRelease, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=%d", s.baseURL, url.PathEscape(s.owner), url.PathEscape(s.repo), updateReleaseLimit)
+
+	var releases []githubRelease
+	if err := fetchReleaseJSON(ctx, client, s, apiURL, "仓库 "+s.owner+"/"+s.repo, &releases); err != nil {
+		return nil, err
+	}
+	return githubSourceReleases(releases), nil
+}
+
+// githubRelease is a release in the GitHub API, which Gitea mirrors
+type githubRelease struct {
+	TagName     string         `json:"tag_name"`
+	Name        string         `json:"name"`
+	Body        string         `json:"body"`
+	Draft       bool           `json:"draft"`
+	Prerelease  bool           `json:"prerelease"`
+	PublishedAt time.Time      `json:"published_at"`
+	Assets      []releaseAsset `json:"assets"`
+}
+
+// githubSourceReleases converts the API response, leaving out drafts
+func githubSourceReleases(releases []githubRelease) []sourceRelease {
+	var result []sourceRelease
+	for _, r := range releases {
+		if r.Draft {
+			continue
+		}
+		result = append(result, sourceRelease{
+			Version:     r.TagName,
+			Name:        r.Name,
+			Notes:       r.Body,
+			Prerelease:  r.Prerelease,
+			PublishedAt: r.PublishedAt,
+			Assets:      r.Assets,
+		})
+	}
+	return result
+}
+
+// giteaSource reads releases of a Gitea or Forgejo repository
+type giteaSource struct {
+	baseURL string
+	owner   string
+	repo    string
+	token   string
+}
+
+// Name implements UpdateSource
+func (s *giteaSource) Name() string { return "Gitea" }
+
+// authorize implements authorizedSource
+func (s *giteaSource) authorize(req *http.Request) {
+	if s.token != "" {
+		req.Header.Set("Authorization", "token "+s.token)
+	}
+}
+
+// Releases implements UpdateSource
+func (s *giteaSource) Releases(ctx context.Context, client *http.Client) ([]sourceRelease, error) {
+	apiURL := fmt.Sprintf("%s/api/v1/repos/%s/%s/releases?draft=false&limit=%d", s.baseURL, url.PathEscape(s.owner), url.PathEscape(s.repo), updateReleaseLimit)
+
+	var releases []githubRelease
+	if err := fetchReleaseJSON(ctx, client, s, apiURL, "仓库 "+s.owner+"/"+s.repo, &releases); err != nil {
+		return nil, err
+	}
+	return githubSourceReleases(releases), nil
+}
+
+// gitlabSource reads releases of a GitLab project
+type gitlabSource struct {
+	baseURL string
+	project string // Full path such as group/project
+	token   string
+}
+
+// Name implements UpdateSource
+func (s *gitlabSource) Name() string { return "GitLab" }
+
+// authorize implements authorizedSource
+func (s *gitlabSource) authorize(req *http.Request) {
+	if s.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", s.token)
+	}
+}
+
+// gitlabRelease is a release in the GitLab API; files are attached as links
+type gitlabRelease struct {
+	TagName         string    `json:"tag_name"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	ReleasedAt      time.Time `json:"released_at"`
+	UpcomingRelease bool      `json:"upcoming_release"`
+	Assets          struct {
+		Links []struct {
+			Name           string `json:"name"`
+			URL            string `json:"url"`
+			DirectAssetURL string `json:"direct_asset_url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+// Releases implements UpdateSource. GitLab has no prerelease flag, so
+// channels follow the version numbers alone.
+func (s *gitlabSource) Releases(ctx context.Context, client *http.Client) ([]sourceRelease, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/releases?per_page=%d", s.baseURL, url.PathEscape(s.project), updateReleaseLimit)
+
+	var releases []gitlabRelease
+	if err := fetchReleaseJSON(ctx, client, s, apiURL, "项目 "+s.project, &releases); err != nil {
+		return nil, err
+	}
+
+	var result []sourceRelease
+	for _, release := range releases {
+		if release.UpcomingRelease {
+			continue
+		}
+		converted := sourceRelease{
+			Version:     release.TagName,
+			Name:        release.Name,
+			Notes:       release.Description,
+			PublishedAt: release.ReleasedAt,
+		}
+		for _, link := range release.Assets.Links {
+			converted.Assets = append(converted.Assets, releaseAsset{
+				Name:               link.Name,
+				BrowserDownloadURL: firstNonEmpty(link.DirectAssetURL, link.URL),
+			})
+		}
+		result = append(result, converted)
+	}
+	return result, nil
+}
+
+// manifestSource reads a self-hosted JSON manifest such as
+//
+//	{
+//	  "version": "1.5.0",
+//	  "notes": "...",
+//	  "platforms": {
+//	    "windows-amd64": {"url": "URLNavigator.exe", "sha256": "...", "signature": "URLNavigator.exe.minisig"},
+//	    "linux-amd64": {"url": "https://example.com/urlnavigator-linux-amd64.tar.gz", "sha256": "..."}
+//	  }
+//	}
+//
+// Several releases, e.g. for the beta channel, can be listed in a
+// "releases" array of such objects. Relative URLs are resolved against
+// the manifest URL.
+type manifestSource struct {
+	manifestURL string
+	token       string
+}
+
+// manifestRelease is one release in an update manifest
+type manifestRelease struct {
+	Version     string                      `json:"version"`
+	Name        string                      `json:"name"`
+	Notes       string                      `json:"notes"`
+	Prerelease  bool                        `json:"prerelease"`
+	PublishedAt time.Time                   `json:"publishedAt"`
+	Platforms   map[string]manifestPlatform `json:"platforms"`
+}
+
+// updateManifest is the JSON document read by manifestSource: a single
+// release, a list of releases, or both
+type updateManifest struct {
+	manifestRelease
+	Releases []manifestRelease `json:"releases"`
+}
+
+// manifestPlatform is the download of one platform in an update manifest
+type manifestPlatform struct {
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	Signature string `json:"signature"` // Detached signature, defaults to url + ".minisig"
+}
+
+// Name implements UpdateSource
+func (s *manifestSource) Name() string { return "更新清单" }
+
+// authorize implements authorizedSource
+func (s *manifestSource) authorize(req *http.Request) {
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+}
+
+// Releases implements UpdateSource
+func (s *manifestSource) Releases(ctx context.Context, client *http.Client) ([]sourceRelease, error) {
+	var manifest updateManifest
+	if err := fetchReleaseJSON(ctx, client, s, s.manifestURL, "更新清单 "+s.manifestURL, &manifest); err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(s.manifestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := manifest.Releases
+	if manifest.Version != "" {
+		entries = append([]manifestRelease{manifest.manifestRelease}, entries...)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("更新清单缺少版本号")
+	}
+
+	releases := make([]sourceRelease, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Version != "" {
+			releases = append(releases, entry.sourceRelease(base))
+		}
+	}
+	return releases, nil
+}
+
+// sourceRelease converts a manifest entry, resolving its URLs against base
+func (r *manifestRelease) sourceRelease(base *url.URL) sourceRelease {
+	resolve := func(ref string) string {
+		parsed, err := url.Parse(ref)
+		if err != nil {
+			return ref
+		}
+		return base.ResolveReference(parsed).String()
+	}
+
+	release := sourceRelease{
+		Version:     r.Version,
+		Name:        r.Name,
+		Notes:       r.Notes,
+		Prerelease:  r.Prerelease,
+		PublishedAt: r.PublishedAt,
+		Downloads:   make(map[string]updateDownload, len(r.Platforms)),
+	}
+	for key, platform := range r.Platforms {
+		if platform.URL == "" {
+			continue
+		}
+		downloadURL := resolve(platform.URL)
+		signatureURL := downloadURL + ".minisig"
+		if platform.Signature != "" {
+			signatureURL = resolve(platform.Signature)
+		}
+
+		assetName := path.Base(platform.URL)
+		if parsed, err := url.Parse(downloadURL); err == nil {
+			assetName = path.Base(parsed.Path)
+		}
+
+		release.Downloads[strings.ToLower(key)] = updateDownload{
+			URL: downloadURL,
+			Verification: updateVerification{
+				AssetName:    assetName,
+				SignatureURL: signatureURL,
+				Checksum:     strings.TrimSpace(platform.SHA256),
+			},
+		}
+	}
+	return release
+}
+
+// fetchReleaseJSON requests a release API or manifest and decodes the
+// JSON response, turning error statuses into readable messages
+func fetchReleaseJSON(ctx context.Context, client *http.Client, source UpdateSource, apiURL, project string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if auth, ok := source.(authorizedSource); ok {
+		auth.authorize(req)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("网络连接失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("%s 未找到或没有发布版本", project)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		// GitHub answers 403 when the hourly limit is used up
+		if retryAt := rateLimitRetryAt(resp.Header); !retryAt.IsZero() {
+			return &updateRateLimitError{message: fmt.Sprintf("%s API访问限制", source.Name()), RetryAt: retryAt}
+		}
+		return fmt.Errorf("%s API访问限制，请稍后重试", source.Name())
+	case http.StatusTooManyRequests:
+		return &updateRateLimitError{message: "请求过于频繁", RetryAt: rateLimitRetryAt(resp.Header)}
+	default:
+		return fmt.Errorf("%s API请求失败，状态码: %d", source.Name(), resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("解析%s响应失败: %v", source.Name(), err)
+	}
+	return nil
+}
+
+// updateRateLimitError is returned when an update source refuses requests
+// because of its rate limit
+type updateRateLimitError struct {
+	message string
+	RetryAt time.Time // When requests are allowed again; zero if unknown
+}
+
+// Error implements error
+func (e *updateRateLimitError) Error() string {
+	if e.RetryAt.IsZero() {
+		return e.message + "，请稍后重试"
+	}
+	return fmt.Sprintf("%s，请在 %s 后重试", e.message, e.RetryAt.Local().Format("15:04"))
+}
+
+// rateLimitRetryAt reads when a rate limited client may retry from
+// Retry-After or, once the remaining requests are used up, from
+// X-RateLimit-Reset (GitHub, Gitea) or RateLimit-Reset (GitLab)
+func rateLimitRetryAt(header http.Header) time.Time {
+	if value := strings.TrimSpace(header.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return at
+		}
+	}
+
+	remaining := firstNonEmpty(header.Get("X-RateLimit-Remaining"), header.Get("RateLimit-Remaining"))
+	if strings.TrimSpace(remaining) != "0" {
+		return time.Time{}
+	}
+	reset, err := strconv.ParseInt(strings.TrimSpace(firstNonEmpty(header.Get("X-RateLimit-Reset"), header.Get("RateLimit-Reset"))), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(reset, 0)
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newUpdateSourceServer stands in for the release APIs of GitHub, GitLab
+// and Gitea and serves an update manifest
+func newUpdateSourceServer(t *testing.T) *httptest.Server {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		reply := func(header, value, body string) {
+			if got := r.Header.Get(header); got != value {
+				t.Errorf("%s: %s = %q, want %q", r.URL.Path, header, got, value)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}
+
+		switch r.URL.EscapedPath() {
+		case "/repos/owner/repo/releases":
+			if r.URL.Query().Get("per_page") != strconv.Itoa(updateReleaseLimit) {
+				t.Errorf("GitHub query = %q", r.URL.RawQuery)
+			}
+			reply("Authorization", "Bearer secret", `[
+				{"tag_name": "v1.6.0", "draft": true, "assets": []},
+				{"tag_name": "v1.5.0-beta.1", "name": "Beta", "body": "beta notes", "prerelease": true,
+				 "published_at": "2024-05-01T10:00:00Z",
+				 "assets": [{"name": "URLNavigator-linux-amd64.tar.gz", "browser_download_url": "https://dl.example/beta.tar.gz", "size": 10}]},
+				{"tag_name": "v1.4.0", "name": "Stable", "body": "stable notes", "published_at": "2024-04-01T10:00:00Z", "assets": []}
+			]`)
+		case "/api/v1/repos/owner/repo/releases":
+			if r.URL.Query().Get("draft") != "false" {
+				t.Errorf("Gitea query = %q", r.URL.RawQuery)
+			}
+			reply("Authorization", "token secret", `[
+				{"tag_name": "v1.5.0", "name": "Gitea release", "body": "notes", "published_at": "2024-05-01T10:00:00Z",
+				 "assets": [{"name": "URLNavigator.exe", "browser_download_url": "https://gitea.example/URLNavigator.exe", "size": 10}]}
+			]`)
+		case "/api/v4/projects/group%2Fsub%2Fproject/releases":
+			reply("PRIVATE-TOKEN", "secret", `[
+				{"tag_name": "v2.0.0", "name": "Upcoming", "upcoming_release": true, "assets": {"links": []}},
+				{"tag_name": "v1.5.0", "name": "GitLab release", "description": "notes", "released_at": "2024-05-01T10:00:00Z",
+				 "assets": {"links": [
+					{"name": "URLNavigator-linux-amd64.tar.gz", "url": "https://gitlab.example/link", "direct_asset_url": "https://gitlab.example/direct"},
+					{"name": "SHA256SUMS", "url": "https://gitlab.example/sums"}
+				 ]}}
+			]`)
+		case "/updates/stable/manifest.json":
+			reply("Authorization", "Bearer secret", `{
+				"version": "1.5.0",
+				"notes": "manifest notes",
+				"platforms": {
+					"windows-amd64": {"url": "URLNavigator.exe", "sha256": " abc123 "},
+					"Linux-AMD64": {"url": "../files/urlnavigator-linux.tar.gz?token=1", "signature": "/sigs/linux.minisig"},
+					"darwin": {"url": "https://cdn.example/URLNavigator.app.zip"},
+					"freebsd": {"url": ""}
+				},
+				"releases": [
+					{"version": "1.6.0-beta.1", "prerelease": true, "platforms": {"linux-amd64": {"url": "beta/urlnavigator"}}},
+					{"notes": "entry without a version is skipped"}
+				]
+			}`)
+		case "/limited/repos/owner/repo/releases":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestUpdateSourceReleases(t *testing.T) {
+	server := newUpdateSourceServer(t)
+	published := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		settings UpdateSettings
+		want     []sourceRelease
+	}{
+		{
+			name:     "github",
+			settings: UpdateSettings{Source: updateSourceGitHub, BaseURL: server.URL + "/", Owner: "owner", Repo: "repo", Token: "secret"},
+			want: []sourceRelease{
+				{
+					Version: "v1.5.0-beta.1", Name: "Beta", Notes: "beta notes", Prerelease: true, PublishedAt: published,
+					Assets: []releaseAsset{{Name: "URLNavigator-linux-amd64.tar.gz", BrowserDownloadURL: "https://dl.example/beta.tar.gz", Size: 10}},
+				},
+				{Version: "v1.4.0", Name: "Stable", Notes: "stable notes", PublishedAt: published.AddDate(0, -1, 0), Assets: []releaseAsset{}},
+			},
+		},
+		{
+			name:     "gitea",
+			settings: UpdateSettings{Source: updateSourceGitea, BaseURL: server.URL, Owner: "owner", Repo: "repo", Token: "secret"},
+			want: []sourceRelease{
+				{
+					Version: "v1.5.0", Name: "Gitea release", Notes: "notes", PublishedAt: published,
+					Assets: []releaseAsset{{Name: "URLNavigator.exe", BrowserDownloadURL: "https://gitea.example/URLNavigator.exe", Size: 10}},
+				},
+			},
+		},
+		{
+			name:     "gitlab",
+			settings: UpdateSettings{Source: updateSourceGitLab, BaseURL: server.URL, Owner: "group/sub", Repo: "project", Token: "secret"},
+			want: []sourceRelease{
+				{
+					Version: "v1.5.0", Name: "GitLab release", Notes: "notes", PublishedAt: published,
+					Assets: []releaseAsset{
+						{Name: "URLNavigator-linux-amd64.tar.gz", BrowserDownloadURL: "https://gitlab.example/direct"},
+						{Name: "SHA256SUMS", BrowserDownloadURL: "https://gitlab.example/sums"},
+					},
+				},
+			},
+		},
+		{
+			name:     "manifest",
+			settings: UpdateSettings{Source: updateSourceManifest, BaseURL: server.URL + "/updates/stable/manifest.json", Token: "secret"},
+			want: []sourceRelease{
+				{
+					Version: "1.5.0", Notes: "manifest notes",
+					Downloads: map[string]updateDownload{
+						"windows-amd64": {
+							URL: server.URL + "/updates/stable/URLNavigator.exe",
+							Verification: updateVerification{
+								AssetName:    "URLNavigator.exe",
+								SignatureURL: server.URL + "/updates/stable/URLNavigator.exe.minisig",
+								Checksum:     "abc123",
+							},
+						},
+						"linux-amd64": {
+							URL: server.URL + "/updates/files/urlnavigator-linux.tar.gz?token=1",
+							Verification: updateVerification{
+								AssetName:    "urlnavigator-linux.tar.gz",
+								SignatureURL: server.URL + "/sigs/linux.minisig",
+							},
+						},
+						"darwin": {
+							URL: "https://cdn.example/URLNavigator.app.zip",
+							Verification: updateVerification{
+								AssetName:    "URLNavigator.app.zip",
+								SignatureURL: "https://cdn.example/URLNavigator.app.zip.minisig",
+							},
+						},
+					},
+				},
+				{
+					Version: "1.6.0-beta.1", Prerelease: true,
+					Downloads: map[string]updateDownload{
+						"linux-amd64": {
+							URL: server.URL + "/updates/stable/beta/urlnavigator",
+							Verification: updateVerification{
+								AssetName:    "urlnavigator",
+								SignatureURL: server.URL + "/updates/stable/beta/urlnavigator.minisig",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, err := newUpdateSource(test.settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := source.Releases(context.Background(), server.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d releases, want %d: %+v", len(got), len(test.want), got)
+			}
+			for i := range got {
+				if !got[i].PublishedAt.Equal(test.want[i].PublishedAt) {
+					t.Errorf("release %d published at %v, want %v", i, got[i].PublishedAt, test.want[i].PublishedAt)
+				}
+				got[i].PublishedAt, test.want[i].PublishedAt = time.Time{}, time.Time{}
+				if !reflect.DeepEqual(got[i], test.want[i]) {
+					t.Errorf("release %d = %+v\nwant %+v", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateSourceErrors(t *testing.T) {
+	server := newUpdateSourceServer(t)
+
+	missing, err := newUpdateSource(UpdateSettings{Source: updateSourceGitHub, BaseURL: server.URL, Owner: "nobody", Repo: "none"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := missing.Releases(context.Background(), server.Client()); err == nil {
+		t.Error("missing repository returned no error")
+	}
+
+	limited, err := newUpdateSource(UpdateSettings{Source: updateSourceGitHub, BaseURL: server.URL + "/limited", Owner: "owner", Repo: "repo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = limited.Releases(context.Background(), server.Client())
+	var rateLimit *updateRateLimitError
+	if !errors.As(err, &rateLimit) || rateLimit.RetryAt.IsZero() {
+		t.Errorf("error = %v, want a rate limit error with a retry time", err)
+	}
+
+	for _, settings := range []UpdateSettings{
+		{Source: updateSourceManifest},
+		{Source: updateSourceGitLab, Owner: "group"},
+		{Source: "svn", Owner: "owner", Repo: "repo"},
+	} {
+		if _, err := newUpdateSource(settings); err == nil {
+			t.Errorf("newUpdateSource(%+v) returned no error", settings)
+		}
+	}
+}
+
+func TestRateLimitRetryAt(t *testing.T) {
+	reset := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		header  http.Header
+		want    time.Time
+		wantIn  time.Duration // Relative to now, for Retry-After seconds
+		wantSet bool
+	}{
+		{name: "Retry-After seconds", header: http.Header{"Retry-After": {" 120 "}}, wantIn: 120 * time.Second, wantSet: true},
+		{name: "Retry-After date", header: http.Header{"Retry-After": {reset.Format(http.TimeFormat)}}, want: reset, wantSet: true},
+		{
+			name: "Retry-After wins over the reset time",
+			header: http.Header{
+				"Retry-After": {"60"}, "X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {strconv.FormatInt(reset.Unix(), 10)},
+			},
+			wantIn: time.Minute, wantSet: true,
+		},
+		{
+			name:   "GitHub reset",
+			header: http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {strconv.FormatInt(reset.Unix(), 10)}},
+			want:   reset, wantSet: true,
+		},
+		{
+			name:   "GitLab reset",
+			header: http.Header{"Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {strconv.FormatInt(reset.Unix(), 10)}},
+			want:   reset, wantSet: true,
+		},
+		{
+			name:   "requests remaining",
+			header: http.Header{"X-Ratelimit-Remaining": {"12"}, "X-Ratelimit-Reset": {strconv.FormatInt(reset.Unix(), 10)}},
+		},
+		{name: "reset without remaining", header: http.Header{"X-Ratelimit-Reset": {strconv.FormatInt(reset.Unix(), 10)}}},
+		{name: "invalid reset", header: http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"soon"}}},
+		{name: "invalid Retry-After", header: http.Header{"Retry-After": {"later"}}},
+		{name: "no headers", header: http.Header{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := rateLimitRetryAt(test.header)
+			switch {
+			case !test.wantSet:
+				if !got.IsZero() {
+					t.Errorf("retry at %v, want zero", got)
+				}
+			case test.wantIn > 0:
+				if wait := time.Until(got); wait > test.wantIn || wait < test.wantIn-5*time.Second {
+					t.Errorf("retry in %v, want %v", wait, test.wantIn)
+				}
+			case !got.Equal(test.want):
+				t.Errorf("retry at %v, want %v", got, test.want)
+			}
+		})
+	}
+}
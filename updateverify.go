@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"aead.dev/minisign"
+)
+
+// UpdatePublicKey is the key release assets are signed with: a minisign
+// public key ("RWQ...") or a base64 ed25519 key. Updates are refused while
+// it is empty.
+// -ldflags "-X main.UpdatePublicKey=RWQ..."
+var UpdatePublicKey = ""
+
+// Size limits of the verification files of a release
+const (
+	updateSignatureMaxSize = 64 * 1024
+	updateChecksumMaxSize  = 1024 * 1024
+)
+
+// checksumManifestNames are the accepted names of the SHA-256 manifest
+var checksumManifestNames = []string{"sha256sums", "sha256sums.txt", "checksums.txt", "checksums.sha256"}
+
+// signatureSuffixes are the accepted extensions of detached signatures
+var signatureSuffixes = []string{".minisig", ".sig"}
+
+// updateVerification locates the files proving an update asset authentic
+type updateVerification struct {
+	AssetName            string
+	SignatureURL         string // Signature of the asset itself
+	ChecksumURL          string // SHA-256 manifest listing the asset
+	ChecksumSignatureURL string // Signature of the manifest
+	Checksum             string // Hex SHA-256 given by the source instead of a manifest
+}
+
+var (
+	// Verification files of the assets found by the last update check
+	updateVerificationMutex sync.Mutex
+	updateVerifications     = make(map[string]updateVerification)
+)
+
+// rememberUpdateVerification records the verification files of an asset
+// so that DownloadAndApplyUpdate can find them from its URL
+func rememberUpdateVerification(assetURL string, verification updateVerification) {
+	updateVerificationMutex.Lock()
+	defer updateVerificationMutex.Unlock()
+	updateVerifications[assetURL] = verification
+}
+
+// lookupUpdateVerification returns the verification files of an asset,
+// assuming the usual names next to it when no update check listed them
+func lookupUpdateVerification(assetURL string) updateVerification {
+	updateVerificationMutex.Lock()
+	verification, ok := updateVerifications[assetURL]
+	updateVerificationMutex.Unlock()
+	if ok {
+		return verification
+	}
+
+	parsed, err := url.Parse(assetURL)
+	if err != nil {
+		return updateVerification{}
+	}
+	name := path.Base(parsed.Path)
+	parsed.Path = path.Join(path.Dir(parsed.Path), "SHA256SUMS")
+	parsed.RawQuery = ""
+
+	return updateVerification{
+		AssetName:            name,
+		SignatureURL:         assetURL + ".minisig",
+		ChecksumURL:          parsed.String(),
+		ChecksumSignatureURL: parsed.String() + ".minisig",
+	}
+}
+
+// findUpdateVerification picks the signature and checksum assets published
+// with a release asset
+func findUpdateVerification(assets []releaseAsset, assetName string) updateVerification {
+	byName := make(map[string]string, len(assets))
+	for _, asset := range assets {
+		byName[strings.ToLower(asset.Name)] = asset.BrowserDownloadURL
+	}
+
+	verification := updateVerification{AssetName: assetName}
+	verification.SignatureURL = findSignatureAsset(byName, assetName)
+	for _, name := range checksumManifestNames {
+		if checksumURL, ok := byName[name]; ok {
+			verification.ChecksumURL = checksumURL
+			verification.ChecksumSignatureURL = findSignatureAsset(byName, name)
+			break
+		}
+	}
+	return verification
+}
+
+// findSignatureAsset returns the URL of the detached signature of a file
+func findSignatureAsset(byName map[string]string, name string) string {
+	for _, suffix := range signatureSuffixes {
+		if signatureURL, ok := byName[strings.ToLower(name)+suffix]; ok {
+			return signatureURL
+		}
+	}
+	return ""
+}
+
+// verifyUpdate checks a downloaded asset against its SHA-256 checksum and
+// a signature by UpdatePublicKey, either of the asset or of the checksum
+// manifest. It returns the SHA-256 of the asset.
+func verifyUpdate(client *http.Client, verification updateVerification, data []byte) ([]byte, error) {
+	publicKey := strings.TrimSpace(UpdatePublicKey)
+	// The mock update server signs its releases with a key of its own
+	if mockKey, ok := mockUpdatePublicKey(verification.SignatureURL); ok {
+		publicKey = mockKey
+	}
+	if publicKey == "" {
+		return nil, fmt.Errorf("未配置更新签名公钥，拒绝安装未经验证的更新")
+	}
+
+	expected := verification.Checksum
+	var manifest []byte
+	if expected == "" {
+		if verification.ChecksumURL == "" {
+			return nil, fmt.Errorf("发布版本中没有 SHA-256 校验文件")
+		}
+
+		var err error
+		manifest, err = fetchVerificationFile(client, verification.ChecksumURL, updateChecksumMaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("下载校验文件失败: %v", err)
+		}
+		var ok bool
+		if expected, ok = lookupChecksum(manifest, verification.AssetName); !ok {
+			return nil, fmt.Errorf("校验文件中没有 %s", verification.AssetName)
+		}
+	}
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), expected) {
+		return nil, fmt.Errorf("SHA-256 校验失败，文件可能已被篡改")
+	}
+
+	// A signature of the asset itself is preferred; a signed manifest
+	// covers the asset through its checksum
+	if verification.SignatureURL != "" {
+		if signature, err := fetchVerificationFile(client, verification.SignatureURL, updateSignatureMaxSize); err == nil {
+			if err := verifyUpdateSignature(publicKey, data, signature); err != nil {
+				return nil, err
+			}
+			return sum[:], nil
+		}
+	}
+	if manifest != nil && verification.ChecksumSignatureURL != "" {
+		if signature, err := fetchVerificationFile(client, verification.ChecksumSignatureURL, updateSignatureMaxSize); err == nil {
+			if err := verifyUpdateSignature(publicKey, manifest, signature); err != nil {
+				return nil, err
+			}
+			return sum[:], nil
+		}
+	}
+	return nil, fmt.Errorf("发布版本中没有更新签名")
+}
+
+// fetchVerificationFile downloads a small signature or checksum file
+func fetchVerificationFile(client *http.Client, fileURL string, maxSize int64) ([]byte, error) {
+	resp, err := client.Get(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP状态码: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("文件过大")
+	}
+	return data, nil
+}
+
+// lookupChecksum finds the hex SHA-256 of a file in a manifest written by
+// sha256sum ("<hash>  <name>") or in BSD style ("SHA256 (<name>) = <hash>")
+func lookupChecksum(manifest []byte, name string) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if rest, ok := strings.CutPrefix(line, "SHA256 ("); ok {
+			file, hash, ok := strings.Cut(rest, ") = ")
+			if ok && file == name {
+				return strings.TrimSpace(hash), true
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], true
+		}
+	}
+	return "", false
+}
+
+// verifyUpdateSignature checks a minisign signature file or a raw ed25519
+// signature, binary or base64 encoded
+func verifyUpdateSignature(publicKey string, message, signature []byte) error {
+	text := bytes.TrimSpace(signature)
+	if bytes.HasPrefix(text, []byte("untrusted comment:")) {
+		var key minisign.PublicKey
+		if err := key.UnmarshalText([]byte(publicKey)); err != nil {
+			return fmt.Errorf("更新签名公钥无效: %v", err)
+		}
+		if !minisign.Verify(key, message, text) {
+			return fmt.Errorf("更新签名验证失败")
+		}
+		return nil
+	}
+
+	key, err := ed25519PublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	if len(signature) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(string(text))
+		if err != nil || len(decoded) != ed25519.SignatureSize {
+			return fmt.Errorf("更新签名格式无效")
+		}
+		signature = decoded
+	}
+	if !ed25519.Verify(key, message, signature) {
+		return fmt.Errorf("更新签名验证失败")
+	}
+	return nil
+}
+
+// ed25519PublicKey decodes a base64 ed25519 key or extracts it from a
+// minisign public key
+func ed25519PublicKey(publicKey string) (ed25519.PublicKey, error) {
+	lines := strings.Split(strings.TrimSpace(publicKey), "\n")
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil {
+		return nil, fmt.Errorf("更新签名公钥无效: %v", err)
+	}
+
+	switch {
+	case len(decoded) == ed25519.PublicKeySize:
+		return ed25519.PublicKey(decoded), nil
+	case len(decoded) == 2+8+ed25519.PublicKeySize && string(decoded[:2]) == "Ed":
+		return ed25519.PublicKey(decoded[10:]), nil
+	}
+	return nil, fmt.Errorf("更新签名公钥长度无效")
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aead.dev/minisign"
+)
+
+// setUpdatePublicKey replaces UpdatePublicKey for the duration of a test
+func setUpdatePublicKey(t *testing.T, key string) {
+	previous := UpdatePublicKey
+	UpdatePublicKey = key
+	t.Cleanup(func() { UpdatePublicKey = previous })
+}
+
+func TestVerifyUpdate(t *testing.T) {
+	publicKey, privateKey, err := minisign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _, err := minisign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey := base64.StdEncoding.EncodeToString(edPublic)
+
+	asset := []byte("release binary")
+	tampered := []byte("release binarY")
+	sum := sha256.Sum256(asset)
+	checksum := hex.EncodeToString(sum[:])
+	manifest := []byte(checksum + "  urlnav-linux-amd64.tar.gz\n" + strings.Repeat("0", 64) + "  other.zip\n")
+	bsdManifest := []byte("SHA256 (other.zip) = " + strings.Repeat("0", 64) + "\nSHA256 (urlnav-linux-amd64.tar.gz) = " + checksum + "\n")
+	binaryManifest := []byte(strings.ToUpper(checksum) + " *urlnav-linux-amd64.tar.gz\n")
+
+	files := map[string][]byte{
+		"/asset.minisig":          minisign.Sign(privateKey, asset),
+		"/asset.sig":              ed25519.Sign(edPrivate, asset),
+		"/asset.sig.b64":          []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(edPrivate, asset)) + "\n"),
+		"/SHA256SUMS":             manifest,
+		"/SHA256SUMS.minisig":     minisign.Sign(privateKey, manifest),
+		"/BSD.txt":                bsdManifest,
+		"/BSD.txt.minisig":        minisign.Sign(privateKey, bsdManifest),
+		"/binary.txt":             binaryManifest,
+		"/binary.txt.minisig":     minisign.Sign(privateKey, binaryManifest),
+		"/garbage.sig":            []byte("not a signature"),
+		"/unsigned/SHA256SUMS":    manifest,
+		"/SHA256SUMS.ed25519.sig": ed25519.Sign(edPrivate, manifest),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	verification := func(signature, manifest, manifestSignature string) updateVerification {
+		v := updateVerification{AssetName: "urlnav-linux-amd64.tar.gz"}
+		if signature != "" {
+			v.SignatureURL = server.URL + signature
+		}
+		if manifest != "" {
+			v.ChecksumURL = server.URL + manifest
+		}
+		if manifestSignature != "" {
+			v.ChecksumSignatureURL = server.URL + manifestSignature
+		}
+		return v
+	}
+	withChecksum := func(v updateVerification, checksum string) updateVerification {
+		v.Checksum = checksum
+		return v
+	}
+
+	tamperedSum := sha256.Sum256(tampered)
+	tests := []struct {
+		name         string
+		key          string
+		verification updateVerification
+		data         []byte
+		wantErr      string
+	}{
+		{
+			name:         "minisign signature of the asset",
+			key:          publicKey.String(),
+			verification: verification("/asset.minisig", "/SHA256SUMS", "/SHA256SUMS.minisig"),
+			data:         asset,
+		},
+		{
+			name:         "only the manifest is signed",
+			key:          publicKey.String(),
+			verification: verification("/missing.minisig", "/SHA256SUMS", "/SHA256SUMS.minisig"),
+			data:         asset,
+		},
+		{
+			name:         "BSD style manifest",
+			key:          publicKey.String(),
+			verification: verification("", "/BSD.txt", "/BSD.txt.minisig"),
+			data:         asset,
+		},
+		{
+			name:         "binary mode manifest line",
+			key:          publicKey.String(),
+			verification: verification("", "/binary.txt", "/binary.txt.minisig"),
+			data:         asset,
+		},
+		{
+			name:         "checksum from the source",
+			key:          publicKey.String(),
+			verification: withChecksum(verification("/asset.minisig", "", ""), checksum),
+			data:         asset,
+		},
+		{
+			name:         "raw ed25519 signature",
+			key:          edKey,
+			verification: verification("/asset.sig", "/SHA256SUMS", ""),
+			data:         asset,
+		},
+		{
+			name:         "base64 ed25519 signature",
+			key:          edKey,
+			verification: verification("/asset.sig.b64", "/SHA256SUMS", ""),
+			data:         asset,
+		},
+		{
+			name:         "ed25519 signature of the manifest",
+			key:          edKey,
+			verification: verification("", "/SHA256SUMS", "/SHA256SUMS.ed25519.sig"),
+			data:         asset,
+		},
+		{
+			name:         "tampered asset",
+			key:          publicKey.String(),
+			verification: verification("/asset.minisig", "/SHA256SUMS", "/SHA256SUMS.minisig"),
+			data:         tampered,
+			wantErr:      "SHA-256 校验失败",
+		},
+		{
+			name:         "tampered asset with a matching checksum",
+			key:          publicKey.String(),
+			verification: withChecksum(verification("/asset.minisig", "", ""), hex.EncodeToString(tamperedSum[:])),
+			data:         tampered,
+			wantErr:      "更新签名验证失败",
+		},
+		{
+			name:         "wrong key",
+			key:          otherKey.String(),
+			verification: verification("/asset.minisig", "/SHA256SUMS", "/SHA256SUMS.minisig"),
+			data:         asset,
+			wantErr:      "更新签名验证失败",
+		},
+		{
+			name:         "wrong key for the manifest",
+			key:          otherKey.String(),
+			verification: verification("/missing.minisig", "/SHA256SUMS", "/SHA256SUMS.minisig"),
+			data:         asset,
+			wantErr:      "更新签名验证失败",
+		},
+		{
+			name:         "ed25519 signature with another key",
+			key:          publicKey.String(),
+			verification: verification("/asset.sig", "/SHA256SUMS", ""),
+			data:         asset,
+			wantErr:      "更新签名验证失败",
+		},
+		{
+			name:         "malformed signature",
+			key:          edKey,
+			verification: verification("/garbage.sig", "/SHA256SUMS", ""),
+			data:         asset,
+			wantErr:      "更新签名格式无效",
+		},
+		{
+			name:         "signature missing and manifest unsigned",
+			key:          publicKey.String(),
+			verification: verification("/missing.minisig", "/unsigned/SHA256SUMS", "/unsigned/SHA256SUMS.minisig"),
+			data:         asset,
+			wantErr:      "没有更新签名",
+		},
+		{
+			name:         "no public key",
+			key:          "  ",
+			verification: verification("/asset.minisig", "/SHA256SUMS", "/SHA256SUMS.minisig"),
+			data:         asset,
+			wantErr:      "未配置更新签名公钥",
+		},
+		{
+			name:         "no manifest",
+			key:          publicKey.String(),
+			verification: verification("/asset.minisig", "", ""),
+			data:         asset,
+			wantErr:      "没有 SHA-256 校验文件",
+		},
+		{
+			name: "asset missing from the manifest",
+			key:  publicKey.String(),
+			verification: func() updateVerification {
+				v := verification("/asset.minisig", "/SHA256SUMS", "")
+				v.AssetName = "urlnav-windows-amd64.zip"
+				return v
+			}(),
+			data:    asset,
+			wantErr: "校验文件中没有",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setUpdatePublicKey(t, test.key)
+			got, err := verifyUpdate(server.Client(), test.verification, test.data)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(got) != checksum {
+				t.Errorf("checksum = %x, want %s", got, checksum)
+			}
+		})
+	}
+}
+
+func TestLookupChecksum(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+		wantOK   bool
+	}{
+		{name: "sha256sum", manifest: hash + "  app.zip\n", want: hash, wantOK: true},
+		{name: "binary mode", manifest: hash + " *app.zip\n", want: hash, wantOK: true},
+		{name: "BSD style", manifest: "SHA256 (app.zip) = " + hash + "\n", want: hash, wantOK: true},
+		{name: "CRLF and blank lines", manifest: "\r\n" + hash + "  app.zip\r\n", want: hash, wantOK: true},
+		{name: "other file", manifest: hash + "  app.zip.minisig\nSHA256 (app.zip.sig) = " + hash + "\n"},
+		{name: "prefix of the name", manifest: hash + "  app\n"},
+		{name: "empty", manifest: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := lookupChecksum([]byte(test.manifest), "app.zip")
+			if got != test.want || ok != test.wantOK {
+				t.Errorf("lookupChecksum() = %q, %v, want %q, %v", got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestEd25519PublicKey(t *testing.T) {
+	minisignKey, _, err := minisign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile, err := minisignKey.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A minisign key is the algorithm and key ID followed by the ed25519 key
+	encoded, err := base64.StdEncoding.DecodeString(minisignKey.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	minisignEd := ed25519.PublicKey(encoded[10:])
+
+	tests := []struct {
+		name    string
+		key     string
+		want    ed25519.PublicKey
+		wantErr string
+	}{
+		{name: "base64 ed25519", key: base64.StdEncoding.EncodeToString(edPublic), want: edPublic},
+		{name: "minisign key", key: minisignKey.String(), want: minisignEd},
+		{name: "minisign key file", key: string(keyFile) + "\n", want: minisignEd},
+		{name: "not base64", key: "RWQ!!!", wantErr: "更新签名公钥无效"},
+		{name: "wrong length", key: base64.StdEncoding.EncodeToString(edPublic[:16]), wantErr: "长度无效"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ed25519PublicKey(test.key)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("key = %x, want %x", got, test.want)
+			}
+		})
+	}
+}
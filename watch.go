@@ -0,0 +1,583 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Page watch events sent to the frontend
+const (
+	eventWatchChanged = "watch:changed"
+	eventWatchChecked = "watch:checked"
+)
+
+// watchMinInterval is the shortest allowed check interval
+const watchMinInterval = 5
+
+// watchDefaultInterval is used when a watch is enabled without an interval
+const watchDefaultInterval = 60
+
+// watchMaxChanges is the number of changes kept per bookmark
+const watchMaxChanges = 50
+
+// watchDiffContext is the number of unchanged lines kept around changes
+const watchDiffContext = 2
+
+// watchMaxDiffLines limits the size of the texts compared line by line
+const watchMaxDiffLines = 2000
+
+// WatchSettings configures change monitoring of a bookmarked page
+type WatchSettings struct {
+	Enabled         bool      `json:"enabled"`
+	IntervalMinutes int       `json:"intervalMinutes"`
+	Selector        string    `json:"selector,omitempty"` // CSS selector of the watched content
+	LastCheckedAt   time.Time `json:"lastCheckedAt,omitempty"`
+	LastChangedAt   time.Time `json:"lastChangedAt,omitempty"`
+	LastHash        string    `json:"lastHash,omitempty"`
+	LastError       string    `json:"lastError,omitempty"`
+}
+
+// DiffLine is one line of a text diff
+type DiffLine struct {
+	Op   string `json:"op"` // "add", "remove", "context" or "skip" for omitted lines
+	Text string `json:"text"`
+}
+
+// PageChange is a detected change of a watched page
+type PageChange struct {
+	ID           string     `json:"id"`
+	URLID        string     `json:"urlId"`
+	DetectedAt   time.Time  `json:"detectedAt"`
+	Hash         string     `json:"hash"`
+	PreviousHash string     `json:"previousHash"`
+	Added        int        `json:"added"`
+	Removed      int        `json:"removed"`
+	Diff         []DiffLine `json:"diff"`
+}
+
+// WatchCheckedEvent is sent after every check of a watched page
+type WatchCheckedEvent struct {
+	URLID   string `json:"urlId"`
+	Changed bool   `json:"changed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// WatchChangedEvent is sent when a watched page changed
+type WatchChangedEvent struct {
+	URLID  string      `json:"urlId"`
+	Title  string      `json:"title"`
+	URL    string      `json:"url"`
+	Change *PageChange `json:"change"`
+}
+
+// watchMutex serializes access to the stored page texts and change feeds.
+// It is taken before urlsMutex when both are needed.
+var watchMutex sync.Mutex
+
+// changesDir returns the directory holding watched page texts and changes
+func (a *App) changesDir() string {
+	return filepath.Join(a.GetDataDir(), "changes")
+}
+
+// SetWatch enables or disables change monitoring of a bookmark. Changing
+// the selector starts over with a new baseline.
+func (a *App) SetWatch(id string, enabled bool, intervalMinutes int, selector string) (*URLItem, error) {
+	selector = strings.TrimSpace(selector)
+	if selector != "" {
+		if _, err := parseSelector(selector); err != nil {
+			return nil, err
+		}
+	}
+	if intervalMinutes <= 0 {
+		intervalMinutes = watchDefaultInterval
+	}
+	if intervalMinutes < watchMinInterval {
+		intervalMinutes = watchMinInterval
+	}
+
+	var updated *URLItem
+	err := a.modifyURLs(func(urls []URLItem) ([]URLItem, error) {
+		for i := range urls {
+			if urls[i].ID != id {
+				continue
+			}
+
+			watch := urls[i].Watch
+			if watch == nil {
+				watch = &WatchSettings{}
+			}
+			if watch.Selector != selector {
+				watch.LastHash = ""
+				watch.LastCheckedAt = time.Time{}
+			}
+			watch.Enabled = enabled
+			watch.IntervalMinutes = intervalMinutes
+			watch.Selector = selector
+
+			urls[i].Watch = watch
+			item := urls[i]
+			updated = &item
+			return urls, nil
+		}
+		return nil, fmt.Errorf("URL with id %s not found", id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// GetChanges returns the detected changes of a watched bookmark, newest first
+func (a *App) GetChanges(id string) ([]PageChange, error) {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+	return a.readChanges(id)
+}
+
+// CheckPageNow checks a watched bookmark immediately and returns the
+// change, or nil if the content is unchanged
+func (a *App) CheckPageNow(id string) (*PageChange, error) {
+	item, err := a.findURL(id)
+	if err != nil {
+		return nil, err
+	}
+	if item.Watch == nil {
+		return nil, fmt.Errorf("URL with id %s is not watched", id)
+	}
+	return a.checkWatchedPage(*item)
+}
+
+// checkWatchedPage fetches a watched page, compares its text with the
+// previous version and records a change if it differs
+func (a *App) checkWatchedPage(item URLItem) (*PageChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	defer cancel()
+
+	client, err := a.httpClient(0)
+	var text string
+	if err == nil {
+		text, err = fetchWatchedText(ctx, client, item.URL, item.Watch.Selector)
+	}
+	if err != nil {
+		a.updateWatchState(item.ID, item.Watch.Selector, func(watch *WatchSettings) {
+			watch.LastCheckedAt = time.Now()
+			watch.LastError = err.Error()
+		})
+		a.emitEvent(eventWatchChecked, WatchCheckedEvent{URLID: item.ID, Error: err.Error()})
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(text))
+	hash := hex.EncodeToString(sum[:])
+
+	// The bookmark may have been deleted, or its selector changed, while
+	// the page was fetched; the text then belongs to nothing stored
+	watchMutex.Lock()
+	current, err := a.findURL(item.ID)
+	if err != nil || current.Watch == nil || current.Watch.Selector != item.Watch.Selector {
+		watchMutex.Unlock()
+		return nil, nil
+	}
+
+	var change *PageChange
+	lastHash := current.Watch.LastHash
+	previous, readErr := os.ReadFile(a.watchTextPath(item.ID))
+	if lastHash != "" && lastHash != hash && readErr == nil {
+		change = newPageChange(item.ID, string(previous), text, lastHash, hash)
+		err = a.appendChange(item.ID, change)
+	}
+	if err == nil {
+		err = a.writeWatchText(item.ID, text)
+	}
+	if err == nil {
+		err = a.updateWatchState(item.ID, item.Watch.Selector, func(watch *WatchSettings) {
+			watch.LastCheckedAt = time.Now()
+			watch.LastHash = hash
+			watch.LastError = ""
+			if change != nil {
+				watch.LastChangedAt = change.DetectedAt
+			}
+		})
+	}
+	watchMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	a.emitEvent(eventWatchChecked, WatchCheckedEvent{URLID: item.ID, Changed: change != nil})
+	if change != nil {
+		a.emitEvent(eventWatchChanged, WatchChangedEvent{URLID: item.ID, Title: item.Title, URL: item.URL, Change: change})
+	}
+	return change, nil
+}
+
+// updateWatchState changes the stored watch state of a bookmark, unless
+// its selector is no longer the one the state was computed for
+func (a *App) updateWatchState(id, selector string, update func(watch *WatchSettings)) error {
+	return a.modifyURLs(func(urls []URLItem) ([]URLItem, error) {
+		for i := range urls {
+			if urls[i].ID == id && urls[i].Watch != nil && urls[i].Watch.Selector == selector {
+				update(urls[i].Watch)
+			}
+		}
+		return urls, nil
+	})
+}
+
+// watchTextPath returns the file holding the last seen text of a page
+func (a *App) watchTextPath(id string) string {
+	return filepath.Join(a.changesDir(), filepath.Base(id)+".txt")
+}
+
+// writeWatchText stores the last seen text; callers must hold watchMutex
+func (a *App) writeWatchText(id, text string) error {
+	if err := os.MkdirAll(a.changesDir(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(a.watchTextPath(id), []byte(text), 0644)
+}
+
+// readChanges reads the change feed of a bookmark; callers must hold watchMutex
+func (a *App) readChanges(id string) ([]PageChange, error) {
+	data, err := os.ReadFile(filepath.Join(a.changesDir(), filepath.Base(id)+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []PageChange{}, nil
+		}
+		return nil, err
+	}
+
+	var changes []PageChange
+	err = json.Unmarshal(data, &changes)
+	return changes, err
+}
+
+// appendChange adds a change to the front of the feed, dropping the
+// oldest entries; callers must hold watchMutex
+func (a *App) appendChange(id string, change *PageChange) error {
+	changes, err := a.readChanges(id)
+	if err != nil {
+		return err
+	}
+
+	changes = append([]PageChange{*change}, changes...)
+	if len(changes) > watchMaxChanges {
+		changes = changes[:watchMaxChanges]
+	}
+
+	data, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.changesDir(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(a.changesDir(), filepath.Base(id)+".json"), data, 0644)
+}
+
+// startWatchScheduler checks watched pages whose interval has passed
+func (a *App) startWatchScheduler(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			urls, err := a.GetURLs()
+			if err != nil {
+				continue
+			}
+			for _, item := range urls {
+				watch := item.Watch
+				if watch == nil || !watch.Enabled {
+					continue
+				}
+				due := watch.LastCheckedAt.Add(time.Duration(watch.IntervalMinutes) * time.Minute)
+				if time.Now().Before(due) {
+					continue
+				}
+				a.checkWatchedPage(item)
+			}
+		}
+	}()
+}
+
+// fetchWatchedText downloads a page and extracts the watched text
+func fetchWatchedText(ctx context.Context, client *http.Client, rawURL, selector string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", AppName, strings.TrimPrefix(Version, "v")))
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, snapshotMaxResource))
+	if err != nil {
+		return "", err
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	text, err := decodeHTML(body, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	// Plain text and JSON endpoints are compared as they are
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "" &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return normalizeText(text), nil
+	}
+
+	return extractWatchedText(text, selector)
+}
+
+// extractWatchedText returns the text of the elements matching the
+// selector, or of the main content of the page when there is none
+func extractWatchedText(page, selector string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return "", err
+	}
+
+	var roots []*html.Node
+	if selector != "" {
+		sel, err := parseSelector(selector)
+		if err != nil {
+			return "", err
+		}
+		roots = sel.matchAll(doc)
+		if len(roots) == 0 {
+			return "", fmt.Errorf("selector %q matches nothing", selector)
+		}
+	} else {
+		roots = []*html.Node{mainContent(doc)}
+	}
+
+	var b strings.Builder
+	for _, root := range roots {
+		collectText(&b, root)
+		b.WriteString("\n")
+	}
+	return normalizeText(b.String()), nil
+}
+
+// mainContent guesses the element holding the main content of a page
+func mainContent(doc *html.Node) *html.Node {
+	if main := findElement(doc, atom.Main); main != nil {
+		return main
+	}
+	if article := findElement(doc, atom.Article); article != nil {
+		return article
+	}
+	if sel, err := parseSelector(`[role=main]`); err == nil {
+		if matches := sel.matchAll(doc); len(matches) > 0 {
+			return matches[0]
+		}
+	}
+	if body := findElement(doc, atom.Body); body != nil {
+		return body
+	}
+	return doc
+}
+
+// collectText appends the visible text of a node, with line breaks
+// between block elements. Navigation and other page chrome is skipped.
+func collectText(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		switch n.DataAtom {
+		case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg,
+			atom.Nav, atom.Header, atom.Footer, atom.Aside, atom.Form, atom.Iframe:
+			return
+		case atom.Br:
+			b.WriteString("\n")
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && isBlockElement(n.DataAtom)
+	if block {
+		b.WriteString("\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		collectText(b, c)
+	}
+	if block {
+		b.WriteString("\n")
+	}
+}
+
+// isBlockElement reports whether an element starts a new line of text
+func isBlockElement(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Li, atom.Ul, atom.Ol,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Pre, atom.Blockquote,
+		atom.Tr, atom.Table, atom.Dt, atom.Dd, atom.Dl, atom.Figcaption, atom.Hr:
+		return true
+	}
+	return false
+}
+
+// normalizeText collapses whitespace within lines and drops empty lines
+// so that formatting changes are not reported as content changes
+func normalizeText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newPageChange builds a change entry with a line diff of the texts
+func newPageChange(id, previous, current, previousHash, hash string) *PageChange {
+	change := &PageChange{
+		ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
+		URLID:        id,
+		DetectedAt:   time.Now(),
+		Hash:         hash,
+		PreviousHash: previousHash,
+	}
+	change.Diff = diffLines(splitLines(previous), splitLines(current), watchDiffContext)
+	for _, line := range change.Diff {
+		switch line.Op {
+		case "add":
+			change.Added++
+		case "remove":
+			change.Removed++
+		}
+	}
+	return change
+}
+
+// splitLines splits a text into at most watchMaxDiffLines lines
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > watchMaxDiffLines {
+		lines = lines[:watchMaxDiffLines]
+	}
+	return lines
+}
+
+// diffLines computes a line diff from the longest common subsequence and
+// keeps only the given number of unchanged lines around each change
+func diffLines(a, b []string, context int) []DiffLine {
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] is the LCS length of midA[i:] and midB[j:]
+	lcs := make([][]int32, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var all []DiffLine
+	for _, line := range a[:prefix] {
+		all = append(all, DiffLine{Op: "context", Text: line})
+	}
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			all = append(all, DiffLine{Op: "context", Text: midA[i]})
+			i++
+			j++
+		case i < len(midA) && (j == len(midB) || lcs[i+1][j] >= lcs[i][j+1]):
+			all = append(all, DiffLine{Op: "remove", Text: midA[i]})
+			i++
+		default:
+			all = append(all, DiffLine{Op: "add", Text: midB[j]})
+			j++
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		all = append(all, DiffLine{Op: "context", Text: line})
+	}
+
+	// Keep unchanged lines only near changes
+	keep := make([]bool, len(all))
+	for k, line := range all {
+		if line.Op == "context" {
+			continue
+		}
+		for m := k - context; m <= k+context; m++ {
+			if m >= 0 && m < len(all) {
+				keep[m] = true
+			}
+		}
+	}
+
+	var result []DiffLine
+	skipped := 0
+	for k, line := range all {
+		if keep[k] {
+			if skipped > 0 {
+				result = append(result, DiffLine{Op: "skip", Text: fmt.Sprintf("%d", skipped)})
+				skipped = 0
+			}
+			result = append(result, line)
+			continue
+		}
+		skipped++
+	}
+	if skipped > 0 && len(result) > 0 {
+		result = append(result, DiffLine{Op: "skip", Text: fmt.Sprintf("%d", skipped)})
+	}
+	return result
+}
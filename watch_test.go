@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    []DiffLine
+	}{
+		{name: "identical", a: "a\nb", b: "a\nb", context: 1},
+		{
+			name: "added line", a: "a\nc", b: "a\nb\nc", context: 1,
+			want: []DiffLine{{"context", "a"}, {"add", "b"}, {"context", "c"}},
+		},
+		{
+			name: "removed line", a: "a\nb\nc", b: "a\nc", context: 1,
+			want: []DiffLine{{"context", "a"}, {"remove", "b"}, {"context", "c"}},
+		},
+		{
+			name: "replaced line", a: "a\nb\nc", b: "a\nx\nc", context: 0,
+			want: []DiffLine{{"skip", "1"}, {"remove", "b"}, {"add", "x"}, {"skip", "1"}},
+		},
+		{
+			name: "distant context skipped", a: "1\n2\n3\n4\n5\n6\n7", b: "1\n2\n3\nfour\n5\n6\n7", context: 1,
+			want: []DiffLine{{"skip", "2"}, {"context", "3"}, {"remove", "4"}, {"add", "four"}, {"context", "5"}, {"skip", "2"}},
+		},
+		{
+			name: "unchanged lines between changes", a: "a\n1\n2\n3\n4\nb", b: "A\n1\n2\n3\n4\nB", context: 1,
+			want: []DiffLine{
+				{"remove", "a"}, {"add", "A"}, {"context", "1"}, {"skip", "2"},
+				{"context", "4"}, {"remove", "b"}, {"add", "B"},
+			},
+		},
+		{
+			name: "from empty", a: "", b: "a\nb", context: 3,
+			want: []DiffLine{{"add", "a"}, {"add", "b"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := diffLines(splitLines(test.a), splitLines(test.b), test.context)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("diffLines() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// startBlockedWatchCheck checks a watched bookmark against a page that is
+// held back until the returned function is called
+func startBlockedWatchCheck(t *testing.T, a *App, item URLItem) (release func() error) {
+	requested := make(chan struct{})
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-unblock
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><h1>Title</h1><p>Fresh text</p></body></html>"))
+	}))
+	t.Cleanup(server.Close)
+
+	item.URL = server.URL + "/"
+	if err := a.SaveURLs([]URLItem{item}); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.checkWatchedPage(item)
+		done <- err
+	}()
+	<-requested
+	return func() error {
+		close(unblock)
+		return <-done
+	}
+}
+
+func TestWatchCheckIgnoresReplacedSelector(t *testing.T) {
+	setTestHome(t)
+	a := NewApp()
+
+	item := URLItem{ID: "1", Title: "Page", Watch: &WatchSettings{Enabled: true, IntervalMinutes: 60, Selector: "p", LastHash: "old"}}
+	if err := os.MkdirAll(a.changesDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(a.watchTextPath(item.ID), []byte("Old text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	release := startBlockedWatchCheck(t, a, item)
+	if _, err := a.SetWatch(item.ID, true, 60, "h1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := release(); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := a.findURL(item.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Watch.LastHash != "" {
+		t.Errorf("last hash = %q, want the reset baseline", stored.Watch.LastHash)
+	}
+	changes, err := a.GetChanges(item.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("changes = %+v, want none", changes)
+	}
+	text, err := os.ReadFile(a.watchTextPath(item.ID))
+	if err != nil || !strings.Contains(string(text), "Old text") {
+		t.Errorf("stored text = %q (%v), want it unchanged", text, err)
+	}
+}
+
+func TestWatchCheckAfterDeleteLeavesNoFiles(t *testing.T) {
+	setTestHome(t)
+	a := NewApp()
+
+	item := URLItem{ID: "1", Title: "Page", Watch: &WatchSettings{Enabled: true, IntervalMinutes: 60}}
+	release := startBlockedWatchCheck(t, a, item)
+	if err := a.DeleteURL(item.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := release(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(a.watchTextPath(item.ID)); !os.IsNotExist(err) {
+		t.Errorf("page text of the deleted bookmark exists (%v)", err)
+	}
+}